@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/google/omnitop/internal/config"
@@ -15,24 +19,56 @@ import (
 func main() {
 	// Parse flags
 	mockMode := flag.Bool("mock", false, "Run in mock mode with simulated data")
+	mockGPUs := flag.Int("mock-gpus", 1, "Number of GPUs to simulate in mock mode")
 	configPath := flag.String("config", "profiles.json", "Path to configuration file")
 	flag.Parse()
 
 	// Load configuration
-	cfg, err := config.LoadConfig("profiles.json")
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
-		log.Printf("Warning: Failed to load profiles.json: %v. Using defaults.", err)
+		log.Printf("Warning: Failed to load %s: %v. Using defaults.", *configPath, err)
 		cfg = config.DefaultConfig()
 	}
 
+	// Subsystems without their own interval use refresh_interval; the
+	// poller runs at an interval dividing all of them and each subsystem
+	// skips the polls that fall between its own samples
+	refresh := time.Duration(cfg.RefreshInterval) * time.Millisecond
+	intervals := make(map[metrics.Subsystem]time.Duration)
+	for _, sub := range metrics.Subsystems {
+		intervals[sub] = refresh
+	}
+	for name, ms := range cfg.RefreshIntervals {
+		sub := metrics.Subsystem(name)
+		if !slices.Contains(metrics.Subsystems, sub) {
+			log.Printf("Warning: Unknown subsystem %q in refresh_intervals", name)
+			continue
+		}
+		if ms > 0 {
+			intervals[sub] = time.Duration(ms) * time.Millisecond
+		}
+	}
+	refresh, exact := ui.PollInterval(slices.Collect(maps.Values(intervals))...)
+	if !exact {
+		log.Printf("Warning: refresh_intervals have no common divisor of at least 50ms; intervals round up to multiples of %v", refresh)
+	}
+
 	// Initialize metrics provider
 	var provider metrics.Provider
 	if *mockMode {
 		log.Println("Starting in MOCK mode...")
-		provider = &metrics.MockProvider{}
+		provider = &metrics.MockProvider{GPUCount: *mockGPUs}
 	} else {
 		log.Println("Starting in REAL mode...")
-		provider = &metrics.RealProvider{}
+		provider = &metrics.RealProvider{
+			Intervals: intervals,
+			FilesystemFilter: metrics.FilesystemFilter{
+				ExcludeTypes:  cfg.Filesystems.ExcludeTypes,
+				ExcludeMounts: cfg.Filesystems.ExcludeMounts,
+			},
+			NetInterfaces:    cfg.Network.Interfaces,
+			CollectorTimeout: time.Duration(cfg.CollectorTimeout) * time.Millisecond,
+		}
 	}
 
 	if err := provider.Init(); err != nil {
@@ -40,12 +76,22 @@ func main() {
 	}
 	defer provider.Shutdown()
 
+	// Collect in the background. The poll loop must stop before the
+	// provider shuts down, which then waits for samples that overran
+	// their deadline
+	ctx, cancel := context.WithCancel(context.Background())
+	poller := ui.NewPoller(provider, refresh)
+	poller.Start(ctx)
+
 	// Create root model
-	root := ui.NewRootModel(provider, cfg)
+	root := ui.NewRootModel(poller, cfg)
 
 	// Start Bubble Tea program
 	p := tea.NewProgram(root, tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	cancel()
+	poller.Wait()
+	if err != nil {
 		fmt.Printf("Error running OmniTop: %v\n", err)
 		os.Exit(1)
 	}
@@ -3,6 +3,7 @@ module github.com/google/omnitop
 go 1.24.3
 
 require (
+	github.com/NVIDIA/go-nvml v0.12.4-0
 	github.com/charmbracelet/bubbles v0.21.1
 	github.com/charmbracelet/bubbletea v1.3.10
 	github.com/charmbracelet/lipgloss v1.1.0
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"slices"
+
+	"github.com/google/omnitop/internal/metrics"
 )
 
 // DefaultConfig returns the hardcoded default configuration.
@@ -16,6 +19,7 @@ func DefaultConfig() *ProfileConfiguration {
 			"cpu":     0.30,
 		},
 		RefreshInterval:  1000,
+		CollectorTimeout: 500,
 		MaxProcesses:     200,
 		GPUHistoryLength: 100,
 		ShowTooltips:     true,
@@ -26,6 +30,16 @@ func DefaultConfig() *ProfileConfiguration {
 			GPUTempCelsius:     85.0,
 			MemoryUsagePercent: 95.0,
 			DiskUsagePercent:   90.0,
+
+			CPUPressurePercent:    75.0,
+			MemoryPressurePercent: 20.0,
+			IOPressurePercent:     50.0,
+
+			LowBatteryPercent: 15.0,
+		},
+		Filesystems: FilesystemConfig{
+			ExcludeTypes:  slices.Clone(metrics.DefaultFilesystemFilter.ExcludeTypes),
+			ExcludeMounts: slices.Clone(metrics.DefaultFilesystemFilter.ExcludeMounts),
 		},
 	}
 }
@@ -59,3 +73,12 @@ func LoadConfig(path string) (*ProfileConfiguration, error) {
 
 	return cfg, nil
 }
+
+// SaveConfig writes the configuration to the specified path as indented JSON.
+func SaveConfig(path string, cfg *ProfileConfiguration) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
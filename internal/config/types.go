@@ -4,11 +4,29 @@ package config
 type ProfileConfiguration struct {
 	Theme            string             `json:"theme"`
 	ColumnWidths     map[string]float64 `json:"column_widths"`
-	RefreshInterval  int                `json:"refresh_interval"` // Milliseconds
+	RefreshInterval  int                `json:"refresh_interval"`            // Milliseconds
+	RefreshIntervals map[string]int     `json:"refresh_intervals,omitempty"` // Milliseconds per subsystem ("cpu", "gpu", "processes", ...), overriding RefreshInterval
+	CollectorTimeout int                `json:"collector_timeout"`           // Milliseconds each subsystem may take before it is shown stale
 	MaxProcesses     int                `json:"max_processes"`
 	GPUHistoryLength int                `json:"gpu_history_length"`
 	ShowTooltips     bool               `json:"show_tooltips"`
 	AlertThresholds  AlertThresholds    `json:"alert_thresholds"`
+	Disks            DiskConfig         `json:"disks"`
+	Filesystems      FilesystemConfig   `json:"filesystems"`
+	Network          NetworkConfig      `json:"network"`
+	Sensors          SensorConfig       `json:"sensors"`
+}
+
+// DiskConfig selects which block devices the disk panel lists. Whole
+// physical disks are always shown.
+type DiskConfig struct {
+	ShowPartitions bool `json:"show_partitions"`
+	ShowVirtual    bool `json:"show_virtual"` // device-mapper, md, loop, zram
+
+	// MaxSpeeds sets the throughput in MB/s that fills the Disk bars, per
+	// device name or glob pattern, for disks whose capability is unknown
+	// (SATA, virtio) or misreported.
+	MaxSpeeds map[string]uint64 `json:"max_speeds,omitempty"`
 }
 
 // AlertThresholds defines the limits for triggering alerts.
@@ -19,4 +37,44 @@ type AlertThresholds struct {
 	GPUTempCelsius     float64 `json:"gpu_temp_celsius"`
 	MemoryUsagePercent float64 `json:"memory_usage_percent"`
 	DiskUsagePercent   float64 `json:"disk_usage_percent"`
+	// Pressure stall thresholds apply to the "some" avg10 share of the
+	// matching /proc/pressure file. Zero disables the check.
+	CPUPressurePercent    float64 `json:"cpu_pressure_percent"`
+	MemoryPressurePercent float64 `json:"memory_pressure_percent"`
+	IOPressurePercent     float64 `json:"io_pressure_percent"`
+	// LowBatteryPercent alerts while discharging below this charge. Zero
+	// disables the check.
+	LowBatteryPercent float64 `json:"low_battery_percent"`
+}
+
+// FilesystemConfig selects which mounts the filesystems panel lists and
+// checks against DiskUsagePercent. A list left out or null keeps the
+// default; an empty list excludes nothing.
+type FilesystemConfig struct {
+	ExcludeTypes  []string `json:"exclude_types"`  // Filesystem types, e.g. "tmpfs"
+	ExcludeMounts []string `json:"exclude_mounts"` // Mount points, including everything below them
+}
+
+// NetworkConfig selects the interfaces counted in the Net bars and listed in
+// the network panel.
+type NetworkConfig struct {
+	// Interfaces holds glob patterns such as "eth*" or "wlp3s0". Empty counts
+	// every physical interface.
+	Interfaces  []string `json:"interfaces"`
+	ShowVirtual bool     `json:"show_virtual"` // List loopback, bridges, veths and tunnels
+
+	// MaxSpeeds sets the bandwidth in Mbit/s that fills the Net bars, per
+	// interface name or glob pattern, in place of the link speed (Wi-Fi
+	// reports none, and the uplink is often slower than the link).
+	MaxSpeeds map[string]uint64 `json:"max_speeds,omitempty"`
+}
+
+// SensorConfig renames and hides hardware sensors. Sensors are named
+// "chip/input" (e.g. "nvme-nvme0/temp1") or "chip/label" (e.g.
+// "nvme-nvme0/Composite"); the sensors panel lists them under their chip.
+type SensorConfig struct {
+	Rename map[string]string `json:"rename,omitempty"` // Display name by sensor name
+	// Hide holds glob patterns of sensor names. A pattern without a "/"
+	// hides a whole chip, e.g. "acpitz*".
+	Hide []string `json:"hide,omitempty"`
 }
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Subsystem identifies a group of metrics that is sampled as a unit.
+type Subsystem string
+
+const (
+	SubsystemCPU         Subsystem = "cpu" // Times, clocks, temperatures, power, load, pressure and kernel activity
+	SubsystemMemory      Subsystem = "memory"
+	SubsystemDisks       Subsystem = "disks"
+	SubsystemFilesystems Subsystem = "filesystems"
+	SubsystemNetwork     Subsystem = "network"
+	SubsystemSensors     Subsystem = "sensors" // hwmon chips and batteries
+	SubsystemGPU         Subsystem = "gpu"
+	SubsystemProcesses   Subsystem = "processes"
+)
+
+// Subsystems lists every subsystem in collection order.
+var Subsystems = []Subsystem{
+	SubsystemCPU, SubsystemSensors, SubsystemMemory, SubsystemDisks,
+	SubsystemFilesystems, SubsystemNetwork, SubsystemGPU, SubsystemProcesses,
+}
+
+// DefaultCollectorTimeout is how long a subsystem may take to sample before
+// it is reported stale.
+const DefaultCollectorTimeout = 500 * time.Millisecond
+
+// collector samples one subsystem under a deadline, so a hung read (NVML,
+// statfs on a dead NFS mount) only stalls its own subsystem.
+type collector struct {
+	interval time.Duration // Zero samples on every call
+	reset    func()        // Drops the rate baselines, nil without rates
+
+	mu        sync.Mutex
+	running   bool
+	finished  chan struct{}      // Closed when the running sample returns
+	resync    bool               // Call reset before the next sample
+	lastStart time.Time          // Start of the last sample
+	latest    func(*SystemStats) // Applies the most recent completed sample
+}
+
+// due reports whether the subsystem's interval has passed. Polls land on
+// wall clock boundaries give or take scheduling delay, so a tenth of the
+// interval is allowed for a sample that fires early.
+func (c *collector) due(now time.Time) bool {
+	return c.lastStart.IsZero() || now.Sub(c.lastStart) >= c.interval-c.interval/10
+}
+
+// resyncRates makes the next sample start its rates over, as after a
+// suspend the counters no longer line up with the clock.
+func (c *collector) resyncRates() {
+	c.mu.Lock()
+	c.resync = true
+	c.mu.Unlock()
+}
+
+// collect runs sample, which reads the subsystem and returns a function
+// applying the values read, and applies them to stats. Between intervals
+// the latest values are applied without sampling. When sample misses
+// the deadline, or is still running from an earlier call, the latest
+// completed values are applied instead and collect reports them stale.
+//
+// A sample that misses its deadline keeps running in the background and
+// becomes the latest when it returns. No new one starts meanwhile, since
+// the trackers behind a subsystem are not safe for concurrent use.
+func (c *collector) collect(ctx context.Context, now time.Time, timeout time.Duration, stats *SystemStats, sample func() func(*SystemStats)) (stale bool) {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		c.applyLatest(stats)
+		return true
+	}
+	if !c.due(now) {
+		c.mu.Unlock()
+		c.applyLatest(stats)
+		return false
+	}
+	c.running = true
+	c.finished = make(chan struct{})
+	c.lastStart = now
+	resync := c.resync && c.reset != nil
+	c.resync = false
+	c.mu.Unlock()
+
+	done := make(chan func(*SystemStats), 1)
+	go func() {
+		if resync {
+			c.reset()
+		}
+		apply := sample()
+		c.mu.Lock()
+		c.latest = apply
+		c.running = false
+		close(c.finished)
+		c.mu.Unlock()
+		done <- apply
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	select {
+	case apply := <-done:
+		apply(stats)
+		return false
+	case <-ctx.Done():
+		c.applyLatest(stats)
+		return true
+	}
+}
+
+// wait blocks until no sample is running, or until the deadline, and
+// reports whether the collector is idle.
+func (c *collector) wait(deadline time.Time) bool {
+	c.mu.Lock()
+	running, finished := c.running, c.finished
+	c.mu.Unlock()
+	if !running {
+		return true
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-finished:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (c *collector) applyLatest(stats *SystemStats) {
+	c.mu.Lock()
+	latest := c.latest
+	c.mu.Unlock()
+	if latest != nil {
+		latest(stats)
+	}
+}
+
+// suspendGap is how far the wall clock may run ahead of the monotonic clock
+// between two samples before it counts as a suspend rather than an NTP
+// adjustment.
+const suspendGap = 2 * time.Second
+
+// suspended reports whether the machine slept between two samples, given
+// the time between them on the wall clock and on the monotonic clock. The
+// wall clock keeps running through a suspend; the monotonic clock, which
+// time.Time.Sub uses, does not.
+func suspended(wall, elapsed time.Duration) bool {
+	return wall-elapsed > suspendGap
+}
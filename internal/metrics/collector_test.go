@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCollectorTimeout(t *testing.T) {
+	var c collector
+	ctx := context.Background()
+	sampleUptime := func(uptime uint64, release chan struct{}) func() func(*SystemStats) {
+		return func() func(*SystemStats) {
+			if release != nil {
+				<-release
+			}
+			return func(s *SystemStats) { s.Uptime = uptime }
+		}
+	}
+
+	var stats SystemStats
+	if stale := c.collect(ctx, time.Now(), time.Second, &stats, sampleUptime(1, nil)); stale || stats.Uptime != 1 {
+		t.Fatalf("Expected a fresh sample, got stale=%v uptime=%d", stale, stats.Uptime)
+	}
+
+	// A hung sample is reported stale with the previous values
+	release := make(chan struct{})
+	stats = SystemStats{}
+	if stale := c.collect(ctx, time.Now(), 10*time.Millisecond, &stats, sampleUptime(2, release)); !stale || stats.Uptime != 1 {
+		t.Fatalf("Expected the last values marked stale, got stale=%v uptime=%d", stale, stats.Uptime)
+	}
+
+	// No second sample starts while it is still running
+	started := false
+	stats = SystemStats{}
+	stale := c.collect(ctx, time.Now(), time.Second, &stats, func() func(*SystemStats) {
+		started = true
+		return func(*SystemStats) {}
+	})
+	if !stale || started || stats.Uptime != 1 {
+		t.Fatalf("Expected stale values without a new sample, got stale=%v started=%v", stale, started)
+	}
+
+	if c.wait(time.Now().Add(10 * time.Millisecond)) {
+		t.Fatal("Expected wait to time out while the sample hangs")
+	}
+
+	// Once the hung sample returns, its values are the latest
+	close(release)
+	if !c.wait(time.Now().Add(time.Second)) {
+		t.Fatal("Expected the sample to finish")
+	}
+	stats = SystemStats{}
+	if stale := c.collect(ctx, time.Now(), time.Second, &stats, sampleUptime(3, nil)); stale || stats.Uptime != 3 {
+		t.Fatalf("Expected a fresh sample after recovery, got stale=%v uptime=%d", stale, stats.Uptime)
+	}
+
+	// Cancellation abandons a sample that is still running
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	release = make(chan struct{})
+	defer close(release)
+	if stale := c.collect(cancelled, time.Now(), time.Hour, &stats, sampleUptime(4, release)); !stale {
+		t.Error("Expected a cancelled collection to be stale")
+	}
+}
+
+func TestCollectorInterval(t *testing.T) {
+	resets := 0
+	c := collector{interval: time.Second, reset: func() { resets++ }}
+	ctx := context.Background()
+	samples := 0
+	sample := func() func(*SystemStats) {
+		samples++
+		uptime := uint64(samples)
+		return func(s *SystemStats) { s.Uptime = uptime }
+	}
+
+	start := time.Now()
+	tests := []struct {
+		name       string
+		at         time.Duration
+		wantUptime uint64
+	}{
+		{"first call samples", 0, 1},
+		{"between intervals reuses the latest", 250 * time.Millisecond, 1},
+		{"slightly early poll samples", 950 * time.Millisecond, 2},
+		{"next interval not reached", 1500 * time.Millisecond, 2},
+		{"next interval", 2 * time.Second, 3},
+	}
+	for _, tt := range tests {
+		var stats SystemStats
+		if stale := c.collect(ctx, start.Add(tt.at), time.Second, &stats, sample); stale || stats.Uptime != tt.wantUptime {
+			t.Errorf("%s: got stale=%v uptime=%d, want fresh uptime=%d", tt.name, stale, stats.Uptime, tt.wantUptime)
+		}
+	}
+	if resets != 0 {
+		t.Errorf("Expected no reset without a resync, got %d", resets)
+	}
+
+	// A resync resets the rate baselines once, on the next sample
+	c.resyncRates()
+	var stats SystemStats
+	c.collect(ctx, start.Add(2500*time.Millisecond), time.Second, &stats, sample)
+	if resets != 0 {
+		t.Errorf("Expected the reset to wait for a due sample, got %d", resets)
+	}
+	c.collect(ctx, start.Add(3*time.Second), time.Second, &stats, sample)
+	c.collect(ctx, start.Add(4*time.Second), time.Second, &stats, sample)
+	if resets != 1 {
+		t.Errorf("Expected one reset, got %d", resets)
+	}
+}
+
+func TestSuspended(t *testing.T) {
+	tests := []struct {
+		name          string
+		wall, elapsed time.Duration
+		want          bool
+	}{
+		{"clocks agree", time.Second, time.Second, false},
+		{"NTP slew", 1010 * time.Millisecond, time.Second, false},
+		{"wall clock stepped back", -time.Hour, time.Second, false},
+		{"slept an hour", time.Hour + time.Second, time.Second, true},
+	}
+	for _, tt := range tests {
+		if got := suspended(tt.wall, tt.elapsed); got != tt.want {
+			t.Errorf("%s: suspended(%v, %v) = %v, want %v", tt.name, tt.wall, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+// blockingGPUBackend hangs in DeviceCount until released, like a wedged
+// driver, and records whether it was shut down in the middle of it.
+type blockingGPUBackend struct {
+	FakeGPUBackend
+	release  chan struct{}
+	sampling atomic.Bool
+	torn     atomic.Bool // Shutdown ran during DeviceCount
+}
+
+func (b *blockingGPUBackend) DeviceCount() (int, error) {
+	b.sampling.Store(true)
+	defer b.sampling.Store(false)
+	<-b.release
+	return b.FakeGPUBackend.DeviceCount()
+}
+
+func (b *blockingGPUBackend) Shutdown() {
+	b.torn.Store(b.sampling.Load())
+	b.FakeGPUBackend.Shutdown()
+}
+
+func TestRealProviderShutdownWaitsForSamples(t *testing.T) {
+	backend := &blockingGPUBackend{
+		FakeGPUBackend: FakeGPUBackend{Frames: [][]FakeGPUState{{{Name: "Wedged"}}}},
+		release:        make(chan struct{}),
+	}
+	provider := &RealProvider{GPUBackend: backend, CollectorTimeout: 10 * time.Millisecond}
+	if err := provider.Init(); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.Stale[SubsystemGPU] {
+		t.Fatal("Expected the GPU sample to miss its deadline")
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(backend.release)
+	}()
+	provider.Shutdown()
+	if !backend.IsShutdown() || backend.torn.Load() {
+		t.Errorf("Expected the backend shut down after the sample returned, shutdown=%v during sample=%v", backend.IsShutdown(), backend.torn.Load())
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readCoreFreqs reads the cpufreq policy state of every logical CPU. It
+// returns nil when the kernel exposes no cpufreq (most VMs).
+func readCoreFreqs(sysfsRoot string, cpus int) []CoreFreq {
+	freqs := make([]CoreFreq, cpus)
+	found := false
+	for cpu := 0; cpu < cpus; cpu++ {
+		dir := filepath.Join(sysfsRoot, "devices", "system", "cpu", "cpu"+strconv.Itoa(cpu), "cpufreq")
+		khz := func(name string) uint32 {
+			v, err := readSysfsUint(filepath.Join(dir, name))
+			if err != nil {
+				return 0
+			}
+			return uint32(v / 1000)
+		}
+
+		f := CoreFreq{
+			Cur:   khz("scaling_cur_freq"),
+			Min:   khz("scaling_min_freq"),
+			Max:   khz("scaling_max_freq"),
+			HWMax: khz("cpuinfo_max_freq"),
+		}
+		f.Governor, _ = readSysfsString(filepath.Join(dir, "scaling_governor"))
+		f.EPP, _ = readSysfsString(filepath.Join(dir, "energy_performance_preference"))
+		if f.Cur > 0 || f.Governor != "" {
+			found = true
+		}
+		freqs[cpu] = f
+	}
+	if !found {
+		return nil
+	}
+	return freqs
+}
+
+// readCoreTypes classifies logical CPUs on hybrid Intel parts, where the
+// kernel registers separate PMUs for performance (cpu_core) and efficiency
+// (cpu_atom) cores. It returns nil on non-hybrid CPUs.
+func readCoreTypes(sysfsRoot string, cpus int) []CoreType {
+	pmus := []struct {
+		dir  string
+		kind CoreType
+	}{
+		{"cpu_core", CoreTypePerformance},
+		{"cpu_atom", CoreTypeEfficiency},
+	}
+
+	types := make([]CoreType, cpus)
+	found := 0
+	for _, pmu := range pmus {
+		list, err := readSysfsString(filepath.Join(sysfsRoot, "devices", pmu.dir, "cpus"))
+		if err != nil {
+			continue
+		}
+		found++
+		for _, cpu := range parseCPUList(list) {
+			if cpu < cpus {
+				types[cpu] = pmu.kind
+			}
+		}
+	}
+	if found < len(pmus) {
+		return nil
+	}
+	return types
+}
+
+// parseCPUList expands a kernel CPU list such as "0-3,8,10-11".
+func parseCPUList(list string) []int {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil {
+				continue
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCPUList(t *testing.T) {
+	cases := map[string][]int{
+		"0-3":         {0, 1, 2, 3},
+		"0-1,8,10-11": {0, 1, 8, 10, 11},
+		"5\n":         {5},
+		"":            nil,
+	}
+	for in, want := range cases {
+		if got := parseCPUList(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseCPUList(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestCoreFreqsAndTypes(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		// Alder Lake style: cpu0-1 are P-cores, cpu2 an E-core
+		"devices/cpu_core/cpus": "0-1\n",
+		"devices/cpu_atom/cpus": "2\n",
+
+		"devices/system/cpu/cpu0/cpufreq/scaling_cur_freq":              "4800000\n",
+		"devices/system/cpu/cpu0/cpufreq/scaling_min_freq":              "800000\n",
+		"devices/system/cpu/cpu0/cpufreq/scaling_max_freq":              "5000000\n",
+		"devices/system/cpu/cpu0/cpufreq/cpuinfo_max_freq":              "5000000\n",
+		"devices/system/cpu/cpu0/cpufreq/scaling_governor":              "powersave\n",
+		"devices/system/cpu/cpu0/cpufreq/energy_performance_preference": "balance_performance\n",
+		// Capped by policy below the hardware maximum
+		"devices/system/cpu/cpu1/cpufreq/scaling_cur_freq": "2000000\n",
+		"devices/system/cpu/cpu1/cpufreq/scaling_max_freq": "2000000\n",
+		"devices/system/cpu/cpu1/cpufreq/cpuinfo_max_freq": "5000000\n",
+		"devices/system/cpu/cpu1/cpufreq/scaling_governor": "powersave\n",
+		"devices/system/cpu/cpu2/cpufreq/scaling_cur_freq": "3500000\n",
+		"devices/system/cpu/cpu2/cpufreq/scaling_governor": "powersave\n",
+	})
+
+	types := readCoreTypes(root, 3)
+	want := []CoreType{CoreTypePerformance, CoreTypePerformance, CoreTypeEfficiency}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("Core types = %v, want %v", types, want)
+	}
+
+	freqs := readCoreFreqs(root, 3)
+	if len(freqs) != 3 {
+		t.Fatalf("Expected 3 cores, got %d", len(freqs))
+	}
+	p := freqs[0]
+	if p.Cur != 4800 || p.Min != 800 || p.Max != 5000 || p.Governor != "powersave" || p.EPP != "balance_performance" {
+		t.Errorf("Unexpected cpu0 state %+v", p)
+	}
+	if p.Capped() || !freqs[1].Capped() {
+		t.Errorf("Expected only cpu1 to be capped: %+v", freqs)
+	}
+
+	stats := CPUStats{PerCoreFreq: freqs}
+	if avg := stats.AverageFreq(); avg != 3433 {
+		t.Errorf("Expected average 3433 MHz, got %d", avg)
+	}
+}
+
+func TestCoreFreqsUnavailable(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		// Only one PMU: not a hybrid part
+		"devices/cpu_core/cpus": "0-3\n",
+	})
+	if types := readCoreTypes(root, 4); types != nil {
+		t.Errorf("Expected no core types, got %v", types)
+	}
+	if freqs := readCoreFreqs(root, 4); freqs != nil {
+		t.Errorf("Expected no cpufreq data, got %v", freqs)
+	}
+}
@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cpuTempSensors maps temperature inputs onto logical CPUs. Discovery walks
+// hwmon and topology once; each sample only reads the *_input files.
+type cpuTempSensors struct {
+	pkgs  []string // Package (or die) temperature inputs, one per socket
+	cores []string // Per logical CPU input; "" falls back to the package
+}
+
+// Thermal zone types that track CPU temperature, in order of preference.
+// acpitz is a board sensor and only used when nothing better exists.
+var cpuThermalZoneTypes = []string{"x86_pkg_temp", "cpu-thermal", "cpu_thermal", "soc_thermal", "acpitz"}
+
+// discoverCPUTemps finds the best CPU temperature sources below sysfsRoot
+// for a host with the given number of logical CPUs. It returns nil when no
+// source exists.
+func discoverCPUTemps(sysfsRoot string, cpus int) *cpuTempSensors {
+	hwmons, _ := filepath.Glob(filepath.Join(sysfsRoot, "class", "hwmon", "hwmon*"))
+	sort.Strings(hwmons)
+
+	var coretemp, amd []string
+	for _, dir := range hwmons {
+		name, _ := readSysfsString(filepath.Join(dir, "name"))
+		switch name {
+		case "coretemp":
+			coretemp = append(coretemp, dir)
+		case "k10temp", "zenpower":
+			amd = append(amd, dir)
+		}
+	}
+
+	switch {
+	case len(coretemp) > 0:
+		return coretempSensors(sysfsRoot, coretemp, cpus)
+	case len(amd) > 0:
+		return amdTempSensors(sysfsRoot, amd, cpus)
+	}
+	if zone := cpuThermalZone(sysfsRoot); zone != "" {
+		return &cpuTempSensors{pkgs: []string{zone}, cores: make([]string, cpus)}
+	}
+	return nil
+}
+
+// hwmonTempLabels returns the temp*_input files of a hwmon directory keyed by
+// their label.
+func hwmonTempLabels(dir string) map[string]string {
+	inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+	labels := make(map[string]string, len(inputs))
+	for _, input := range inputs {
+		label, err := readSysfsString(strings.TrimSuffix(input, "_input") + "_label")
+		if err != nil {
+			continue
+		}
+		labels[label] = input
+	}
+	return labels
+}
+
+// cpuTopology reads a topology attribute (core_id, physical_package_id) of a
+// logical CPU, returning -1 when unavailable.
+func cpuTopology(sysfsRoot string, cpu int, attr string) int {
+	v, err := readSysfsUint(filepath.Join(sysfsRoot, "devices", "system", "cpu", "cpu"+strconv.Itoa(cpu), "topology", attr))
+	if err != nil {
+		return -1
+	}
+	return int(v)
+}
+
+// coretempSensors maps Intel coretemp "Core N" inputs to logical CPUs via
+// their physical package and core id. Each package has its own hwmon.
+func coretempSensors(sysfsRoot string, dirs []string, cpus int) *cpuTempSensors {
+	type coreKey struct{ pkg, core int }
+	byCore := make(map[coreKey]string)
+	sensors := &cpuTempSensors{cores: make([]string, cpus)}
+
+	for i, dir := range dirs {
+		labels := hwmonTempLabels(dir)
+		pkg := i
+		for label, input := range labels {
+			if id, ok := strings.CutPrefix(label, "Package id "); ok {
+				if n, err := strconv.Atoi(id); err == nil {
+					pkg = n
+				}
+				sensors.pkgs = append(sensors.pkgs, input)
+			}
+		}
+		for label, input := range labels {
+			if id, ok := strings.CutPrefix(label, "Core "); ok {
+				if n, err := strconv.Atoi(id); err == nil {
+					byCore[coreKey{pkg, n}] = input
+				}
+			}
+		}
+	}
+
+	for cpu := 0; cpu < cpus; cpu++ {
+		pkg := cpuTopology(sysfsRoot, cpu, "physical_package_id")
+		if pkg < 0 {
+			pkg = 0
+		}
+		sensors.cores[cpu] = byCore[coreKey{pkg, cpuTopology(sysfsRoot, cpu, "core_id")}]
+	}
+	return sensors
+}
+
+// amdTempSensors uses Tdie (or Tctl, which may carry an offset) of every
+// socket for the package. On single-socket hosts the per-CCD Tccd inputs are
+// mapped to cores through their L3 cache, since every CCD has its own L3.
+func amdTempSensors(sysfsRoot string, dirs []string, cpus int) *cpuTempSensors {
+	sensors := &cpuTempSensors{cores: make([]string, cpus)}
+	for _, dir := range dirs {
+		labels := hwmonTempLabels(dir)
+		switch {
+		case labels["Tdie"] != "":
+			sensors.pkgs = append(sensors.pkgs, labels["Tdie"])
+		case labels["Tctl"] != "":
+			sensors.pkgs = append(sensors.pkgs, labels["Tctl"])
+		default:
+			// Older k10temp without labels
+			sensors.pkgs = append(sensors.pkgs, filepath.Join(dir, "temp1_input"))
+		}
+	}
+	if len(dirs) > 1 {
+		return sensors
+	}
+
+	labels := hwmonTempLabels(dirs[0])
+
+	var ccds []string
+	for i := 1; ; i++ {
+		input, ok := labels["Tccd"+strconv.Itoa(i)]
+		if !ok {
+			break
+		}
+		ccds = append(ccds, input)
+	}
+	if len(ccds) == 0 {
+		return sensors
+	}
+
+	l3 := make([]int, cpus)
+	var ids []int
+	for cpu := 0; cpu < cpus; cpu++ {
+		l3[cpu] = -1
+		v, err := readSysfsUint(filepath.Join(sysfsRoot, "devices", "system", "cpu", "cpu"+strconv.Itoa(cpu), "cache", "index3", "id"))
+		if err != nil {
+			continue
+		}
+		l3[cpu] = int(v)
+		if !slices.Contains(ids, int(v)) {
+			ids = append(ids, int(v))
+		}
+	}
+	// Only trust the mapping when there is one L3 per reported CCD
+	if len(ids) != len(ccds) {
+		return sensors
+	}
+	sort.Ints(ids)
+	for cpu, id := range l3 {
+		if i := sort.SearchInts(ids, id); id >= 0 && i < len(ids) && ids[i] == id {
+			sensors.cores[cpu] = ccds[i]
+		}
+	}
+	return sensors
+}
+
+// cpuThermalZone returns the temp file of the preferred CPU thermal zone.
+func cpuThermalZone(sysfsRoot string) string {
+	zones, _ := filepath.Glob(filepath.Join(sysfsRoot, "class", "thermal", "thermal_zone*"))
+	sort.Strings(zones)
+	best, bestRank := "", len(cpuThermalZoneTypes)
+	for _, zone := range zones {
+		kind, err := readSysfsString(filepath.Join(zone, "type"))
+		if err != nil {
+			continue
+		}
+		for rank, want := range cpuThermalZoneTypes {
+			if kind == want && rank < bestRank {
+				best, bestRank = filepath.Join(zone, "temp"), rank
+			}
+		}
+	}
+	return best
+}
+
+// read returns per-CPU temperatures and the hottest package temperature in
+// Celsius. CPUs without a dedicated sensor report the package temperature.
+func (s *cpuTempSensors) read() ([]float64, float64) {
+	readC := func(path string) (float64, bool) {
+		if path == "" {
+			return 0, false
+		}
+		milli, err := readSysfsUint(path)
+		if err != nil {
+			return 0, false
+		}
+		return float64(milli) / 1000, true
+	}
+
+	var pkg float64
+	pkgOK := false
+	for _, path := range s.pkgs {
+		if t, ok := readC(path); ok {
+			pkg, pkgOK = math.Max(pkg, t), true
+		}
+	}
+	temps := make([]float64, len(s.cores))
+	for i, path := range s.cores {
+		if t, ok := readC(path); ok {
+			temps[i] = t
+			if !pkgOK && t > pkg {
+				pkg = t // No package sensor: use the hottest core
+			}
+		}
+	}
+	for i, path := range s.cores {
+		if path == "" || temps[i] == 0 {
+			temps[i] = pkg
+		}
+	}
+	return temps, pkg
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestCPUTempsCoretemp(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		// Unrelated chip listed first
+		"class/hwmon/hwmon0/name":        "acpitz\n",
+		"class/hwmon/hwmon0/temp1_input": "27800\n",
+		// 2 cores with SMT: cpu0/cpu2 on core 0, cpu1/cpu3 on core 4
+		"class/hwmon/hwmon3/name":        "coretemp\n",
+		"class/hwmon/hwmon3/temp1_label": "Package id 0\n",
+		"class/hwmon/hwmon3/temp1_input": "61000\n",
+		"class/hwmon/hwmon3/temp2_label": "Core 0\n",
+		"class/hwmon/hwmon3/temp2_input": "55000\n",
+		"class/hwmon/hwmon3/temp6_label": "Core 4\n",
+		"class/hwmon/hwmon3/temp6_input": "59000\n",
+
+		"devices/system/cpu/cpu0/topology/core_id":             "0\n",
+		"devices/system/cpu/cpu0/topology/physical_package_id": "0\n",
+		"devices/system/cpu/cpu1/topology/core_id":             "4\n",
+		"devices/system/cpu/cpu1/topology/physical_package_id": "0\n",
+		"devices/system/cpu/cpu2/topology/core_id":             "0\n",
+		"devices/system/cpu/cpu2/topology/physical_package_id": "0\n",
+		"devices/system/cpu/cpu3/topology/core_id":             "4\n",
+		"devices/system/cpu/cpu3/topology/physical_package_id": "0\n",
+	})
+
+	sensors := discoverCPUTemps(root, 4)
+	if sensors == nil {
+		t.Fatal("Expected coretemp sensors")
+	}
+	temps, pkg := sensors.read()
+	want := []float64{55, 59, 55, 59}
+	for i := range want {
+		if temps[i] != want[i] {
+			t.Errorf("cpu%d: got %.1f°C, want %.1f°C", i, temps[i], want[i])
+		}
+	}
+	if pkg != 61 {
+		t.Errorf("Expected package 61°C, got %.1f", pkg)
+	}
+}
+
+func TestCPUTempsCoretempMultiSocket(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"class/hwmon/hwmon1/name":        "coretemp\n",
+		"class/hwmon/hwmon1/temp1_label": "Package id 0\n",
+		"class/hwmon/hwmon1/temp1_input": "50000\n",
+		"class/hwmon/hwmon1/temp2_label": "Core 0\n",
+		"class/hwmon/hwmon1/temp2_input": "48000\n",
+		"class/hwmon/hwmon2/name":        "coretemp\n",
+		"class/hwmon/hwmon2/temp1_label": "Package id 1\n",
+		"class/hwmon/hwmon2/temp1_input": "72000\n",
+		"class/hwmon/hwmon2/temp2_label": "Core 0\n",
+		"class/hwmon/hwmon2/temp2_input": "70000\n",
+
+		"devices/system/cpu/cpu0/topology/core_id":             "0\n",
+		"devices/system/cpu/cpu0/topology/physical_package_id": "0\n",
+		"devices/system/cpu/cpu1/topology/core_id":             "0\n",
+		"devices/system/cpu/cpu1/topology/physical_package_id": "1\n",
+	})
+
+	temps, pkg := discoverCPUTemps(root, 2).read()
+	if temps[0] != 48 || temps[1] != 70 {
+		t.Errorf("Expected per-socket core temps 48/70, got %v", temps)
+	}
+	if pkg != 72 {
+		t.Errorf("Expected hottest package 72°C, got %.1f", pkg)
+	}
+}
+
+func TestCPUTempsK10temp(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		// Ryzen 9 with two CCDs
+		"class/hwmon/hwmon2/name":                 "k10temp\n",
+		"class/hwmon/hwmon2/temp1_label":          "Tctl\n",
+		"class/hwmon/hwmon2/temp1_input":          "68500\n",
+		"class/hwmon/hwmon2/temp3_label":          "Tccd1\n",
+		"class/hwmon/hwmon2/temp3_input":          "60250\n",
+		"class/hwmon/hwmon2/temp4_label":          "Tccd2\n",
+		"class/hwmon/hwmon2/temp4_input":          "52000\n",
+		"devices/system/cpu/cpu0/cache/index3/id": "0\n",
+		"devices/system/cpu/cpu1/cache/index3/id": "0\n",
+		"devices/system/cpu/cpu2/cache/index3/id": "1\n",
+		"devices/system/cpu/cpu3/cache/index3/id": "1\n",
+	})
+
+	temps, pkg := discoverCPUTemps(root, 4).read()
+	want := []float64{60.25, 60.25, 52, 52}
+	for i := range want {
+		if temps[i] != want[i] {
+			t.Errorf("cpu%d: got %.2f°C, want %.2f°C", i, temps[i], want[i])
+		}
+	}
+	if pkg != 68.5 {
+		t.Errorf("Expected Tctl 68.5°C, got %.1f", pkg)
+	}
+}
+
+func TestCPUTempsZenpowerPackageOnly(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"class/hwmon/hwmon1/name":        "zenpower\n",
+		"class/hwmon/hwmon1/temp1_label": "Tdie\n",
+		"class/hwmon/hwmon1/temp1_input": "45000\n",
+		"class/hwmon/hwmon1/temp2_label": "Tctl\n",
+		"class/hwmon/hwmon1/temp2_input": "55000\n",
+	})
+
+	temps, pkg := discoverCPUTemps(root, 2).read()
+	if pkg != 45 || temps[0] != 45 || temps[1] != 45 {
+		t.Errorf("Expected Tdie 45°C everywhere, got %v / %.1f", temps, pkg)
+	}
+}
+
+func TestCPUTempsThermalZoneFallback(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		// Raspberry Pi style: no hwmon CPU driver
+		"class/thermal/thermal_zone0/type": "acpitz\n",
+		"class/thermal/thermal_zone0/temp": "30000\n",
+		"class/thermal/thermal_zone1/type": "cpu-thermal\n",
+		"class/thermal/thermal_zone1/temp": "47200\n",
+	})
+
+	temps, pkg := discoverCPUTemps(root, 4).read()
+	if pkg != 47.2 || len(temps) != 4 || temps[3] != 47.2 {
+		t.Errorf("Expected cpu-thermal 47.2°C, got %v / %.1f", temps, pkg)
+	}
+
+	if discoverCPUTemps(t.TempDir(), 4) != nil {
+		t.Error("Expected no sensors on an empty sysfs")
+	}
+}
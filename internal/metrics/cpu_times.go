@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// cpuTimesPercent converts the difference between two cumulative /proc/stat
+// samples into a per-mode percentage breakdown. Pass a zero prev to get the
+// average since boot.
+func cpuTimesPercent(prev, cur cpu.TimesStat) CPUTimes {
+	// The kernel already counts guest time inside user (and guest_nice inside
+	// nice), so those are split out rather than added on top
+	user := (cur.User - cur.Guest) - (prev.User - prev.Guest)
+	nice := (cur.Nice - cur.GuestNice) - (prev.Nice - prev.GuestNice)
+	guest := (cur.Guest + cur.GuestNice) - (prev.Guest + prev.GuestNice)
+	system := cur.System - prev.System
+	idle := cur.Idle - prev.Idle
+	iowait := cur.Iowait - prev.Iowait
+	irq := cur.Irq - prev.Irq
+	softirq := cur.Softirq - prev.Softirq
+	steal := cur.Steal - prev.Steal
+
+	total := user + nice + guest + system + idle + iowait + irq + softirq + steal
+	if total <= 0 {
+		return CPUTimes{Idle: 100}
+	}
+	pct := func(v float64) float64 {
+		if v < 0 {
+			return 0 // Counters occasionally step back on CPU hotplug
+		}
+		return v / total * 100
+	}
+	return CPUTimes{
+		User:    pct(user),
+		Nice:    pct(nice),
+		System:  pct(system),
+		IOWait:  pct(iowait),
+		IRQ:     pct(irq),
+		SoftIRQ: pct(softirq),
+		Steal:   pct(steal),
+		Guest:   pct(guest),
+		Idle:    pct(idle),
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+func TestCPUTimesPercent(t *testing.T) {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 10, Steal: 5, Guest: 20}
+	// 100 ticks elapse: 30 user of which 10 guest, 10 system, 30 idle,
+	// 10 iowait, 5 irq, 5 softirq, 10 steal
+	cur := cpu.TimesStat{User: 130, System: 60, Idle: 830, Iowait: 20, Irq: 5, Softirq: 5, Steal: 15, Guest: 30}
+
+	got := cpuTimesPercent(prev, cur)
+	want := CPUTimes{User: 20, System: 10, Idle: 30, IOWait: 10, IRQ: 5, SoftIRQ: 5, Steal: 10, Guest: 10}
+	if got != want {
+		t.Errorf("cpuTimesPercent = %+v, want %+v", got, want)
+	}
+	if busy := got.Busy(); math.Abs(busy-60) > 1e-9 {
+		t.Errorf("Expected 60%% busy (iowait excluded), got %.2f", busy)
+	}
+
+	if idle := cpuTimesPercent(cur, cur); idle.Idle != 100 || idle.Busy() != 0 {
+		t.Errorf("Expected an idle breakdown for an empty interval, got %+v", idle)
+	}
+}
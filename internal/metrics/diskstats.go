@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diskSectorSize is the unit of the sector counters in /proc/diskstats,
+// regardless of the device's logical block size.
+const diskSectorSize = 512
+
+// diskHistoryLength is the number of throughput samples kept per device.
+const diskHistoryLength = 100
+
+// diskCounters holds the cumulative /proc/diskstats fields used for rates.
+type diskCounters struct {
+	reads, readSectors, readMs    uint64
+	writes, writeSectors, writeMs uint64
+	inFlight                      uint64
+	ioMs                          uint64 // Time the device had I/O in flight
+}
+
+// diskClass is the cached classification of a block device.
+type diskClass struct {
+	kind     DiskKind
+	label    string
+	maxSpeed uint64
+}
+
+// diskStatsTracker derives per-device rates from consecutive samples of
+// /proc/diskstats.
+type diskStatsTracker struct {
+	procRoot  string
+	sysfsRoot string
+
+	classes  map[string]diskClass
+	last     map[string]diskCounters
+	lastTime time.Time
+	history  map[string][]float64
+}
+
+func newDiskStatsTracker(procRoot, sysfsRoot string) *diskStatsTracker {
+	return &diskStatsTracker{
+		procRoot:  procRoot,
+		sysfsRoot: sysfsRoot,
+		classes:   make(map[string]diskClass),
+		last:      make(map[string]diskCounters),
+		history:   make(map[string][]float64),
+	}
+}
+
+// sample reads /proc/diskstats and returns every block device, sorted by
+// name. Rates are zero on the first sample and for devices that just
+// appeared.
+func (t *diskStatsTracker) sample(now time.Time) ([]DiskDevice, error) {
+	counters, err := readDiskStats(filepath.Join(t.procRoot, "diskstats"))
+	if err != nil {
+		return nil, err
+	}
+
+	interval := now.Sub(t.lastTime)
+	devices := make([]DiskDevice, 0, len(counters))
+	for name, cur := range counters {
+		class, ok := t.classes[name]
+		if !ok {
+			class = classifyDisk(t.sysfsRoot, name)
+			t.classes[name] = class
+		}
+		dev := DiskDevice{
+			Name:       name,
+			Label:      class.label,
+			Kind:       class.kind,
+			MaxSpeed:   class.maxSpeed,
+			ReadBytes:  cur.readSectors * diskSectorSize,
+			WriteBytes: cur.writeSectors * diskSectorSize,
+			InFlight:   cur.inFlight,
+		}
+		if prev, ok := t.last[name]; ok && !t.lastTime.IsZero() && interval > 0 {
+			dev.setRates(prev, cur, interval)
+			history := append(t.history[name], float64(dev.ReadSpeed+dev.WriteSpeed))
+			if len(history) > diskHistoryLength {
+				history = history[1:]
+			}
+			t.history[name] = history
+			dev.History = append([]float64(nil), history...)
+		}
+		devices = append(devices, dev)
+	}
+
+	// Forget devices that went away (USB sticks, loop devices) so a new
+	// device reusing the name starts from scratch
+	for name := range t.last {
+		if _, ok := counters[name]; !ok {
+			delete(t.classes, name)
+			delete(t.history, name)
+		}
+	}
+	t.last = counters
+	t.lastTime = now
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices, nil
+}
+
+// setRates fills in the per-second rates between two samples.
+func (d *DiskDevice) setRates(prev, cur diskCounters, interval time.Duration) {
+	secs := interval.Seconds()
+	reads := counterDelta(prev.reads, cur.reads)
+	writes := counterDelta(prev.writes, cur.writes)
+
+	d.ReadSpeed = uint64(float64(counterDelta(prev.readSectors, cur.readSectors)*diskSectorSize) / secs)
+	d.WriteSpeed = uint64(float64(counterDelta(prev.writeSectors, cur.writeSectors)*diskSectorSize) / secs)
+	d.ReadIOPS = float64(reads) / secs
+	d.WriteIOPS = float64(writes) / secs
+	if ios := reads + writes; ios > 0 {
+		d.Await = float64(counterDelta(prev.readMs, cur.readMs)+counterDelta(prev.writeMs, cur.writeMs)) / float64(ios)
+	}
+	d.Util = float64(counterDelta(prev.ioMs, cur.ioMs)) / (secs * 1000) * 100
+	if d.Util > 100 {
+		d.Util = 100
+	}
+}
+
+// counterDelta returns cur-prev, or 0 when the counter went backwards (a
+// 32-bit field wrapped or the device was replaced).
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+// readDiskStats parses /proc/diskstats. Lines look like:
+//
+//	259  0 nvme0n1 1234 56 78901 234 5678 90 12345 678 0 900 1000 ...
+//
+// with reads, merged reads, sectors read, ms reading, the same four fields
+// for writes, then I/Os in flight, ms doing I/O and weighted ms.
+func readDiskStats(path string) (map[string]diskCounters, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]diskCounters)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+		var v [11]uint64
+		for i := range v {
+			v[i], _ = strconv.ParseUint(fields[3+i], 10, 64)
+		}
+		stats[fields[2]] = diskCounters{
+			reads:        v[0],
+			readSectors:  v[2],
+			readMs:       v[3],
+			writes:       v[4],
+			writeSectors: v[6],
+			writeMs:      v[7],
+			inFlight:     v[8],
+			ioMs:         v[9],
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// classifyDisk tells whole disks apart from partitions and from stacked or
+// memory-backed devices (device-mapper, md, loop, zram), whose I/O is
+// already counted on the disks underneath.
+func classifyDisk(sysfsRoot, name string) diskClass {
+	dir := filepath.Join(sysfsRoot, "class", "block", name)
+	if _, err := os.Stat(filepath.Join(dir, "partition")); err == nil {
+		return diskClass{kind: DiskKindPartition}
+	}
+	if label, err := readSysfsString(filepath.Join(dir, "dm", "name")); err == nil {
+		return diskClass{kind: DiskKindVirtual, label: label}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+		return diskClass{kind: DiskKindVirtual}
+	}
+	// An NVMe namespace's device is the controller, whose device is the PCIe
+	// function. SATA and virtio links are not exposed in a usable form.
+	return diskClass{kind: DiskKindDisk, maxSpeed: pcieLinkSpeed(filepath.Join(dir, "device", "device"))}
+}
+
+// pcieLinkSpeed returns the usable bandwidth in bytes per second of the
+// PCIe function at dir, from its negotiated link rate and width, or 0 if
+// they are unknown. current_link_speed reads e.g. "8.0 GT/s PCIe".
+func pcieLinkSpeed(dir string) uint64 {
+	speed, err := readSysfsString(filepath.Join(dir, "current_link_speed"))
+	if err != nil {
+		return 0
+	}
+	width, err := readSysfsUint(filepath.Join(dir, "current_link_width"))
+	if err != nil || width == 0 {
+		return 0
+	}
+	rate, _, _ := strings.Cut(speed, " ")
+	gts, err := strconv.ParseFloat(rate, 64)
+	if err != nil || gts <= 0 {
+		return 0
+	}
+	// Gen1 and Gen2 use 8b/10b encoding, Gen3 and later 128b/130b
+	encoding := 128.0 / 130
+	if gts < 8 {
+		encoding = 8.0 / 10
+	}
+	return uint64(gts * 1e9 * encoding / 8 * float64(width))
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiskStatsTracker(t *testing.T) {
+	procRoot := t.TempDir()
+	sysRoot := t.TempDir()
+	writeFixture(t, sysRoot, map[string]string{
+		"class/block/nvme0n1/device/vendor":                    "0x144d\n",
+		"class/block/nvme0n1/device/device/current_link_speed": "8.0 GT/s PCIe\n",
+		"class/block/nvme0n1/device/device/current_link_width": "4\n",
+		"class/block/nvme0n1p1/partition":                      "1\n",
+		"class/block/dm-0/dm/name":                             "luks-root\n",
+		"class/block/loop0/loop/backing_file":                  "/var/lib/snapd/snaps/core.snap\n",
+	})
+	writeFixture(t, procRoot, map[string]string{
+		"diskstats": "" +
+			" 259       0 nvme0n1 1000 0 20000 500 2000 0 40000 1500 0 3000 2000 0 0 0 0\n" +
+			" 259       1 nvme0n1p1 1000 0 20000 500 2000 0 40000 1500 0 3000 2000 0 0 0 0\n" +
+			" 253       0 dm-0 1000 0 20000 600 2000 0 40000 1600 0 3100 2200 0 0 0 0\n" +
+			"   7       0 loop0 10 0 80 1 0 0 0 0 0 1 1 0 0 0 0\n",
+	})
+
+	tracker := newDiskStatsTracker(procRoot, sysRoot)
+	start := time.Unix(1000, 0)
+	devices, err := tracker.sample(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]DiskKind{}
+	for _, d := range devices {
+		kinds[d.Name] = d.Kind
+		if d.ReadSpeed != 0 || d.History != nil {
+			t.Errorf("Expected no rates on the first sample, got %+v", d)
+		}
+	}
+	want := map[string]DiskKind{"nvme0n1": DiskKindDisk, "nvme0n1p1": DiskKindPartition, "dm-0": DiskKindVirtual, "loop0": DiskKindVirtual}
+	for name, kind := range want {
+		if kinds[name] != kind {
+			t.Errorf("%s classified as %q, want %q", name, kinds[name], kind)
+		}
+	}
+	if devices[0].Name != "dm-0" || devices[0].Label != "luks-root" {
+		t.Errorf("Expected dm-0 first with its mapper name, got %+v", devices[0])
+	}
+
+	// Two seconds later: 100 reads of 4 KiB and 300 writes of 8 KiB, 1.5s
+	// busy. loop0 is detached, dm-0's counters went backwards.
+	writeFixture(t, procRoot, map[string]string{
+		"diskstats": "" +
+			" 259       0 nvme0n1 1100 0 20800 700 2300 0 44800 2100 4 4500 2600 0 0 0 0\n" +
+			" 259       1 nvme0n1p1 1100 0 20800 700 2300 0 44800 2100 4 4500 2600 0 0 0 0\n" +
+			" 253       0 dm-0 10 0 80 6 20 0 400 16 0 31 22 0 0 0 0\n",
+	})
+	devices, err = tracker.sample(start.Add(2 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("Expected loop0 to be gone, got %d devices", len(devices))
+	}
+	nvme := devices[1]
+	if nvme.Name != "nvme0n1" {
+		t.Fatalf("Unexpected device order: %v", devices)
+	}
+	if nvme.ReadSpeed != 200*1024 || nvme.WriteSpeed != 1200*1024 {
+		t.Errorf("Unexpected throughput R %d W %d", nvme.ReadSpeed, nvme.WriteSpeed)
+	}
+	if nvme.ReadIOPS != 50 || nvme.WriteIOPS != 150 {
+		t.Errorf("Unexpected IOPS %.1f/%.1f", nvme.ReadIOPS, nvme.WriteIOPS)
+	}
+	// (200ms reading + 600ms writing) / 400 requests
+	if nvme.Await != 2 {
+		t.Errorf("Expected 2ms await, got %.2f", nvme.Await)
+	}
+	if nvme.Util != 75 || nvme.InFlight != 4 {
+		t.Errorf("Expected 75%% util with 4 in flight, got %.1f%% / %d", nvme.Util, nvme.InFlight)
+	}
+	if len(nvme.History) != 1 || nvme.History[0] != float64(1400*1024) {
+		t.Errorf("Unexpected history %v", nvme.History)
+	}
+	// 8 GT/s with 128b/130b encoding over four lanes
+	if nvme.MaxSpeed != 3938461538 {
+		t.Errorf("Unexpected PCIe link bandwidth %d", nvme.MaxSpeed)
+	}
+	if dm := devices[0]; dm.MaxSpeed != 0 || dm.ReadSpeed != 0 || dm.WriteIOPS != 0 || dm.Util != 0 {
+		t.Errorf("Expected a counter reset to report no activity, got %+v", dm)
+	}
+}
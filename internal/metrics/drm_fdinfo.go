@@ -0,0 +1,418 @@
+package metrics
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Engine classes reported in DRM fdinfo, normalized across drivers.
+const (
+	EngineRender       = "render"
+	EngineCompute      = "compute"
+	EngineCopy         = "copy"
+	EngineVideo        = "video" // Combined encode/decode engine (Intel)
+	EngineVideoEnhance = "video-enhance"
+	EngineEncode       = "encode"
+	EngineDecode       = "decode"
+)
+
+// drmEngineClasses maps driver-specific engine names onto the classes above.
+// Engines not listed keep their driver name.
+var drmEngineClasses = map[string]string{
+	// amdgpu
+	"gfx":   EngineRender,
+	"dma":   EngineCopy,
+	"dec":   EngineDecode,
+	"jpeg":  EngineDecode,
+	"enc":   EngineEncode,
+	"enc_1": EngineEncode,
+	// xe reports cycles per hardware class
+	"rcs":  EngineRender,
+	"bcs":  EngineCopy,
+	"vcs":  EngineVideo,
+	"vecs": EngineVideoEnhance,
+	"ccs":  EngineCompute,
+	// msm
+	"gpu": EngineRender,
+}
+
+// drmClientKey identifies a DRM client. The same client can be reachable
+// through several duplicated descriptors of a process and is counted once.
+// Client ids are only unique per device.
+type drmClientKey struct {
+	pid      int
+	dev      drmDevice
+	clientID string
+}
+
+// drmClient holds the counters of one client as read from fdinfo.
+type drmClient struct {
+	driver      string
+	pdev        string
+	engineNs    map[string]uint64 // drm-engine-<engine>: busy time in ns
+	capacity    map[string]uint64 // drm-engine-capacity-<engine>: parallel engines
+	cycles      map[string]uint64 // drm-cycles-<engine>
+	totalCycles map[string]uint64 // drm-total-cycles-<engine>
+	memory      map[string]uint64 // Bytes per memory region
+}
+
+// drmDevice identifies the device a client uses by its driver and PCI slot
+// name. The slot is "" on kernels that do not report drm-pdev.
+type drmDevice struct {
+	driver string
+	pdev   string
+}
+
+// drmProcessUsage is the GPU usage of one process on one device, summed over
+// all of its clients.
+type drmProcessUsage struct {
+	pid     int
+	engines map[string]float64 // Busy percent per engine class
+	memory  uint64             // Device memory in bytes
+}
+
+// drmClientTracker samples /proc/<pid>/fdinfo of every process and derives
+// per-process engine busy percentages from consecutive samples. It works for
+// any driver implementing the DRM client usage stats (amdgpu, i915, xe,
+// msm, ...), and one scan serves the backends of every driver.
+type drmClientTracker struct {
+	procRoot string
+	usage    map[drmDevice][]drmProcessUsage // Result of the last sample
+	samples  int
+
+	last     map[drmClientKey]drmClient
+	lastTime time.Time
+}
+
+func newDRMClientTracker(procRoot string) *drmClientTracker {
+	return &drmClientTracker{procRoot: procRoot}
+}
+
+// primed reports whether the last sample had a previous one to measure
+// engine usage against.
+func (t *drmClientTracker) primed() bool {
+	return t.samples > 1
+}
+
+// sample scans procfs and returns per-process usage grouped by device.
+// Engine percentages are only available from the second sample on; memory
+// is reported immediately.
+func (t *drmClientTracker) sample(now time.Time) map[drmDevice][]drmProcessUsage {
+	clients := t.scan()
+	elapsed := now.Sub(t.lastTime)
+	primed := t.last != nil && elapsed > 0
+
+	type devPid struct {
+		dev drmDevice
+		pid int
+	}
+	byProc := make(map[devPid]*drmProcessUsage)
+	for key, cur := range clients {
+		dp := devPid{dev: key.dev, pid: key.pid}
+		usage, ok := byProc[dp]
+		if !ok {
+			usage = &drmProcessUsage{pid: key.pid, engines: make(map[string]float64)}
+			byProc[dp] = usage
+		}
+		usage.memory += cur.deviceMemory()
+		if prev, ok := t.last[key]; ok && primed {
+			for engine, pct := range cur.busy(prev, elapsed) {
+				usage.engines[engine] += pct
+			}
+		}
+	}
+
+	t.last = clients
+	t.lastTime = now
+
+	result := make(map[drmDevice][]drmProcessUsage)
+	for dp, usage := range byProc {
+		result[dp.dev] = append(result[dp.dev], *usage)
+	}
+	for _, procs := range result {
+		sort.Slice(procs, func(i, j int) bool { return procs[i].pid < procs[j].pid })
+	}
+	t.usage = result
+	t.samples++
+	return result
+}
+
+// scan reads every DRM client in procfs. Only the fdinfo of descriptors
+// open on a DRM node is read: a busy host has tens of thousands of files
+// and sockets open, and a handful of GPU clients.
+func (t *drmClientTracker) scan() map[drmClientKey]drmClient {
+	clients := make(map[drmClientKey]drmClient)
+	pidDirs, err := os.ReadDir(t.procRoot)
+	if err != nil {
+		return clients
+	}
+	for _, pidDir := range pidDirs {
+		pid, err := strconv.Atoi(pidDir.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(t.procRoot, pidDir.Name())
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue // Exited or not ours to inspect
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "/dev/dri/") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "fdinfo", fd.Name()))
+			if err != nil {
+				continue
+			}
+			clientID, client, ok := parseDRMFdinfo(string(data))
+			if !ok {
+				continue
+			}
+			dev := drmDevice{driver: client.driver, pdev: client.pdev}
+			clients[drmClientKey{pid: pid, dev: dev, clientID: clientID}] = client
+		}
+	}
+	return clients
+}
+
+// parseDRMFdinfo parses the fdinfo of one descriptor. ok is false for
+// descriptors that are not DRM clients.
+func parseDRMFdinfo(data string) (clientID string, client drmClient, ok bool) {
+	client = drmClient{
+		engineNs:    make(map[string]uint64),
+		capacity:    make(map[string]uint64),
+		cycles:      make(map[string]uint64),
+		totalCycles: make(map[string]uint64),
+		memory:      make(map[string]uint64),
+	}
+	// Memory regions may be reported as drm-resident-*, drm-memory-* (older
+	// amdgpu, also resident) and drm-total-*; the first in that order wins
+	resident := make(map[string]uint64)
+	legacy := make(map[string]uint64)
+	total := make(map[string]uint64)
+
+	for _, line := range strings.Split(data, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case key == "drm-driver":
+			client.driver = value
+		case key == "drm-pdev":
+			client.pdev = value
+		case key == "drm-client-id":
+			clientID = value
+		case strings.HasPrefix(key, "drm-engine-capacity-"):
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				client.capacity[drmEngineClass(strings.TrimPrefix(key, "drm-engine-capacity-"))] = n
+			}
+		case strings.HasPrefix(key, "drm-engine-"):
+			if n, err := strconv.ParseUint(strings.TrimSuffix(value, " ns"), 10, 64); err == nil {
+				client.engineNs[drmEngineClass(strings.TrimPrefix(key, "drm-engine-"))] += n
+			}
+		case strings.HasPrefix(key, "drm-total-cycles-"):
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				client.totalCycles[drmEngineClass(strings.TrimPrefix(key, "drm-total-cycles-"))] = n
+			}
+		case strings.HasPrefix(key, "drm-cycles-"):
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				client.cycles[drmEngineClass(strings.TrimPrefix(key, "drm-cycles-"))] += n
+			}
+		case strings.HasPrefix(key, "drm-resident-"):
+			if n, ok := parseDRMMemory(value); ok {
+				resident[strings.TrimPrefix(key, "drm-resident-")] = n
+			}
+		case strings.HasPrefix(key, "drm-memory-"):
+			if n, ok := parseDRMMemory(value); ok {
+				legacy[strings.TrimPrefix(key, "drm-memory-")] = n
+			}
+		case strings.HasPrefix(key, "drm-total-"):
+			if n, ok := parseDRMMemory(value); ok {
+				total[strings.TrimPrefix(key, "drm-total-")] = n
+			}
+		}
+	}
+
+	for _, source := range []map[string]uint64{total, legacy, resident} {
+		for region, n := range source {
+			client.memory[region] = n
+		}
+	}
+	return clientID, client, client.driver != "" && clientID != ""
+}
+
+// parseDRMMemory parses a memory value such as "1024 KiB".
+func parseDRMMemory(value string) (uint64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if len(fields) > 1 {
+		switch fields[1] {
+		case "KiB":
+			n *= 1024
+		case "MiB":
+			n *= 1024 * 1024
+		}
+	}
+	return n, true
+}
+
+func drmEngineClass(engine string) string {
+	if class, ok := drmEngineClasses[engine]; ok {
+		return class
+	}
+	return engine
+}
+
+// deviceMemory returns the memory a client holds on the device: the sum of
+// its local regions (vram*, local*) or, on integrated GPUs that only report
+// system memory, of all regions.
+func (c drmClient) deviceMemory() uint64 {
+	var local, all uint64
+	hasLocal := false
+	for region, n := range c.memory {
+		all += n
+		if strings.HasPrefix(region, "vram") || strings.HasPrefix(region, "local") {
+			local += n
+			hasLocal = true
+		}
+	}
+	if hasLocal {
+		return local
+	}
+	return all
+}
+
+// busy returns the busy percentage per engine class since prev.
+func (c drmClient) busy(prev drmClient, elapsed time.Duration) map[string]float64 {
+	usage := make(map[string]float64)
+	for engine, ns := range c.engineNs {
+		last, ok := prev.engineNs[engine]
+		if !ok || ns < last {
+			continue
+		}
+		pct := float64(ns-last) / float64(elapsed.Nanoseconds()) * 100
+		if capacity := c.capacity[engine]; capacity > 1 {
+			pct /= float64(capacity)
+		}
+		usage[engine] = pct
+	}
+	for engine, cycles := range c.cycles {
+		last, ok := prev.cycles[engine]
+		total, lastTotal := c.totalCycles[engine], prev.totalCycles[engine]
+		if ok && cycles >= last && total > lastTotal {
+			usage[engine] = float64(cycles-last) / float64(total-lastTotal) * 100
+		}
+	}
+	return usage
+}
+
+// drmDeviceUsage returns the processes using the device of one of drivers
+// at pdev. Clients from kernels without drm-pdev cannot be told apart and
+// count for every device of their driver.
+func drmDeviceUsage(usage map[drmDevice][]drmProcessUsage, pdev string, drivers ...string) []drmProcessUsage {
+	var procs []drmProcessUsage
+	for _, driver := range drivers {
+		procs = append(procs, usage[drmDevice{driver: driver}]...)
+		if pdev != "" {
+			procs = append(procs, usage[drmDevice{driver: driver, pdev: pdev}]...)
+		}
+	}
+	return procs
+}
+
+// drmClients gives a backend the fdinfo usage of its clients. A backend
+// used on its own scans fdinfo itself; inside a MultiGPUBackend all
+// backends share one tracker, which the MultiGPUBackend samples once.
+type drmClients struct {
+	tracker *drmClientTracker
+	shared  bool
+}
+
+// share makes the backend read t instead of scanning on its own.
+func (c *drmClients) share(t *drmClientTracker) {
+	c.tracker, c.shared = t, true
+}
+
+// sample returns the usage for the current sample, scanning procRoot unless
+// the tracker is shared.
+func (c *drmClients) sample(procRoot string, now time.Time) map[drmDevice][]drmProcessUsage {
+	if c.tracker == nil {
+		c.tracker = newDRMClientTracker(procRoot)
+	}
+	if !c.shared {
+		c.tracker.sample(now)
+	}
+	return c.tracker.usage
+}
+
+// primed reports whether engine usage is available for the current sample.
+func (c *drmClients) primed() bool {
+	return c.tracker != nil && c.tracker.primed()
+}
+
+// drmEngineTotals sums per-process engine usage into device-wide busy
+// percentages.
+func drmEngineTotals(procs []drmProcessUsage) map[string]uint32 {
+	totals := make(map[string]float64)
+	for _, p := range procs {
+		for engine, pct := range p.engines {
+			totals[engine] += pct
+		}
+	}
+	engines := make(map[string]uint32, len(totals))
+	for engine, pct := range totals {
+		engines[engine] = percentUint(pct)
+	}
+	return engines
+}
+
+// drmGPUProcesses converts fdinfo usage into GPUProcess entries, heaviest
+// memory users first. Render/compute busy time stands in for SM utilization.
+func drmGPUProcesses(procs []drmProcessUsage) []GPUProcess {
+	result := make([]GPUProcess, 0, len(procs))
+	for _, p := range procs {
+		result = append(result, GPUProcess{
+			PID:        uint32(p.pid),
+			Type:       drmProcessType(p.engines),
+			MemoryUsed: p.memory,
+			SMUtil:     percentUint(math.Max(p.engines[EngineRender], p.engines[EngineCompute])),
+			EncUtil:    percentUint(math.Max(p.engines[EngineEncode], p.engines[EngineVideo])),
+			DecUtil:    percentUint(math.Max(p.engines[EngineDecode], p.engines[EngineVideo])),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].MemoryUsed != result[j].MemoryUsed {
+			return result[i].MemoryUsed > result[j].MemoryUsed
+		}
+		return result[i].SMUtil > result[j].SMUtil
+	})
+	return result
+}
+
+// drmProcessType classifies a client as compute ("C") when it only uses the
+// compute engine, graphics ("G") otherwise.
+func drmProcessType(engines map[string]float64) string {
+	if engines[EngineCompute] > 0 && engines[EngineRender] == 0 {
+		return "C"
+	}
+	return "G"
+}
+
+// percentUint rounds a percentage to an integer within [0, 100].
+func percentUint(v float64) uint32 {
+	return uint32(math.Round(math.Max(0, math.Min(100, v))))
+}
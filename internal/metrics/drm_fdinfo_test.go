@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDRMClients writes fdinfo files like writeFixture and links each
+// matching /proc/<pid>/fd/<n> to a DRM render node, as for an open GPU
+// client.
+func writeDRMClients(t testing.TB, proc string, files map[string]string) {
+	t.Helper()
+	writeFixture(t, proc, files)
+	for rel := range files {
+		pid, fd, ok := strings.Cut(rel, "/fdinfo/")
+		if !ok {
+			continue
+		}
+		link := filepath.Join(proc, pid, "fd", fd)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("/dev/dri/renderD128", link); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseDRMFdinfo(t *testing.T) {
+	data := "pos:\t0\nflags:\t02100002\n" +
+		"drm-driver:\tamdgpu\n" +
+		"drm-pdev:\t0000:03:00.0\n" +
+		"drm-client-id:\t42\n" +
+		"drm-memory-vram:\t1024 KiB\n" +
+		"drm-memory-gtt:\t2048 KiB\n" +
+		"drm-resident-vram:\t4 MiB\n" +
+		"drm-engine-gfx:\t1000 ns\n" +
+		"drm-engine-enc:\t10 ns\n" +
+		"drm-engine-enc_1:\t5 ns\n" +
+		"drm-engine-capacity-gfx:\t2\n"
+
+	id, client, ok := parseDRMFdinfo(data)
+	if !ok || id != "42" || client.driver != "amdgpu" || client.pdev != "0000:03:00.0" {
+		t.Fatalf("Unexpected client %q %+v (ok=%v)", id, client, ok)
+	}
+	if client.engineNs[EngineRender] != 1000 || client.engineNs[EngineEncode] != 15 {
+		t.Errorf("Expected engines normalized and summed, got %v", client.engineNs)
+	}
+	if client.capacity[EngineRender] != 2 {
+		t.Errorf("Expected render capacity 2, got %v", client.capacity)
+	}
+	// drm-resident wins over the legacy drm-memory key; gtt is system memory
+	if got := client.deviceMemory(); got != 4*1024*1024 {
+		t.Errorf("Expected 4 MiB of VRAM, got %d", got)
+	}
+
+	if _, _, ok := parseDRMFdinfo("pos:\t0\nflags:\t02\nmnt_id:\t15\n"); ok {
+		t.Error("Non-DRM fdinfo should be rejected")
+	}
+
+	// Integrated GPUs only report system memory
+	_, igpu, _ := parseDRMFdinfo("drm-driver:\ti915\ndrm-client-id:\t1\ndrm-total-system0:\t8 MiB\ndrm-resident-system0:\t6 MiB\n")
+	if got := igpu.deviceMemory(); got != 6*1024*1024 {
+		t.Errorf("Expected 6 MiB of system memory, got %d", got)
+	}
+}
+
+func TestDRMClientTracker(t *testing.T) {
+	proc := t.TempDir()
+	writeDRMClients(t, proc, map[string]string{
+		// Two descriptors for the same client, plus a second client
+		"10/fdinfo/4": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t1\ndrm-engine-gfx:\t0 ns\ndrm-memory-vram:\t1024 KiB\n",
+		"10/fdinfo/5": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t1\ndrm-engine-gfx:\t0 ns\ndrm-memory-vram:\t1024 KiB\n",
+		"10/fdinfo/6": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t2\ndrm-engine-compute:\t0 ns\ndrm-memory-vram:\t1024 KiB\n",
+		// Another driver's client, without drm-pdev
+		"20/fdinfo/3": "drm-driver:\ti915\ndrm-client-id:\t9\ndrm-engine-render:\t0 ns\n",
+		// Kernel threads and files that are not pids
+		"self/fdinfo/0": "drm-driver:\tamdgpu\ndrm-client-id:\t5\n",
+		"30/status":     "Name:\tkworker\n",
+		// One process with the same client id on two devices
+		"40/fdinfo/3": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t7\ndrm-memory-vram:\t1024 KiB\n",
+		"40/fdinfo/4": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:0a:00.0\ndrm-client-id:\t7\ndrm-memory-vram:\t1024 KiB\n",
+	})
+	// A descriptor that is not open on a DRM node is never read
+	writeFixture(t, proc, map[string]string{
+		"50/fdinfo/3": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t8\n",
+	})
+	if err := os.MkdirAll(filepath.Join(proc, "50", "fd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("socket:[4242]", filepath.Join(proc, "50", "fd", "3")); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := newDRMClientTracker(proc)
+	start := time.Unix(100, 0)
+	usage := tracker.sample(start)
+	procs := drmDeviceUsage(usage, "0000:03:00.0", "amdgpu")
+	if len(procs) != 2 || procs[0].pid != 10 || procs[1].pid != 40 {
+		t.Fatalf("Expected pids 10 and 40, got %+v", usage)
+	}
+	if other := drmDeviceUsage(usage, "0000:0a:00.0", "amdgpu"); len(other) != 1 || other[0].pid != 40 || other[0].memory != 1024*1024 {
+		t.Errorf("Expected pid 40's client on the second device too, got %+v", other)
+	}
+	if intel := drmDeviceUsage(usage, "0000:00:02.0", "i915", "xe"); len(intel) != 1 || intel[0].pid != 20 {
+		t.Errorf("Expected the i915 client on every i915 device, got %+v", intel)
+	}
+	if procs[0].memory != 2*1024*1024 {
+		t.Errorf("Expected duplicated descriptors counted once (2 MiB), got %d", procs[0].memory)
+	}
+	if len(procs[0].engines) != 0 {
+		t.Errorf("Expected no engine usage on the first sample, got %v", procs[0].engines)
+	}
+
+	writeDRMClients(t, proc, map[string]string{
+		"10/fdinfo/4": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t1\ndrm-engine-gfx:\t250000000 ns\ndrm-memory-vram:\t1024 KiB\n",
+		"10/fdinfo/5": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t1\ndrm-engine-gfx:\t250000000 ns\ndrm-memory-vram:\t1024 KiB\n",
+		"10/fdinfo/6": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t2\ndrm-engine-compute:\t100000000 ns\ndrm-memory-vram:\t1024 KiB\n",
+	})
+	usage = tracker.sample(start.Add(500 * time.Millisecond))
+	procs = drmDeviceUsage(usage, "0000:03:00.0", "amdgpu")
+	if len(procs) != 2 {
+		t.Fatalf("Expected 2 processes, got %+v", procs)
+	}
+	if got := procs[0].engines[EngineRender]; got != 50 {
+		t.Errorf("Expected 50%% render, got %.1f", got)
+	}
+	if got := procs[0].engines[EngineCompute]; got != 20 {
+		t.Errorf("Expected 20%% compute, got %.1f", got)
+	}
+
+	gpuProcs := drmGPUProcesses(procs)
+	if gpuProcs[0].PID != 10 || gpuProcs[0].SMUtil != 50 || gpuProcs[0].MemoryUsed != 2*1024*1024 {
+		t.Errorf("Unexpected GPU process %+v", gpuProcs[0])
+	}
+	if engines := drmEngineTotals(procs); engines[EngineRender] != 50 || engines[EngineCompute] != 20 {
+		t.Errorf("Unexpected engine totals %v", engines)
+	}
+}
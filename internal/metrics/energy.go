@@ -0,0 +1,83 @@
+package metrics
+
+import "time"
+
+// energyAccountant estimates the power each process draws and integrates it
+// into energy over the process's lifetime.
+//
+// CPU package power is split by each process's share of the CPU time used
+// by all processes, so the estimates add up to the package power, idle
+// draw included. Each GPU's power is split by the per-process utilization
+// the driver reports; GPUs without one leave their power unattributed.
+//
+// Energy is kept per process, by PID and start time, so a reused PID starts
+// over. A process missing from a sample, say after a failed read, keeps its
+// total for energyRetention in case it shows up again.
+type energyAccountant struct {
+	joules   map[processKey]processEnergy
+	lastTime time.Time
+}
+
+// processKey identifies a process across PID reuse.
+type processKey struct {
+	pid       int32
+	startTime uint64
+}
+
+type processEnergy struct {
+	joules   float64
+	lastSeen time.Time
+}
+
+// energyRetention is how long the energy of a process missing from the
+// samples is kept.
+const energyRetention = time.Minute
+
+func newEnergyAccountant() *energyAccountant {
+	return &energyAccountant{joules: make(map[processKey]processEnergy)}
+}
+
+// attribute fills in Power and Energy for every process in stats.
+func (a *energyAccountant) attribute(stats *SystemStats, now time.Time) {
+	var secs float64
+	if !a.lastTime.IsZero() {
+		secs = now.Sub(a.lastTime).Seconds()
+	}
+	a.lastTime = now
+
+	var cpuTotal float64
+	for _, p := range stats.Processes {
+		cpuTotal += p.CPUPercent
+	}
+
+	gpuWatts := make(map[int32]float64)
+	for _, gpu := range stats.GPUs {
+		var utilTotal uint32
+		for _, gp := range gpu.Processes {
+			utilTotal += gp.SMUtil
+		}
+		if !gpu.Available || utilTotal == 0 {
+			continue
+		}
+		watts := float64(gpu.PowerUsage) / 1000
+		for _, gp := range gpu.Processes {
+			gpuWatts[int32(gp.PID)] += watts * float64(gp.SMUtil) / float64(utilTotal)
+		}
+	}
+
+	for i := range stats.Processes {
+		p := &stats.Processes[i]
+		p.Power = gpuWatts[p.PID]
+		if stats.CPU.Power.Available && cpuTotal > 0 {
+			p.Power += stats.CPU.Power.Package * p.CPUPercent / cpuTotal
+		}
+		key := processKey{pid: p.PID, startTime: p.StartTime}
+		p.Energy = a.joules[key].joules + p.Power*secs
+		a.joules[key] = processEnergy{joules: p.Energy, lastSeen: now}
+	}
+	for key, e := range a.joules {
+		if now.Sub(e.lastSeen) > energyRetention {
+			delete(a.joules, key)
+		}
+	}
+}
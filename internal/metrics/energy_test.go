@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnergyAccountant(t *testing.T) {
+	stats := &SystemStats{
+		CPU: CPUStats{Power: CPUPower{Available: true, Package: 40}},
+		GPUs: []GPUStats{{
+			Available:  true,
+			PowerUsage: 200000,
+			Processes:  []GPUProcess{{PID: 2, SMUtil: 30}, {PID: 3, SMUtil: 10}},
+		}},
+		Processes: []ProcessInfo{
+			{PID: 1, CPUPercent: 75},
+			{PID: 2, CPUPercent: 25},
+			{PID: 3},
+		},
+	}
+
+	accountant := newEnergyAccountant()
+	start := time.Unix(1000, 0)
+	accountant.attribute(stats, start)
+	want := []float64{30, 10 + 150, 50}
+	for i, p := range stats.Processes {
+		if p.Power != want[i] || p.Energy != 0 {
+			t.Errorf("PID %d: got %.1f W %.1f J, want %.1f W and no energy yet", p.PID, p.Power, p.Energy, want[i])
+		}
+	}
+
+	// PID 1 exits and its PID is reused by a new process ten seconds later
+	stats.Processes = stats.Processes[1:]
+	accountant.attribute(stats, start.Add(10*time.Second))
+	stats.Processes = append(stats.Processes, ProcessInfo{PID: 1, StartTime: 900, CPUPercent: 100})
+	accountant.attribute(stats, start.Add(20*time.Second))
+
+	energy := map[int32]float64{}
+	for _, p := range stats.Processes {
+		energy[p.PID] = p.Energy
+	}
+	// PID 2 took all of the package power for 10 s, then a fifth of it
+	if energy[2] != (40+150)*10+(8+150)*10 || energy[3] != 50*20 || energy[1] != 32*10 {
+		t.Errorf("Unexpected energy %v", energy)
+	}
+
+	// PID 3 is missing from one sample and keeps its energy
+	stats.Processes = []ProcessInfo{{PID: 1, StartTime: 900, CPUPercent: 100}}
+	accountant.attribute(stats, start.Add(30*time.Second))
+	stats.Processes = append(stats.Processes, ProcessInfo{PID: 3})
+	accountant.attribute(stats, start.Add(40*time.Second))
+	if got := stats.Processes[1].Energy; got != 50*20+50*10 {
+		t.Errorf("Expected PID 3 to keep its energy, got %.0f J", got)
+	}
+
+	// and loses it once gone for longer than energyRetention
+	stats.Processes = stats.Processes[:1]
+	accountant.attribute(stats, start.Add(40*time.Second+energyRetention+time.Second))
+	if _, ok := accountant.joules[processKey{pid: 3}]; ok {
+		t.Error("Expected PID 3 to be forgotten")
+	}
+}
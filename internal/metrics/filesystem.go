@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultFilesystemFilter excludes kernel pseudo filesystems, tmpfs and
+// read-only images (snaps, live media) that are always full.
+var DefaultFilesystemFilter = FilesystemFilter{
+	ExcludeTypes: []string{
+		"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs",
+		"debugfs", "devpts", "devtmpfs", "efivarfs", "fusectl", "hugetlbfs",
+		"mqueue", "nsfs", "overlay", "proc", "pstore", "ramfs", "rpc_pipefs",
+		"securityfs", "squashfs", "sysfs", "tmpfs", "tracefs",
+	},
+	ExcludeMounts: []string{"/proc", "/sys", "/dev", "/run", "/snap"},
+}
+
+// FilesystemFilter selects which mounts are reported.
+type FilesystemFilter struct {
+	ExcludeTypes  []string // Filesystem types to skip, e.g. "tmpfs"
+	ExcludeMounts []string // Mount points to skip, along with everything below them
+}
+
+// excludes reports whether a mount is filtered out.
+func (f FilesystemFilter) excludes(mount, fstype string) bool {
+	for _, t := range f.ExcludeTypes {
+		if t == fstype {
+			return true
+		}
+	}
+	for _, m := range f.ExcludeMounts {
+		m = strings.TrimSuffix(m, "/")
+		if mount == m || strings.HasPrefix(mount, m+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// fsGrowthWindow is how far back usage samples are kept for the time until
+// full estimate, and fsGrowthMinSpan how much history is needed before one
+// is made.
+const (
+	fsGrowthWindow  = 10 * time.Minute
+	fsGrowthMinSpan = 30 * time.Second
+)
+
+// fsUsage is the subset of statfs(2) used for capacity, in bytes and inodes.
+type fsUsage struct {
+	total, free, avail uint64
+	inodes, inodesFree uint64
+	readOnly           bool
+}
+
+// fsSample is a used-bytes reading of one mount at a point in time.
+type fsSample struct {
+	time time.Time
+	used uint64
+}
+
+// mountEntry is one line of /proc/self/mountinfo.
+type mountEntry struct {
+	device   string // major:minor
+	mount    string
+	source   string
+	fstype   string
+	readOnly bool
+}
+
+// filesystemTracker reports mounted filesystems and estimates when each will
+// fill up from its recent growth.
+type filesystemTracker struct {
+	procRoot string
+	filter   FilesystemFilter
+	statfs   func(path string) (fsUsage, error)
+
+	samples map[string][]fsSample // Keyed by mount point
+}
+
+func newFilesystemTracker(procRoot string, filter FilesystemFilter) *filesystemTracker {
+	return &filesystemTracker{
+		procRoot: procRoot,
+		filter:   filter,
+		statfs:   statfsUsage,
+		samples:  make(map[string][]fsSample),
+	}
+}
+
+// sample returns every mounted filesystem passing the filter, ordered by
+// mount point. A filesystem mounted in several places (bind mounts) is
+// reported once, at its shortest mount point.
+func (t *filesystemTracker) sample(now time.Time) ([]FilesystemStats, error) {
+	mounts, err := readMountInfo(filepath.Join(t.procRoot, "self", "mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	byDevice := make(map[string]mountEntry)
+	for _, m := range mounts {
+		if t.filter.excludes(m.mount, m.fstype) {
+			continue
+		}
+		if prev, ok := byDevice[m.device]; ok && len(prev.mount) <= len(m.mount) {
+			continue
+		}
+		byDevice[m.device] = m
+	}
+
+	var filesystems []FilesystemStats
+	seen := make(map[string]bool)
+	for _, m := range byDevice {
+		usage, err := t.statfs(m.mount)
+		if err != nil || usage.total == 0 {
+			continue // Inaccessible, or a pseudo filesystem the filter missed
+		}
+		fs := FilesystemStats{
+			Mount:      m.mount,
+			Device:     m.source,
+			FSType:     m.fstype,
+			ReadOnly:   m.readOnly || usage.readOnly,
+			Total:      usage.total,
+			Used:       usage.total - usage.free,
+			Free:       usage.avail,
+			Inodes:     usage.inodes,
+			InodesUsed: usage.inodes - usage.inodesFree,
+		}
+		// Like df, the root-reserved blocks count as neither used nor free
+		if size := fs.Used + fs.Free; size > 0 {
+			fs.UsedPercent = float64(fs.Used) / float64(size) * 100
+		}
+		if fs.Inodes > 0 {
+			fs.InodesPercent = float64(fs.InodesUsed) / float64(fs.Inodes) * 100
+		}
+		fs.GrowthRate, fs.TimeToFull = t.growth(m.mount, now, fs.Used, fs.Free)
+		seen[m.mount] = true
+		filesystems = append(filesystems, fs)
+	}
+
+	// Drop the history of unmounted filesystems
+	for mount := range t.samples {
+		if !seen[mount] {
+			delete(t.samples, mount)
+		}
+	}
+
+	sort.Slice(filesystems, func(i, j int) bool { return filesystems[i].Mount < filesystems[j].Mount })
+	return filesystems, nil
+}
+
+// growth records a usage sample and returns the growth rate in bytes per
+// second over the window, and the time until the free space runs out at
+// that rate (0 when usage is not growing).
+func (t *filesystemTracker) growth(mount string, now time.Time, used, free uint64) (float64, time.Duration) {
+	samples := append(t.samples[mount], fsSample{time: now, used: used})
+	for len(samples) > 1 && now.Sub(samples[0].time) > fsGrowthWindow {
+		samples = samples[1:]
+	}
+	t.samples[mount] = samples
+
+	first := samples[0]
+	span := now.Sub(first.time)
+	if span < fsGrowthMinSpan {
+		return 0, 0
+	}
+	rate := (float64(used) - float64(first.used)) / span.Seconds()
+	if rate <= 0 {
+		return rate, 0
+	}
+	return rate, time.Duration(float64(free) / rate * float64(time.Second))
+}
+
+// readMountInfo parses /proc/<pid>/mountinfo. Lines look like:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// with a variable number of optional fields before the "-" separator.
+func readMountInfo(path string) ([]mountEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || sep+2 >= len(fields) {
+			continue
+		}
+		readOnly := false
+		for _, opt := range strings.Split(fields[5], ",") {
+			if opt == "ro" {
+				readOnly = true
+			}
+		}
+		mounts = append(mounts, mountEntry{
+			device:   fields[2],
+			mount:    unescapeMountPath(fields[4]),
+			fstype:   fields[sep+1],
+			source:   unescapeMountPath(fields[sep+2]),
+			readOnly: readOnly,
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space, ...) the
+// kernel uses for whitespace and backslashes in mount paths.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// statfsUsage reads the capacity of the filesystem mounted at path.
+func statfsUsage(path string) (fsUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return fsUsage{}, err
+	}
+	bsize := uint64(st.Frsize)
+	if bsize == 0 {
+		bsize = uint64(st.Bsize)
+	}
+	return fsUsage{
+		total:      st.Blocks * bsize,
+		free:       st.Bfree * bsize,
+		avail:      st.Bavail * bsize,
+		inodes:     st.Files,
+		inodesFree: st.Ffree,
+		readOnly:   st.Flags&1 != 0, // ST_RDONLY
+	}, nil
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilesystemTracker(t *testing.T) {
+	procRoot := t.TempDir()
+	writeFixture(t, procRoot, map[string]string{
+		"self/mountinfo": "" +
+			"22 1 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:12 - proc proc rw\n" +
+			"26 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw\n" +
+			"27 26 259:1 / /boot/efi rw,relatime shared:2 - vfat /dev/nvme0n1p1 rw,fmask=0077\n" +
+			"28 26 0:25 / /run rw,nosuid,nodev shared:5 - tmpfs tmpfs rw,size=3276800k\n" +
+			// Bind mount of the root filesystem, reported once
+			"29 26 259:2 /srv /var/lib/srv rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw\n" +
+			"30 26 7:0 / /mnt/My\\040ISO ro,relatime shared:9 - iso9660 /dev/loop0 ro\n" +
+			"31 26 0:40 / /mnt/nfs rw,relatime shared:10 - nfs4 server:/export rw\n",
+	})
+
+	used := uint64(60 << 30)
+	tracker := newFilesystemTracker(procRoot, DefaultFilesystemFilter)
+	tracker.statfs = func(path string) (fsUsage, error) {
+		switch path {
+		case "/":
+			// 100 GiB with 5 GiB reserved for root
+			return fsUsage{total: 100 << 30, free: 100<<30 - used, avail: 95<<30 - used, inodes: 1000, inodesFree: 250}, nil
+		case "/boot/efi":
+			return fsUsage{total: 512 << 20, free: 448 << 20, avail: 448 << 20}, nil
+		case "/mnt/My ISO":
+			return fsUsage{total: 4 << 30, readOnly: true}, nil
+		}
+		return fsUsage{}, errors.New("stale file handle")
+	}
+
+	start := time.Unix(1000, 0)
+	filesystems, err := tracker.sample(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mounts []string
+	for _, fs := range filesystems {
+		mounts = append(mounts, fs.Mount)
+	}
+	if len(filesystems) != 3 || mounts[0] != "/" || mounts[1] != "/boot/efi" || mounts[2] != "/mnt/My ISO" {
+		t.Fatalf("Unexpected mounts %q", mounts)
+	}
+
+	root := filesystems[0]
+	if root.Device != "/dev/nvme0n1p2" || root.FSType != "ext4" || root.ReadOnly {
+		t.Errorf("Unexpected root filesystem %+v", root)
+	}
+	// 60 GiB used of the 95 GiB usable by regular users
+	if root.Used != 60<<30 || root.Free != 35<<30 || int(root.UsedPercent*10) != 631 {
+		t.Errorf("Unexpected usage %d/%d (%.2f%%)", root.Used, root.Free, root.UsedPercent)
+	}
+	if root.InodesUsed != 750 || root.InodesPercent != 75 {
+		t.Errorf("Unexpected inode usage %d (%.1f%%)", root.InodesUsed, root.InodesPercent)
+	}
+	if root.TimeToFull != 0 {
+		t.Errorf("Expected no estimate without history, got %v", root.TimeToFull)
+	}
+	if !filesystems[2].ReadOnly {
+		t.Error("Expected the ISO mount to be read-only")
+	}
+
+	// Growing 1 GiB per minute leaves 34 GiB for another 34 minutes
+	used += 1 << 30
+	filesystems, _ = tracker.sample(start.Add(time.Minute))
+	root = filesystems[0]
+	if root.GrowthRate != float64(1<<30)/60 {
+		t.Errorf("Unexpected growth rate %.0f", root.GrowthRate)
+	}
+	if root.TimeToFull != 34*time.Minute {
+		t.Errorf("Expected full in 34m, got %v", root.TimeToFull)
+	}
+}
+
+func TestFilesystemFilter(t *testing.T) {
+	filter := FilesystemFilter{ExcludeTypes: []string{"tmpfs"}, ExcludeMounts: []string{"/snap/"}}
+	cases := []struct {
+		mount, fstype string
+		want          bool
+	}{
+		{"/tmp", "tmpfs", true},
+		{"/snap", "ext4", true},
+		{"/snap/core/123", "squashfs", true},
+		{"/snapshots", "btrfs", false},
+		{"/", "ext4", false},
+	}
+	for _, c := range cases {
+		if got := filter.excludes(c.mount, c.fstype); got != c.want {
+			t.Errorf("excludes(%q, %q) = %v, want %v", c.mount, c.fstype, got, c.want)
+		}
+	}
+}
+
+func TestRealProviderFilesystemFilterDefaults(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter FilesystemFilter
+		want   FilesystemFilter
+	}{
+		{"unset", FilesystemFilter{}, DefaultFilesystemFilter},
+		{"empty", FilesystemFilter{ExcludeTypes: []string{}, ExcludeMounts: []string{}}, FilesystemFilter{ExcludeTypes: []string{}, ExcludeMounts: []string{}}},
+		{"types only", FilesystemFilter{ExcludeTypes: []string{"nfs"}}, FilesystemFilter{ExcludeTypes: []string{"nfs"}, ExcludeMounts: DefaultFilesystemFilter.ExcludeMounts}},
+	}
+	for _, c := range cases {
+		provider := &RealProvider{GPUBackend: &FakeGPUBackend{}, ProcRoot: t.TempDir(), SysfsRoot: t.TempDir(), FilesystemFilter: c.filter}
+		if err := provider.Init(); err != nil {
+			t.Fatalf("%s: Init failed: %v", c.name, err)
+		}
+		if got := provider.FilesystemFilter; !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: filter = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
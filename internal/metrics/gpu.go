@@ -0,0 +1,147 @@
+package metrics
+
+import "fmt"
+
+// Clock throttle reasons reported in GPUStats.Throttle.
+const (
+	ThrottleIdle          = "idle"
+	ThrottleAppClocks     = "app-clocks"
+	ThrottlePowerCap      = "power-cap"
+	ThrottleHWSlowdown    = "hw-slowdown"
+	ThrottleSyncBoost     = "sync-boost"
+	ThrottleSWThermal     = "sw-thermal"
+	ThrottleHWThermal     = "hw-thermal"
+	ThrottlePowerBrake    = "hw-power-brake"
+	ThrottleDisplayClocks = "display-clocks"
+)
+
+// ThrottleCause classifies the active throttle reasons into the question
+// users actually ask: is the device held back by "thermal" limits, by
+// "power" limits, or "" when it is not throttled in a way that costs
+// performance (idle, application clocks, sync boost).
+func (g GPUStats) ThrottleCause() string {
+	thermal, power := false, false
+	for _, r := range g.Throttle {
+		switch r {
+		case ThrottleSWThermal, ThrottleHWThermal:
+			thermal = true
+		case ThrottlePowerCap, ThrottlePowerBrake, ThrottleHWSlowdown:
+			power = true
+		}
+	}
+	switch {
+	case thermal && power:
+		return "thermal+power"
+	case thermal:
+		return "thermal"
+	case power:
+		return "power"
+	}
+	return ""
+}
+
+// AggregateGPUs folds a set of devices into a single GPUStats so the UI can
+// present a whole-host view. Utilization and history are averaged, memory and
+// power are summed, and temperature/fan report the hottest device.
+func AggregateGPUs(gpus []GPUStats) GPUStats {
+	agg := GPUStats{Index: -1}
+	count := 0
+	for _, g := range gpus {
+		if !g.Available {
+			continue
+		}
+		count++
+		agg.Available = true
+		agg.Utilization += g.Utilization
+		agg.MemoryTotal += g.MemoryTotal
+		agg.MemoryUsed += g.MemoryUsed
+		agg.PowerUsage += g.PowerUsage
+		agg.PowerLimit += g.PowerLimit
+		agg.PCIeRxSpeed += g.PCIeRxSpeed
+		agg.PCIeTxSpeed += g.PCIeTxSpeed
+		agg.ECCCorrected += g.ECCCorrected
+		agg.ECCUncorrected += g.ECCUncorrected
+		if g.EncoderUtil > agg.EncoderUtil {
+			agg.EncoderUtil = g.EncoderUtil
+		}
+		if g.DecoderUtil > agg.DecoderUtil {
+			agg.DecoderUtil = g.DecoderUtil
+		}
+		for class, util := range g.Engines {
+			if agg.Engines == nil {
+				agg.Engines = make(map[string]uint32)
+			}
+			if util > agg.Engines[class] {
+				agg.Engines[class] = util
+			}
+		}
+		for _, r := range g.Throttle {
+			if !containsString(agg.Throttle, r) {
+				agg.Throttle = append(agg.Throttle, r)
+			}
+		}
+		if g.Temperature > agg.Temperature {
+			agg.Temperature = g.Temperature
+		}
+		if g.FanSpeed > agg.FanSpeed {
+			agg.FanSpeed = g.FanSpeed
+		}
+		agg.Processes = append(agg.Processes, g.Processes...)
+
+		// Right-align histories of differing lengths before averaging
+		if len(g.HistoricalUtil) > len(agg.HistoricalUtil) {
+			grown := make([]float64, len(g.HistoricalUtil))
+			copy(grown[len(grown)-len(agg.HistoricalUtil):], agg.HistoricalUtil)
+			agg.HistoricalUtil = grown
+		}
+		offset := len(agg.HistoricalUtil) - len(g.HistoricalUtil)
+		for i, v := range g.HistoricalUtil {
+			agg.HistoricalUtil[offset+i] += v
+		}
+	}
+	if count == 0 {
+		return agg
+	}
+
+	agg.Name = fmt.Sprintf("%d GPUs", count)
+	agg.Utilization /= uint32(count)
+	if agg.MemoryTotal > 0 {
+		agg.MemoryUtil = uint32(float64(agg.MemoryUsed) / float64(agg.MemoryTotal) * 100.0)
+	}
+	for i := range agg.HistoricalUtil {
+		agg.HistoricalUtil[i] /= float64(count)
+	}
+	return agg
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// gpuUsage summarizes a process's footprint across every device.
+type gpuUsage struct {
+	memory uint64
+	util   uint32
+}
+
+// gpuUsageByPid indexes the per-device process lists by PID so the system
+// process table can be annotated with GPU usage.
+func gpuUsageByPid(gpus []GPUStats) map[uint32]gpuUsage {
+	usage := make(map[uint32]gpuUsage)
+	for _, g := range gpus {
+		for _, p := range g.Processes {
+			u := usage[p.PID]
+			u.memory += p.MemoryUsed
+			if p.SMUtil > u.util {
+				u.util = p.SMUtil
+			}
+			usage[p.PID] = u
+		}
+	}
+	return usage
+}
@@ -0,0 +1,325 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AMDBackend implements GPUBackend for amdgpu devices using the DRM sysfs
+// interface (/sys/class/drm/card*/device) and its hwmon nodes. Processes and
+// per-engine usage come from DRM fdinfo.
+type AMDBackend struct {
+	// SysfsRoot and ProcRoot default to "/sys" and "/proc"; tests point them
+	// at fixture trees.
+	SysfsRoot string
+	ProcRoot  string
+
+	now   func() time.Time
+	drm   drmClients
+	usage map[drmDevice][]drmProcessUsage // fdinfo usage from the last sample
+	cards []string                        // device directories, ordered by card number
+	pdevs []string                        // PCI slot name per card
+}
+
+const amdVendorID = "0x1002"
+
+var drmCardPattern = regexp.MustCompile(`^card(\d+)$`)
+
+func (b *AMDBackend) Name() string { return "amdgpu" }
+
+func (b *AMDBackend) Init() error {
+	if b.SysfsRoot == "" {
+		b.SysfsRoot = "/sys"
+	}
+	if b.ProcRoot == "" {
+		b.ProcRoot = "/proc"
+	}
+	if b.now == nil {
+		b.now = time.Now
+	}
+	b.cards = findDRMCards(b.SysfsRoot, func(dev string) bool {
+		vendor, err := readSysfsString(filepath.Join(dev, "vendor"))
+		return err == nil && vendor == amdVendorID
+	})
+	if len(b.cards) == 0 {
+		return errors.New("no amdgpu devices found")
+	}
+	b.pdevs = make([]string, len(b.cards))
+	for i, dev := range b.cards {
+		b.pdevs[i] = pciSlotName(dev)
+	}
+	return nil
+}
+
+func (b *AMDBackend) Shutdown() {}
+
+func (b *AMDBackend) shareDRMTracker(t *drmClientTracker) {
+	b.drm.share(t)
+}
+
+func (b *AMDBackend) drmDrivers() []string { return []string{"amdgpu"} }
+
+// DeviceCount marks the start of a sample and picks up the fdinfo usage.
+func (b *AMDBackend) DeviceCount() (int, error) {
+	b.usage = b.drm.sample(b.ProcRoot, b.now())
+	return len(b.cards), nil
+}
+
+func (b *AMDBackend) Device(index int) (GPUDevice, error) {
+	if index < 0 || index >= len(b.cards) {
+		return nil, fmt.Errorf("amdgpu: no device at index %d", index)
+	}
+	dev := b.cards[index]
+	return amdDevice{
+		dir:     dev,
+		hwmon:   findHwmonDir(dev),
+		clients: drmDeviceUsage(b.usage, b.pdevs[index], "amdgpu"),
+		primed:  b.drm.primed(),
+	}, nil
+}
+
+// findDRMCards returns the device directories of /sys/class/drm/cardN entries
+// accepted by match, ordered by card number. Connector entries such as
+// card0-DP-1 are skipped.
+func findDRMCards(sysfsRoot string, match func(dev string) bool) []string {
+	entries, err := filepath.Glob(filepath.Join(sysfsRoot, "class", "drm", "card*"))
+	if err != nil {
+		return nil
+	}
+
+	type card struct {
+		num int
+		dev string
+	}
+	var cards []card
+	for _, e := range entries {
+		m := drmCardPattern.FindStringSubmatch(filepath.Base(e))
+		if m == nil {
+			continue
+		}
+		dev := filepath.Join(e, "device")
+		if !match(dev) {
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		cards = append(cards, card{num: num, dev: dev})
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].num < cards[j].num })
+
+	dirs := make([]string, len(cards))
+	for i, c := range cards {
+		dirs[i] = c.dev
+	}
+	return dirs
+}
+
+// pciSlotName returns the PCI address (e.g. "0000:00:02.0") of a device.
+func pciSlotName(dev string) string {
+	f, err := os.Open(filepath.Join(dev, "uevent"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "PCI_SLOT_NAME="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// findHwmonDir returns the first hwmon directory below a device, or "".
+func findHwmonDir(dev string) string {
+	matches, _ := filepath.Glob(filepath.Join(dev, "hwmon", "hwmon*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[0]
+}
+
+type amdDevice struct {
+	dir     string
+	hwmon   string
+	clients []drmProcessUsage
+	primed  bool // Engine usage needs two fdinfo samples
+}
+
+func (d amdDevice) Name() (string, error) {
+	if name, err := readSysfsString(filepath.Join(d.dir, "product_name")); err == nil && name != "" {
+		return name, nil
+	}
+	id, err := readSysfsString(filepath.Join(d.dir, "device"))
+	if err != nil {
+		return "AMD GPU", nil
+	}
+	return fmt.Sprintf("AMD GPU %s", id), nil
+}
+
+func (d amdDevice) Utilization() (uint32, uint32, error) {
+	busy, err := readSysfsUint(filepath.Join(d.dir, "gpu_busy_percent"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// mem_busy_percent is absent on APUs
+	memBusy, _ := readSysfsUint(filepath.Join(d.dir, "mem_busy_percent"))
+	return uint32(busy), uint32(memBusy), nil
+}
+
+func (d amdDevice) MemoryInfo() (uint64, uint64, error) {
+	total, err := readSysfsUint(filepath.Join(d.dir, "mem_info_vram_total"))
+	if err != nil {
+		return 0, 0, err
+	}
+	used, err := readSysfsUint(filepath.Join(d.dir, "mem_info_vram_used"))
+	return total, used, err
+}
+
+func (d amdDevice) Temperature() (uint32, error) {
+	if d.hwmon == "" {
+		return 0, ErrGPUNotSupported
+	}
+	// temp1 is the "edge" sensor on every amdgpu generation
+	milli, err := readSysfsUint(filepath.Join(d.hwmon, "temp1_input"))
+	return uint32(milli / 1000), err
+}
+
+func (d amdDevice) FanSpeed() (uint32, error) {
+	if d.hwmon == "" {
+		return 0, ErrGPUNotSupported
+	}
+	pwm, err := readSysfsUint(filepath.Join(d.hwmon, "pwm1"))
+	if err != nil {
+		return 0, err
+	}
+	pwmMax, err := readSysfsUint(filepath.Join(d.hwmon, "pwm1_max"))
+	if err != nil || pwmMax == 0 {
+		pwmMax = 255
+	}
+	return uint32(pwm * 100 / pwmMax), nil
+}
+
+func (d amdDevice) PowerUsage() (uint32, error) {
+	if d.hwmon == "" {
+		return 0, ErrGPUNotSupported
+	}
+	// Older kernels expose power1_average, RDNA3+ only power1_input (µW)
+	micro, err := readSysfsUint(filepath.Join(d.hwmon, "power1_average"))
+	if err != nil {
+		micro, err = readSysfsUint(filepath.Join(d.hwmon, "power1_input"))
+	}
+	return uint32(micro / 1000), err
+}
+
+func (d amdDevice) PowerLimit() (uint32, error) {
+	if d.hwmon == "" {
+		return 0, ErrGPUNotSupported
+	}
+	micro, err := readSysfsUint(filepath.Join(d.hwmon, "power1_cap"))
+	return uint32(micro / 1000), err
+}
+
+func (d amdDevice) Clocks() (uint32, uint32, error) {
+	graphics, gerr := d.clock("freq1_input", "pp_dpm_sclk")
+	memory, merr := d.clock("freq2_input", "pp_dpm_mclk")
+	if gerr != nil && merr != nil {
+		return 0, 0, gerr
+	}
+	return graphics, memory, nil
+}
+
+// clock reads a clock in MHz from hwmon (Hz), falling back to the active
+// entry of a pp_dpm_* table ("1: 1800Mhz *").
+func (d amdDevice) clock(hwmonFile, dpmFile string) (uint32, error) {
+	if d.hwmon != "" {
+		if hz, err := readSysfsUint(filepath.Join(d.hwmon, hwmonFile)); err == nil {
+			return uint32(hz / 1000000), nil
+		}
+	}
+	table, err := readSysfsString(filepath.Join(d.dir, dpmFile))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(table, "\n") {
+		if !strings.HasSuffix(strings.TrimSpace(line), "*") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		mhz, err := strconv.ParseUint(strings.TrimSuffix(strings.ToLower(fields[1]), "mhz"), 10, 32)
+		if err == nil {
+			return uint32(mhz), nil
+		}
+	}
+	return 0, ErrGPUNotSupported
+}
+
+func (d amdDevice) PerformanceState() (string, error) {
+	return "", ErrGPUNotSupported
+}
+
+func (d amdDevice) ThrottleReasons() ([]string, error) {
+	return nil, ErrGPUNotSupported
+}
+
+func (d amdDevice) PCIeThroughput() (uint64, uint64, error) {
+	// pcie_bw blocks for a full second inside the kernel, too slow per tick
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d amdDevice) CodecUtilization() (uint32, uint32, error) {
+	if !d.primed {
+		return 0, 0, ErrGPUNotSupported
+	}
+	engines := drmEngineTotals(d.clients)
+	return engines[EngineEncode], engines[EngineDecode], nil
+}
+
+// EngineUtilization returns the busy percentage per engine class.
+func (d amdDevice) EngineUtilization() (map[string]uint32, error) {
+	if !d.primed {
+		return nil, ErrGPUNotSupported
+	}
+	return drmEngineTotals(d.clients), nil
+}
+
+func (d amdDevice) ECCErrors() (uint64, uint64, error) {
+	// ras/umc_err_count holds "ue: N" and "ce: N" lines
+	data, err := readSysfsString(filepath.Join(d.dir, "ras", "umc_err_count"))
+	if err != nil {
+		return 0, 0, ErrGPUNotSupported
+	}
+	var corrected, uncorrected uint64
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "ce":
+			corrected = n
+		case "ue":
+			uncorrected = n
+		}
+	}
+	return corrected, uncorrected, nil
+}
+
+func (d amdDevice) Processes() ([]GPUProcess, error) {
+	return drmGPUProcesses(d.clients), nil
+}
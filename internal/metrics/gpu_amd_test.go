@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAMDBackend(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		// Navi 21 discrete card with hwmon
+		"class/drm/card1/device/vendor":                      "0x1002\n",
+		"class/drm/card1/device/device":                      "0x73bf\n",
+		"class/drm/card1/device/product_name":                "AMD Radeon RX 6800 XT\n",
+		"class/drm/card1/device/gpu_busy_percent":            "87\n",
+		"class/drm/card1/device/mem_busy_percent":            "40\n",
+		"class/drm/card1/device/mem_info_vram_total":         "17163091968\n",
+		"class/drm/card1/device/mem_info_vram_used":          "4294967296\n",
+		"class/drm/card1/device/ras/umc_err_count":           "ue: 1\nce: 5\n",
+		"class/drm/card1/device/hwmon/hwmon3/temp1_input":    "65000\n",
+		"class/drm/card1/device/hwmon/hwmon3/pwm1":           "128\n",
+		"class/drm/card1/device/hwmon/hwmon3/pwm1_max":       "255\n",
+		"class/drm/card1/device/hwmon/hwmon3/power1_average": "215000000\n",
+		"class/drm/card1/device/hwmon/hwmon3/power1_cap":     "300000000\n",
+		"class/drm/card1/device/hwmon/hwmon3/freq1_input":    "2250000000\n",
+		// APU without hwmon clocks; pp_dpm tables instead
+		"class/drm/card0/device/vendor":              "0x1002\n",
+		"class/drm/card0/device/device":              "0x1638\n",
+		"class/drm/card0/device/gpu_busy_percent":    "3\n",
+		"class/drm/card0/device/mem_info_vram_total": "536870912\n",
+		"class/drm/card0/device/mem_info_vram_used":  "268435456\n",
+		"class/drm/card0/device/pp_dpm_sclk":         "0: 200Mhz\n1: 1100Mhz *\n2: 2100Mhz\n",
+		"class/drm/card0/device/pp_dpm_mclk":         "0: 400Mhz *\n1: 1600Mhz\n",
+		// Connector entry and a non-AMD card are ignored
+		"class/drm/card0-DP-1/status":   "connected\n",
+		"class/drm/card2/device/vendor": "0x10de\n",
+	})
+
+	backend := &AMDBackend{SysfsRoot: root, ProcRoot: t.TempDir()}
+	provider := &RealProvider{GPUBackend: backend}
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if len(stats.GPUs) != 2 {
+		t.Fatalf("Expected 2 AMD GPUs, got %d", len(stats.GPUs))
+	}
+
+	apu := stats.GPUs[0]
+	if apu.Name != "AMD GPU 0x1638" || apu.Utilization != 3 || apu.MemoryUsed != 268435456 {
+		t.Errorf("Unexpected APU stats: %+v", apu)
+	}
+	if apu.GraphicsClock != 1100 || apu.MemoryClock != 400 {
+		t.Errorf("Expected DPM clocks 1100/400, got %d/%d", apu.GraphicsClock, apu.MemoryClock)
+	}
+
+	dgpu := stats.GPUs[1]
+	if dgpu.Name != "AMD Radeon RX 6800 XT" || dgpu.Utilization != 87 || dgpu.MemoryUtil != 40 {
+		t.Errorf("Unexpected dGPU identity/utilization: %+v", dgpu)
+	}
+	if dgpu.MemoryTotal != 17163091968 || dgpu.MemoryUsed != 4294967296 {
+		t.Errorf("Unexpected VRAM %d/%d", dgpu.MemoryUsed, dgpu.MemoryTotal)
+	}
+	if dgpu.Temperature != 65 || dgpu.FanSpeed != 50 {
+		t.Errorf("Expected 65°C / 50%% fan, got %d / %d", dgpu.Temperature, dgpu.FanSpeed)
+	}
+	if dgpu.PowerUsage != 215000 || dgpu.PowerLimit != 300000 {
+		t.Errorf("Expected 215W of 300W, got %d/%d mW", dgpu.PowerUsage, dgpu.PowerLimit)
+	}
+	if dgpu.GraphicsClock != 2250 {
+		t.Errorf("Expected 2250 MHz core clock, got %d", dgpu.GraphicsClock)
+	}
+	if dgpu.ECCCorrected != 5 || dgpu.ECCUncorrected != 1 {
+		t.Errorf("Expected ECC 5/1, got %d/%d", dgpu.ECCCorrected, dgpu.ECCUncorrected)
+	}
+}
+
+func TestAMDBackendProcesses(t *testing.T) {
+	sys := t.TempDir()
+	proc := t.TempDir()
+	writeFixture(t, sys, map[string]string{
+		"class/drm/card0/device/vendor":           "0x1002\n",
+		"class/drm/card0/device/uevent":           "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:03:00.0\n",
+		"class/drm/card0/device/gpu_busy_percent": "60\n",
+	})
+	writeDRMClients(t, proc, map[string]string{
+		"300/fdinfo/7": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t11\ndrm-engine-gfx:\t0 ns\ndrm-engine-dec:\t0 ns\ndrm-memory-vram:\t524288 KiB\n",
+		// A client of another card in the same host
+		"301/fdinfo/4": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:0a:00.0\ndrm-client-id:\t2\ndrm-engine-gfx:\t0 ns\n",
+	})
+
+	now := time.Unix(500, 0)
+	backend := &AMDBackend{SysfsRoot: sys, ProcRoot: proc, now: func() time.Time { return now }}
+	if err := backend.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	backend.DeviceCount()
+
+	now = now.Add(time.Second)
+	writeDRMClients(t, proc, map[string]string{
+		"300/fdinfo/7": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t11\ndrm-engine-gfx:\t600000000 ns\ndrm-engine-dec:\t150000000 ns\ndrm-memory-vram:\t524288 KiB\n",
+	})
+	backend.DeviceCount()
+	dev, _ := backend.Device(0)
+	gpu := collectGPU(0, dev)
+
+	if len(gpu.Processes) != 1 {
+		t.Fatalf("Expected only the client of this card, got %+v", gpu.Processes)
+	}
+	p := gpu.Processes[0]
+	if p.PID != 300 || p.MemoryUsed != 512*1024*1024 || p.SMUtil != 60 || p.DecUtil != 15 {
+		t.Errorf("Unexpected process %+v", p)
+	}
+	if gpu.DecoderUtil != 15 || gpu.Engines[EngineRender] != 60 {
+		t.Errorf("Expected 15%% decode / 60%% render, got %d / %v", gpu.DecoderUtil, gpu.Engines)
+	}
+}
+
+func TestAMDBackendNoDevices(t *testing.T) {
+	backend := &AMDBackend{SysfsRoot: t.TempDir()}
+	if err := backend.Init(); err == nil {
+		t.Error("Init should fail without amdgpu devices")
+	}
+}
+
+func TestMultiGPUBackend(t *testing.T) {
+	nvidia := &FakeGPUBackend{Frames: [][]FakeGPUState{{{Name: "nv0"}, {Name: "nv1"}}}}
+	broken := &FakeGPUBackend{InitErr: ErrGPUNotSupported}
+	amd := &FakeGPUBackend{Frames: [][]FakeGPUState{{{Name: "amd0"}}}}
+
+	multi := &MultiGPUBackend{Backends: []GPUBackend{nvidia, broken, amd}}
+	if err := multi.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	count, err := multi.DeviceCount()
+	if err != nil || count != 3 {
+		t.Fatalf("Expected 3 devices, got %d (%v)", count, err)
+	}
+	for i, want := range []string{"nv0", "nv1", "amd0"} {
+		dev, err := multi.Device(i)
+		if err != nil {
+			t.Fatalf("Device(%d) failed: %v", i, err)
+		}
+		if name, _ := dev.Name(); name != want {
+			t.Errorf("Device(%d) = %q, want %q", i, name, want)
+		}
+	}
+	if _, err := multi.Device(3); err == nil {
+		t.Error("Device(3) should fail")
+	}
+
+	// A backend that fails to count keeps its slots, so the devices after
+	// it keep their indices
+	nvidia.CountErr = errors.New("driver reset")
+	count, err = multi.DeviceCount()
+	if err != nil || count != 3 {
+		t.Fatalf("Expected 3 devices after a failed count, got %d (%v)", count, err)
+	}
+	if _, err := multi.Device(0); err == nil {
+		t.Error("Device(0) should fail while its backend is failing")
+	}
+	if dev, err := multi.Device(2); err != nil {
+		t.Errorf("Device(2) failed: %v", err)
+	} else if name, _ := dev.Name(); name != "amd0" {
+		t.Errorf("Device(2) = %q, want amd0", name)
+	}
+
+	amd.CountErr = errors.New("driver reset")
+	if _, err := multi.DeviceCount(); err == nil {
+		t.Error("DeviceCount should fail when every backend fails")
+	}
+}
+
+func TestMultiGPUBackendSharesDRMTracker(t *testing.T) {
+	sys, proc := t.TempDir(), t.TempDir()
+	writeFixture(t, sys, map[string]string{
+		"class/drm/card0/device/vendor":   "0x8086\n",
+		"class/drm/card0/device/uevent":   "PCI_SLOT_NAME=0000:00:02.0\n",
+		"class/drm/card0/gt_act_freq_mhz": "1200\n",
+		"class/drm/card1/device/vendor":   "0x1002\n",
+		"class/drm/card1/device/uevent":   "PCI_SLOT_NAME=0000:03:00.0\n",
+	})
+	writeDRMClients(t, proc, map[string]string{
+		"10/fdinfo/4": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t1\ndrm-memory-vram:\t1024 KiB\n",
+		"20/fdinfo/3": "drm-driver:\ti915\ndrm-pdev:\t0000:00:02.0\ndrm-client-id:\t2\ndrm-total-system0:\t2 MiB\n",
+	})
+
+	now := func() time.Time { return time.Unix(1000, 0) }
+	amd := &AMDBackend{SysfsRoot: sys, ProcRoot: proc, now: now}
+	intel := &IntelBackend{SysfsRoot: sys, ProcRoot: proc, now: now}
+	multi := &MultiGPUBackend{Backends: []GPUBackend{amd, intel}, ProcRoot: proc, now: now}
+	if err := multi.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if count, err := multi.DeviceCount(); err != nil || count != 2 {
+			t.Fatalf("Expected 2 devices, got %d (%v)", count, err)
+		}
+	}
+	if multi.drm == nil || multi.drm.samples != 2 {
+		t.Fatalf("Expected one fdinfo scan per sample for both backends")
+	}
+	if amd.drm.tracker != multi.drm || intel.drm.tracker != multi.drm {
+		t.Error("Expected the backends to share the tracker")
+	}
+
+	for i, want := range []uint32{10, 20} {
+		dev, err := multi.Device(i)
+		if err != nil {
+			t.Fatalf("Device(%d) failed: %v", i, err)
+		}
+		procs, _ := dev.Processes()
+		if len(procs) != 1 || procs[0].PID != want {
+			t.Errorf("Device(%d) processes = %+v, want PID %d only", i, procs, want)
+		}
+	}
+}
+
+func TestFallbackGPUBackend(t *testing.T) {
+	primary := &FakeGPUBackend{InitErr: ErrGPUNotSupported}
+	secondary := &FakeGPUBackend{Frames: [][]FakeGPUState{{{Name: "fallback"}}}}
+
+	fallback := &FallbackGPUBackend{Backends: []GPUBackend{primary, secondary}}
+	if err := fallback.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if count, _ := fallback.DeviceCount(); count != 1 {
+		t.Fatalf("Expected 1 device, got %d", count)
+	}
+	fallback.Shutdown()
+	if primary.IsShutdown() || !secondary.IsShutdown() {
+		t.Error("Only the active backend should be shut down")
+	}
+}
@@ -0,0 +1,279 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrGPUNotSupported is returned by a GPUDevice for metrics its backend
+// cannot provide. Collectors treat it as "leave the field zero".
+var ErrGPUNotSupported = errors.New("gpu metric not supported by backend")
+
+// GPUBackend abstracts a vendor driver library (NVML, sysfs, ...) so GPU
+// collection can be swapped out or faked in tests.
+type GPUBackend interface {
+	Name() string
+	Init() error
+	Shutdown()
+	DeviceCount() (int, error)
+	Device(index int) (GPUDevice, error)
+}
+
+// GPUDevice exposes the per-device queries used by the collector. Power
+// values are in milliwatts, clocks in MHz and temperatures in Celsius.
+type GPUDevice interface {
+	Name() (string, error)
+	Utilization() (gpu uint32, memory uint32, err error)
+	MemoryInfo() (total uint64, used uint64, err error)
+	Temperature() (uint32, error)
+	FanSpeed() (uint32, error)
+	PowerUsage() (uint32, error)
+	PowerLimit() (uint32, error)
+	Clocks() (graphics uint32, memory uint32, err error)
+	PerformanceState() (string, error)
+	ThrottleReasons() ([]string, error)
+	PCIeThroughput() (rx uint64, tx uint64, err error) // Bytes per second
+	CodecUtilization() (encoder uint32, decoder uint32, err error)
+	ECCErrors() (corrected uint64, uncorrected uint64, err error)
+	Processes() ([]GPUProcess, error)
+}
+
+// RequestedClockReporter is implemented by devices that expose the clock the
+// driver asked for alongside the actual one (Intel GT frequency).
+type RequestedClockReporter interface {
+	RequestedClock() (uint32, error)
+}
+
+// EngineReporter is implemented by devices that report busy time per engine
+// class (render, copy, video, ...) rather than a single utilization figure.
+type EngineReporter interface {
+	EngineUtilization() (map[string]uint32, error)
+}
+
+// drmTrackerSharer is implemented by backends whose processes come from DRM
+// fdinfo, so that several of them can share one scan of /proc.
+type drmTrackerSharer interface {
+	shareDRMTracker(t *drmClientTracker)
+}
+
+// DefaultGPUBackend returns the backend RealProvider uses when none is
+// configured: the first NVIDIA driver interface that loads (go-nvml, gonvml,
+// then the nvidia-smi binary), plus amdgpu, i915/xe and DRM fdinfo for any
+// other driver.
+func DefaultGPUBackend() GPUBackend {
+	return &MultiGPUBackend{Backends: []GPUBackend{
+		&FallbackGPUBackend{Backends: []GPUBackend{&NVIDIABackend{}, &NVMLBackend{}, &NVIDIASMIBackend{}}},
+		&AMDBackend{},
+		&IntelBackend{},
+		&DRMBackend{},
+	}}
+}
+
+// FallbackGPUBackend uses the first of several alternative backends for the
+// same hardware that initializes successfully.
+type FallbackGPUBackend struct {
+	Backends []GPUBackend
+	active   GPUBackend
+}
+
+func (b *FallbackGPUBackend) Name() string {
+	if b.active != nil {
+		return b.active.Name()
+	}
+	names := make([]string, len(b.Backends))
+	for i, backend := range b.Backends {
+		names[i] = backend.Name()
+	}
+	return strings.Join(names, "|")
+}
+
+func (b *FallbackGPUBackend) Init() error {
+	var errs []error
+	for _, backend := range b.Backends {
+		if err := backend.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name(), err))
+			continue
+		}
+		b.active = backend
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func (b *FallbackGPUBackend) Shutdown() {
+	if b.active != nil {
+		b.active.Shutdown()
+	}
+}
+
+func (b *FallbackGPUBackend) drmDrivers() []string {
+	if c, ok := b.active.(drmDriverClaimer); ok {
+		return c.drmDrivers()
+	}
+	return nil
+}
+
+func (b *FallbackGPUBackend) DeviceCount() (int, error) {
+	if b.active == nil {
+		return 0, nil
+	}
+	return b.active.DeviceCount()
+}
+
+func (b *FallbackGPUBackend) Device(index int) (GPUDevice, error) {
+	if b.active == nil {
+		return nil, fmt.Errorf("gpu: no backend initialized")
+	}
+	return b.active.Device(index)
+}
+
+// MultiGPUBackend merges the devices of several backends (e.g. NVIDIA and
+// AMD cards in one host), numbering them consecutively in backend order.
+// Backends that fail to initialize are skipped. Backends reading DRM fdinfo
+// share one tracker, so /proc is walked once per sample on mixed hosts, and
+// a DRMBackend leaves out the drivers the other active backends claim.
+type MultiGPUBackend struct {
+	Backends []GPUBackend
+	// ProcRoot defaults to "/proc"; tests point it at a fixture tree.
+	ProcRoot string
+
+	now    func() time.Time
+	drm    *drmClientTracker // nil when no active backend reads fdinfo
+	active []GPUBackend
+	counts []int  // Device count per active backend from the last DeviceCount
+	failed []bool // Whether the backend's last DeviceCount failed
+}
+
+func (b *MultiGPUBackend) Name() string {
+	list := b.active
+	if len(list) == 0 {
+		list = b.Backends
+	}
+	names := make([]string, len(list))
+	for i, backend := range list {
+		names[i] = backend.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (b *MultiGPUBackend) Init() error {
+	if b.ProcRoot == "" {
+		b.ProcRoot = "/proc"
+	}
+	if b.now == nil {
+		b.now = time.Now
+	}
+	drm := newDRMClientTracker(b.ProcRoot)
+	var errs []error
+	b.active, b.drm = nil, nil
+	for _, backend := range b.Backends {
+		sharer, shares := backend.(drmTrackerSharer)
+		if shares {
+			sharer.shareDRMTracker(drm)
+		}
+		if err := backend.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name(), err))
+			continue
+		}
+		b.active = append(b.active, backend)
+		if shares {
+			b.drm = drm
+		}
+	}
+	if len(b.active) == 0 {
+		return errors.Join(errs...)
+	}
+
+	var claimed []string
+	for _, backend := range b.active {
+		if c, ok := backend.(drmDriverClaimer); ok {
+			claimed = append(claimed, c.drmDrivers()...)
+		}
+	}
+	for _, backend := range b.active {
+		if d, ok := backend.(*DRMBackend); ok {
+			d.exclude = claimed
+		}
+	}
+	return nil
+}
+
+func (b *MultiGPUBackend) Shutdown() {
+	for _, backend := range b.active {
+		backend.Shutdown()
+	}
+}
+
+// DeviceCount sums the device counts of the active backends. A backend
+// whose count fails keeps its previous one, with its devices reported
+// unavailable, so the devices after it keep their indices; it only fails
+// when every backend does.
+func (b *MultiGPUBackend) DeviceCount() (int, error) {
+	if len(b.counts) != len(b.active) {
+		b.counts = make([]int, len(b.active))
+		b.failed = make([]bool, len(b.active))
+	}
+	if b.drm != nil {
+		b.drm.sample(b.now())
+	}
+	var errs []error
+	total := 0
+	for i, backend := range b.active {
+		count, err := backend.DeviceCount()
+		b.failed[i] = err != nil
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name(), err))
+		} else {
+			b.counts[i] = count
+		}
+		total += b.counts[i]
+	}
+	if len(errs) > 0 && len(errs) == len(b.active) {
+		return 0, errors.Join(errs...)
+	}
+	return total, nil
+}
+
+func (b *MultiGPUBackend) Device(index int) (GPUDevice, error) {
+	for i, count := range b.counts {
+		if index < count {
+			if b.failed[i] {
+				return nil, fmt.Errorf("gpu: %s: device count failed", b.active[i].Name())
+			}
+			return b.active[i].Device(index)
+		}
+		index -= count
+	}
+	return nil, fmt.Errorf("gpu: no device at index %d", index)
+}
+
+// collectGPU queries every metric from a device. Individual query failures
+// leave the corresponding field zero rather than dropping the device.
+func collectGPU(index int, dev GPUDevice) GPUStats {
+	gpu := GPUStats{Available: true, Index: index}
+	gpu.Name, _ = dev.Name()
+	gpu.Utilization, gpu.MemoryUtil, _ = dev.Utilization()
+	gpu.MemoryTotal, gpu.MemoryUsed, _ = dev.MemoryInfo()
+	gpu.Temperature, _ = dev.Temperature()
+	gpu.FanSpeed, _ = dev.FanSpeed()
+	gpu.PowerUsage, _ = dev.PowerUsage()
+	gpu.PowerLimit, _ = dev.PowerLimit()
+	gpu.GraphicsClock, gpu.MemoryClock, _ = dev.Clocks()
+	if r, ok := dev.(RequestedClockReporter); ok {
+		gpu.RequestedClock, _ = r.RequestedClock()
+	}
+	gpu.PState, _ = dev.PerformanceState()
+	gpu.Throttle, _ = dev.ThrottleReasons()
+	gpu.PCIeRxSpeed, gpu.PCIeTxSpeed, _ = dev.PCIeThroughput()
+	gpu.EncoderUtil, gpu.DecoderUtil, _ = dev.CodecUtilization()
+	if r, ok := dev.(EngineReporter); ok {
+		gpu.Engines, _ = r.EngineUtilization()
+	}
+	gpu.ECCCorrected, gpu.ECCUncorrected, _ = dev.ECCErrors()
+	if procs, err := dev.Processes(); err == nil {
+		gpu.Processes = procs
+	}
+	return gpu
+}
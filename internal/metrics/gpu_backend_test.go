@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRealProviderFakeGPUBackend(t *testing.T) {
+	backend := &FakeGPUBackend{
+		Frames: [][]FakeGPUState{
+			{
+				{Name: "Fake A100", Utilization: 10, MemoryTotal: 40 << 30, MemoryUsed: 1 << 30, Temperature: 50, PowerUsage: 90000, PowerLimit: 400000, GraphicsClock: 1410, MemoryClock: 1215,
+					PState: "P0", Throttle: []string{ThrottleSWThermal}, PCIeRxSpeed: 1 << 20, EncoderUtil: 7, ECCCorrected: 3},
+				{Name: "Fake A100", Utilization: 20, Errors: map[string]error{"Temperature": errors.New("sensor offline")}},
+			},
+			{
+				{Name: "Fake A100", Utilization: 30, Processes: []GPUProcess{{PID: 42, MemoryUsed: 512 << 20}}},
+				{Name: "Fake A100", DeviceErr: errors.New("device lost")},
+			},
+		},
+	}
+
+	provider := &RealProvider{GPUBackend: backend}
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if len(stats.GPUs) != 2 {
+		t.Fatalf("Expected 2 GPUs, got %d", len(stats.GPUs))
+	}
+	first := stats.GPUs[0]
+	if first.Name != "Fake A100" || first.Utilization != 10 || first.PowerLimit != 400000 || first.GraphicsClock != 1410 {
+		t.Errorf("Unexpected first sample: %+v", first)
+	}
+	if first.PState != "P0" || first.ThrottleCause() != "thermal" || first.PCIeRxSpeed != 1<<20 || first.EncoderUtil != 7 || first.ECCCorrected != 3 {
+		t.Errorf("Extended telemetry not collected: %+v", first)
+	}
+	if !stats.GPUs[1].Available || stats.GPUs[1].Temperature != 0 || stats.GPUs[1].Utilization != 20 {
+		t.Errorf("A failed query should only zero its field: %+v", stats.GPUs[1])
+	}
+
+	stats, err = provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if got := stats.GPUs[0].HistoricalUtil; len(got) != 2 || got[0] != 10 || got[1] != 30 {
+		t.Errorf("Expected history [10 30], got %v", got)
+	}
+	if len(stats.GPUs[0].Processes) != 1 || stats.GPUs[0].Processes[0].PID != 42 {
+		t.Errorf("Expected replayed process list, got %v", stats.GPUs[0].Processes)
+	}
+	if stats.GPUs[1].Available || stats.GPUs[1].Index != 1 {
+		t.Errorf("Lost device should be reported unavailable at its index: %+v", stats.GPUs[1])
+	}
+
+	provider.Shutdown()
+	if !backend.IsShutdown() {
+		t.Error("Backend was not shut down")
+	}
+}
+
+func TestRealProviderGPUBackendInitFailure(t *testing.T) {
+	backend := &FakeGPUBackend{
+		InitErr: errors.New("driver not loaded"),
+		Frames:  [][]FakeGPUState{{{Name: "Never seen"}}},
+	}
+
+	provider := &RealProvider{GPUBackend: backend}
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init should degrade gracefully, got: %v", err)
+	}
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if len(stats.GPUs) != 0 {
+		t.Errorf("Expected no GPUs when the backend fails to init, got %d", len(stats.GPUs))
+	}
+	provider.Shutdown()
+	if backend.IsShutdown() {
+		t.Error("Backend that failed to init should not be shut down")
+	}
+}
+
+func TestGPUUsageByPid(t *testing.T) {
+	gpus := []GPUStats{
+		{Index: 0, Processes: []GPUProcess{{PID: 10, MemoryUsed: 100, SMUtil: 30}, {PID: 11, MemoryUsed: 5}}},
+		{Index: 1, Processes: []GPUProcess{{PID: 10, MemoryUsed: 50, SMUtil: 70}}},
+	}
+
+	usage := gpuUsageByPid(gpus)
+	if len(usage) != 2 {
+		t.Fatalf("Expected 2 GPU users, got %d", len(usage))
+	}
+	if u := usage[10]; u.memory != 150 || u.util != 70 {
+		t.Errorf("Expected PID 10 to use 150 bytes at 70%%, got %+v", u)
+	}
+	if _, ok := usage[12]; ok {
+		t.Error("PID 12 should not be a GPU user")
+	}
+}
+
+func TestGPUThrottleCause(t *testing.T) {
+	cases := []struct {
+		reasons []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{ThrottleIdle}, ""},
+		{[]string{ThrottlePowerCap}, "power"},
+		{[]string{ThrottleHWThermal}, "thermal"},
+		{[]string{ThrottleSWThermal, ThrottlePowerBrake}, "thermal+power"},
+	}
+	for _, c := range cases {
+		if got := (GPUStats{Throttle: c.reasons}).ThrottleCause(); got != c.want {
+			t.Errorf("ThrottleCause(%v) = %q, want %q", c.reasons, got, c.want)
+		}
+	}
+}
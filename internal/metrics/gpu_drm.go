@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DRMBackend implements GPUBackend for the GPUs no vendor backend covers
+// (msm, panfrost, nouveau, v3d, ...) from DRM fdinfo alone: processes,
+// per-engine usage and a utilization estimate summed over the clients.
+//
+// Devices are the ones clients were seen on, listed from the sample they
+// first show up in and kept afterwards so that indices stay put. Drivers
+// without fdinfo stats, such as display-only BMC and firmware framebuffer
+// drivers, never appear. Inside a MultiGPUBackend it leaves out the drivers
+// other active backends report themselves.
+type DRMBackend struct {
+	// SysfsRoot and ProcRoot default to "/sys" and "/proc"; tests point them
+	// at fixture trees.
+	SysfsRoot string
+	ProcRoot  string
+
+	now     func() time.Time
+	drm     drmClients
+	usage   map[drmDevice][]drmProcessUsage // fdinfo usage from the last sample
+	exclude []string                        // Drivers claimed by other backends
+	devices []drmDevice
+}
+
+// drmDriverClaimer is implemented by backends that report the DRM clients of
+// their drivers themselves, so that DRMBackend does not list their devices
+// a second time.
+type drmDriverClaimer interface {
+	drmDrivers() []string
+}
+
+func (b *DRMBackend) Name() string { return "drm" }
+
+// Init only checks for DRM nodes; devices are found as clients use them.
+func (b *DRMBackend) Init() error {
+	if b.SysfsRoot == "" {
+		b.SysfsRoot = "/sys"
+	}
+	if b.ProcRoot == "" {
+		b.ProcRoot = "/proc"
+	}
+	if b.now == nil {
+		b.now = time.Now
+	}
+	nodes, _ := filepath.Glob(filepath.Join(b.SysfsRoot, "class", "drm", "renderD*"))
+	if len(nodes) == 0 {
+		return errors.New("no DRM render nodes found")
+	}
+	b.devices = nil
+	return nil
+}
+
+func (b *DRMBackend) Shutdown() {}
+
+func (b *DRMBackend) shareDRMTracker(t *drmClientTracker) {
+	b.drm.share(t)
+}
+
+// DeviceCount marks the start of a sample, picks up the fdinfo usage and
+// adds the devices it shows for the first time.
+func (b *DRMBackend) DeviceCount() (int, error) {
+	b.usage = b.drm.sample(b.ProcRoot, b.now())
+	var added []drmDevice
+	for dev := range b.usage {
+		if !slices.Contains(b.exclude, dev.driver) && !slices.Contains(b.devices, dev) {
+			added = append(added, dev)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool {
+		if added[i].driver != added[j].driver {
+			return added[i].driver < added[j].driver
+		}
+		return added[i].pdev < added[j].pdev
+	})
+	b.devices = append(b.devices, added...)
+	return len(b.devices), nil
+}
+
+func (b *DRMBackend) Device(index int) (GPUDevice, error) {
+	if index < 0 || index >= len(b.devices) {
+		return nil, fmt.Errorf("drm: no device at index %d", index)
+	}
+	dev := b.devices[index]
+	return drmGPUDevice{
+		dev:     dev,
+		clients: drmDeviceUsage(b.usage, dev.pdev, dev.driver),
+		primed:  b.drm.primed(),
+	}, nil
+}
+
+type drmGPUDevice struct {
+	dev     drmDevice
+	clients []drmProcessUsage
+	primed  bool // Engine usage needs two fdinfo samples
+}
+
+func (d drmGPUDevice) Name() (string, error) {
+	if d.dev.pdev == "" {
+		return d.dev.driver, nil
+	}
+	return fmt.Sprintf("%s (%s)", d.dev.driver, d.dev.pdev), nil
+}
+
+// Utilization estimates device busy time as the busiest of the render and
+// compute engines, summed over the clients.
+func (d drmGPUDevice) Utilization() (uint32, uint32, error) {
+	if !d.primed {
+		return 0, 0, ErrGPUNotSupported
+	}
+	engines := drmEngineTotals(d.clients)
+	return max(engines[EngineRender], engines[EngineCompute]), 0, nil
+}
+
+func (d drmGPUDevice) MemoryInfo() (uint64, uint64, error) {
+	// fdinfo only has the clients' share, not the device's size
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) Temperature() (uint32, error) {
+	return 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) FanSpeed() (uint32, error) {
+	return 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) PowerUsage() (uint32, error) {
+	return 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) PowerLimit() (uint32, error) {
+	return 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) Clocks() (uint32, uint32, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) PerformanceState() (string, error) {
+	return "", ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) ThrottleReasons() ([]string, error) {
+	return nil, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) PCIeThroughput() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) CodecUtilization() (uint32, uint32, error) {
+	if !d.primed {
+		return 0, 0, ErrGPUNotSupported
+	}
+	engines := drmEngineTotals(d.clients)
+	return max(engines[EngineEncode], engines[EngineVideo]), max(engines[EngineDecode], engines[EngineVideo]), nil
+}
+
+// EngineUtilization returns the busy percentage per engine class.
+func (d drmGPUDevice) EngineUtilization() (map[string]uint32, error) {
+	if !d.primed {
+		return nil, ErrGPUNotSupported
+	}
+	return drmEngineTotals(d.clients), nil
+}
+
+func (d drmGPUDevice) ECCErrors() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d drmGPUDevice) Processes() ([]GPUProcess, error) {
+	return drmGPUProcesses(d.clients), nil
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDRMBackend(t *testing.T) {
+	sys, proc := t.TempDir(), t.TempDir()
+	writeFixture(t, sys, map[string]string{
+		"class/drm/card0/device/vendor": "0x1002\n",
+		"class/drm/card0/device/uevent": "PCI_SLOT_NAME=0000:03:00.0\n",
+		"class/drm/renderD128/dev":      "226:128\n",
+		"class/drm/renderD129/dev":      "226:129\n",
+	})
+	writeDRMClients(t, proc, map[string]string{
+		"10/fdinfo/4": "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t1\ndrm-engine-gfx:\t0 ns\n",
+		"20/fdinfo/5": "drm-driver:\tmsm\ndrm-pdev:\tae00000.gpu\ndrm-client-id:\t3\ndrm-engine-gpu:\t0 ns\ndrm-resident-memory:\t8 MiB\n",
+	})
+
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+	drm := &DRMBackend{SysfsRoot: sys, ProcRoot: proc, now: clock}
+	multi := &MultiGPUBackend{
+		Backends: []GPUBackend{&AMDBackend{SysfsRoot: sys, ProcRoot: proc, now: clock}, drm},
+		ProcRoot: proc,
+		now:      clock,
+	}
+	if err := multi.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	// The amdgpu client belongs to the AMD backend
+	if count, err := multi.DeviceCount(); err != nil || count != 2 {
+		t.Fatalf("Expected the AMD card and the msm device, got %d (%v)", count, err)
+	}
+
+	now = now.Add(time.Second)
+	writeDRMClients(t, proc, map[string]string{
+		"20/fdinfo/5": "drm-driver:\tmsm\ndrm-pdev:\tae00000.gpu\ndrm-client-id:\t3\ndrm-engine-gpu:\t400000000 ns\ndrm-resident-memory:\t8 MiB\n",
+		"30/fdinfo/3": "drm-driver:\tpanfrost\ndrm-pdev:\tfde60000.gpu\ndrm-client-id:\t1\ndrm-engine-fragment:\t0 ns\n",
+	})
+	if count, _ := multi.DeviceCount(); count != 3 {
+		t.Fatalf("Expected the panfrost device added after the others, got %d", count)
+	}
+
+	dev, err := multi.Device(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msm := collectGPU(1, dev)
+	if msm.Name != "msm (ae00000.gpu)" || msm.Utilization != 40 || msm.Engines[EngineRender] != 40 {
+		t.Errorf("Unexpected msm device %+v", msm)
+	}
+	if len(msm.Processes) != 1 || msm.Processes[0].PID != 20 || msm.Processes[0].SMUtil != 40 || msm.Processes[0].MemoryUsed != 8<<20 {
+		t.Errorf("Expected pid 20 on the msm device, got %+v", msm.Processes)
+	}
+
+	dev, _ = multi.Device(2)
+	if panfrost := collectGPU(2, dev); panfrost.Name != "panfrost (fde60000.gpu)" || len(panfrost.Processes) != 1 || panfrost.Processes[0].PID != 30 {
+		t.Errorf("Unexpected panfrost device %+v", panfrost)
+	}
+
+	// Devices stay listed once their clients exit, keeping the indices
+	writeDRMClients(t, proc, map[string]string{
+		"20/fdinfo/5": "pos:\t0\n",
+	})
+	now = now.Add(time.Second)
+	if count, _ := multi.DeviceCount(); count != 3 {
+		t.Errorf("Expected 3 devices after the msm client exits, got %d", count)
+	}
+}
+
+func TestDRMBackendNoRenderNodes(t *testing.T) {
+	backend := &DRMBackend{SysfsRoot: t.TempDir(), ProcRoot: t.TempDir()}
+	if err := backend.Init(); err == nil {
+		t.Error("Expected Init to fail without DRM render nodes")
+	}
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import "fmt"
+
+// FakeGPUState is a canned device state replayed by FakeGPUBackend.
+type FakeGPUState struct {
+	Name          string
+	Utilization   uint32
+	MemoryUtil    uint32
+	MemoryTotal   uint64
+	MemoryUsed    uint64
+	Temperature   uint32
+	FanSpeed      uint32
+	PowerUsage    uint32 // mW
+	PowerLimit    uint32 // mW
+	GraphicsClock uint32
+	MemoryClock   uint32
+	PState        string
+	Throttle      []string
+	PCIeRxSpeed   uint64
+	PCIeTxSpeed   uint64
+	EncoderUtil   uint32
+	DecoderUtil   uint32
+	ECCCorrected  uint64
+	ECCUncorr     uint64
+	Processes     []GPUProcess
+
+	// DeviceErr makes the device handle lookup fail.
+	DeviceErr error
+	// Errors fails individual queries, keyed by GPUDevice method name
+	// (e.g. "Temperature", "Processes").
+	Errors map[string]error
+}
+
+// FakeGPUBackend is a scriptable GPUBackend for tests and demos. Each call to
+// DeviceCount starts a new sample and advances to the next frame; once the
+// script is exhausted the last frame repeats.
+type FakeGPUBackend struct {
+	Frames   [][]FakeGPUState
+	InitErr  error
+	CountErr error
+	frame    int
+	sampled  bool
+	shutdown bool
+}
+
+func (b *FakeGPUBackend) Name() string { return "fake" }
+
+func (b *FakeGPUBackend) Init() error {
+	return b.InitErr
+}
+
+func (b *FakeGPUBackend) Shutdown() {
+	b.shutdown = true
+}
+
+// IsShutdown reports whether Shutdown has been called.
+func (b *FakeGPUBackend) IsShutdown() bool {
+	return b.shutdown
+}
+
+func (b *FakeGPUBackend) DeviceCount() (int, error) {
+	if b.sampled && b.frame < len(b.Frames)-1 {
+		b.frame++
+	}
+	b.sampled = true
+	if b.CountErr != nil {
+		return 0, b.CountErr
+	}
+	return len(b.current()), nil
+}
+
+func (b *FakeGPUBackend) Device(index int) (GPUDevice, error) {
+	states := b.current()
+	if index < 0 || index >= len(states) {
+		return nil, fmt.Errorf("fake gpu: no device at index %d", index)
+	}
+	if states[index].DeviceErr != nil {
+		return nil, states[index].DeviceErr
+	}
+	return fakeGPUDevice{state: states[index]}, nil
+}
+
+func (b *FakeGPUBackend) current() []FakeGPUState {
+	if len(b.Frames) == 0 {
+		return nil
+	}
+	return b.Frames[b.frame]
+}
+
+type fakeGPUDevice struct {
+	state FakeGPUState
+}
+
+func (d fakeGPUDevice) err(method string) error {
+	return d.state.Errors[method]
+}
+
+func (d fakeGPUDevice) Name() (string, error) {
+	return d.state.Name, d.err("Name")
+}
+
+func (d fakeGPUDevice) Utilization() (uint32, uint32, error) {
+	if err := d.err("Utilization"); err != nil {
+		return 0, 0, err
+	}
+	return d.state.Utilization, d.state.MemoryUtil, nil
+}
+
+func (d fakeGPUDevice) MemoryInfo() (uint64, uint64, error) {
+	if err := d.err("MemoryInfo"); err != nil {
+		return 0, 0, err
+	}
+	return d.state.MemoryTotal, d.state.MemoryUsed, nil
+}
+
+func (d fakeGPUDevice) Temperature() (uint32, error) {
+	if err := d.err("Temperature"); err != nil {
+		return 0, err
+	}
+	return d.state.Temperature, nil
+}
+
+func (d fakeGPUDevice) FanSpeed() (uint32, error) {
+	if err := d.err("FanSpeed"); err != nil {
+		return 0, err
+	}
+	return d.state.FanSpeed, nil
+}
+
+func (d fakeGPUDevice) PowerUsage() (uint32, error) {
+	if err := d.err("PowerUsage"); err != nil {
+		return 0, err
+	}
+	return d.state.PowerUsage, nil
+}
+
+func (d fakeGPUDevice) PowerLimit() (uint32, error) {
+	if err := d.err("PowerLimit"); err != nil {
+		return 0, err
+	}
+	return d.state.PowerLimit, nil
+}
+
+func (d fakeGPUDevice) Clocks() (uint32, uint32, error) {
+	if err := d.err("Clocks"); err != nil {
+		return 0, 0, err
+	}
+	return d.state.GraphicsClock, d.state.MemoryClock, nil
+}
+
+func (d fakeGPUDevice) PerformanceState() (string, error) {
+	if err := d.err("PerformanceState"); err != nil {
+		return "", err
+	}
+	return d.state.PState, nil
+}
+
+func (d fakeGPUDevice) ThrottleReasons() ([]string, error) {
+	if err := d.err("ThrottleReasons"); err != nil {
+		return nil, err
+	}
+	return append([]string(nil), d.state.Throttle...), nil
+}
+
+func (d fakeGPUDevice) PCIeThroughput() (uint64, uint64, error) {
+	if err := d.err("PCIeThroughput"); err != nil {
+		return 0, 0, err
+	}
+	return d.state.PCIeRxSpeed, d.state.PCIeTxSpeed, nil
+}
+
+func (d fakeGPUDevice) CodecUtilization() (uint32, uint32, error) {
+	if err := d.err("CodecUtilization"); err != nil {
+		return 0, 0, err
+	}
+	return d.state.EncoderUtil, d.state.DecoderUtil, nil
+}
+
+func (d fakeGPUDevice) ECCErrors() (uint64, uint64, error) {
+	if err := d.err("ECCErrors"); err != nil {
+		return 0, 0, err
+	}
+	return d.state.ECCCorrected, d.state.ECCUncorr, nil
+}
+
+func (d fakeGPUDevice) Processes() ([]GPUProcess, error) {
+	if err := d.err("Processes"); err != nil {
+		return nil, err
+	}
+	procs := make([]GPUProcess, len(d.state.Processes))
+	copy(procs, d.state.Processes)
+	return procs, nil
+}
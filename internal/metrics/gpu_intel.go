@@ -0,0 +1,295 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// IntelBackend implements GPUBackend for Intel integrated and discrete GPUs
+// driven by i915 or xe. Busy time is estimated from RC6 (idle) residency,
+// power from the hwmon energy counter, and per-engine/per-process usage from
+// the DRM fdinfo of every client in /proc.
+type IntelBackend struct {
+	// SysfsRoot and ProcRoot default to "/sys" and "/proc"; tests point them
+	// at fixture trees.
+	SysfsRoot string
+	ProcRoot  string
+
+	now   func() time.Time
+	drm   drmClients
+	cards []*intelCard
+}
+
+const intelVendorID = "0x8086"
+
+type intelCard struct {
+	card   string // /sys/class/drm/cardN
+	dev    string // .../cardN/device
+	driver string // "i915" or "xe"
+	pdev   string // PCI slot name used to match fdinfo clients
+	hwmon  string
+
+	// Previous raw counters for delta computation
+	lastTime   time.Time
+	lastRC6    uint64 // ms
+	lastEnergy uint64 // µJ
+	hasLast    bool
+
+	// Derived values for the current sample
+	busy    uint32
+	busyOK  bool
+	power   uint32 // mW
+	powerOK bool
+	engines map[string]uint32
+	procs   []GPUProcess
+}
+
+func (b *IntelBackend) Name() string { return "intel" }
+
+func (b *IntelBackend) Init() error {
+	if b.SysfsRoot == "" {
+		b.SysfsRoot = "/sys"
+	}
+	if b.ProcRoot == "" {
+		b.ProcRoot = "/proc"
+	}
+	if b.now == nil {
+		b.now = time.Now
+	}
+
+	b.cards = nil
+	devs := findDRMCards(b.SysfsRoot, func(dev string) bool {
+		vendor, err := readSysfsString(filepath.Join(dev, "vendor"))
+		return err == nil && vendor == intelVendorID
+	})
+	for _, dev := range devs {
+		card := &intelCard{
+			card:   filepath.Dir(dev),
+			dev:    dev,
+			driver: intelDriver(filepath.Dir(dev), dev),
+			pdev:   pciSlotName(dev),
+			hwmon:  findHwmonDir(dev),
+		}
+		if card.driver == "" {
+			continue
+		}
+		b.cards = append(b.cards, card)
+	}
+	if len(b.cards) == 0 {
+		return errors.New("no i915/xe devices found")
+	}
+	return nil
+}
+
+func (b *IntelBackend) Shutdown() {}
+
+func (b *IntelBackend) shareDRMTracker(t *drmClientTracker) {
+	b.drm.share(t)
+}
+
+func (b *IntelBackend) drmDrivers() []string { return []string{"i915", "xe"} }
+
+// DeviceCount marks the start of a sample: counters are read once here and
+// the per-device queries report the derived rates.
+func (b *IntelBackend) DeviceCount() (int, error) {
+	now := b.now()
+	usage := b.drm.sample(b.ProcRoot, now)
+	for _, card := range b.cards {
+		card.sample(now, drmDeviceUsage(usage, card.pdev, card.driver))
+	}
+	return len(b.cards), nil
+}
+
+func (b *IntelBackend) Device(index int) (GPUDevice, error) {
+	if index < 0 || index >= len(b.cards) {
+		return nil, fmt.Errorf("intel: no device at index %d", index)
+	}
+	return intelDevice{card: b.cards[index]}, nil
+}
+
+// intelDriver identifies the kernel driver bound to a card, from the driver
+// symlink when present or from the driver-specific sysfs layout otherwise.
+func intelDriver(card, dev string) string {
+	if target, err := os.Readlink(filepath.Join(dev, "driver")); err == nil {
+		switch name := filepath.Base(target); name {
+		case "i915", "xe":
+			return name
+		}
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(card, "gt_act_freq_mhz")); err == nil {
+		return "i915"
+	}
+	if _, err := os.Stat(filepath.Join(dev, "tile0")); err == nil {
+		return "xe"
+	}
+	return ""
+}
+
+// freqPath returns the sysfs path of a GT frequency attribute for either
+// driver: i915 uses cardN/gt_<kind>_freq_mhz, xe tile0/gt0/freq0/<kind>_freq.
+func (c *intelCard) freqPath(kind string) string {
+	if c.driver == "xe" {
+		return filepath.Join(c.dev, "tile0", "gt0", "freq0", kind+"_freq")
+	}
+	return filepath.Join(c.card, "gt_"+kind+"_freq_mhz")
+}
+
+// rc6Residency returns the cumulative GT idle residency in milliseconds.
+func (c *intelCard) rc6Residency() (uint64, error) {
+	if c.driver == "xe" {
+		return readSysfsUint(filepath.Join(c.dev, "tile0", "gt0", "gtidle", "idle_residency_ms"))
+	}
+	ms, err := readSysfsUint(filepath.Join(c.card, "gt", "gt0", "rc6_residency_ms"))
+	if err != nil {
+		ms, err = readSysfsUint(filepath.Join(c.card, "power", "rc6_residency_ms"))
+	}
+	return ms, err
+}
+
+func (c *intelCard) sample(now time.Time, clients []drmProcessUsage) {
+	rc6, rc6Err := c.rc6Residency()
+	var energy uint64
+	energyErr := ErrGPUNotSupported
+	if c.hwmon != "" {
+		energy, energyErr = readSysfsUint(filepath.Join(c.hwmon, "energy1_input"))
+	}
+
+	c.busyOK, c.powerOK = false, false
+	c.engines = drmEngineTotals(clients)
+	c.procs = drmGPUProcesses(clients)
+
+	if c.hasLast {
+		elapsed := now.Sub(c.lastTime)
+		if elapsed > 0 {
+			if rc6Err == nil && rc6 >= c.lastRC6 {
+				idle := float64(rc6-c.lastRC6) / float64(elapsed.Milliseconds())
+				c.busy = percentUint((1 - idle) * 100)
+				c.busyOK = true
+			}
+			if energyErr == nil && energy >= c.lastEnergy {
+				// µJ / µs = W; scaled to mW
+				c.power = uint32(float64(energy-c.lastEnergy) / float64(elapsed.Microseconds()) * 1000)
+				c.powerOK = true
+			}
+		}
+	}
+
+	c.lastTime = now
+	c.lastRC6 = rc6
+	c.lastEnergy = energy
+	c.hasLast = true
+}
+
+type intelDevice struct {
+	card *intelCard
+}
+
+func (d intelDevice) Name() (string, error) {
+	id, err := readSysfsString(filepath.Join(d.card.dev, "device"))
+	if err != nil {
+		return fmt.Sprintf("Intel Graphics (%s)", d.card.driver), nil
+	}
+	return fmt.Sprintf("Intel Graphics %s (%s)", id, d.card.driver), nil
+}
+
+func (d intelDevice) Utilization() (uint32, uint32, error) {
+	if !d.card.busyOK {
+		return 0, 0, ErrGPUNotSupported
+	}
+	return d.card.busy, 0, nil
+}
+
+func (d intelDevice) MemoryInfo() (uint64, uint64, error) {
+	// Integrated parts share system memory; no VRAM accounting in sysfs
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d intelDevice) Temperature() (uint32, error) {
+	if d.card.hwmon == "" {
+		return 0, ErrGPUNotSupported
+	}
+	milli, err := readSysfsUint(filepath.Join(d.card.hwmon, "temp1_input"))
+	if err != nil {
+		return 0, ErrGPUNotSupported
+	}
+	return uint32(milli / 1000), nil
+}
+
+func (d intelDevice) FanSpeed() (uint32, error) {
+	return 0, ErrGPUNotSupported
+}
+
+func (d intelDevice) PowerUsage() (uint32, error) {
+	if !d.card.powerOK {
+		return 0, ErrGPUNotSupported
+	}
+	return d.card.power, nil
+}
+
+func (d intelDevice) PowerLimit() (uint32, error) {
+	if d.card.hwmon == "" {
+		return 0, ErrGPUNotSupported
+	}
+	micro, err := readSysfsUint(filepath.Join(d.card.hwmon, "power1_max"))
+	if err != nil {
+		return 0, ErrGPUNotSupported
+	}
+	return uint32(micro / 1000), nil
+}
+
+func (d intelDevice) Clocks() (uint32, uint32, error) {
+	mhz, err := readSysfsUint(d.card.freqPath("act"))
+	return uint32(mhz), 0, err
+}
+
+// RequestedClock returns the GT frequency requested by the driver in MHz.
+// Comparing it with the actual clock shows when the GPU is being held back.
+func (d intelDevice) RequestedClock() (uint32, error) {
+	mhz, err := readSysfsUint(d.card.freqPath("cur"))
+	return uint32(mhz), err
+}
+
+// EngineUtilization returns the busy percentage per engine class.
+func (d intelDevice) EngineUtilization() (map[string]uint32, error) {
+	if !d.card.hasLast {
+		return nil, ErrGPUNotSupported
+	}
+	engines := make(map[string]uint32, len(d.card.engines))
+	for k, v := range d.card.engines {
+		engines[k] = v
+	}
+	return engines, nil
+}
+
+func (d intelDevice) PerformanceState() (string, error) {
+	return "", ErrGPUNotSupported
+}
+
+func (d intelDevice) ThrottleReasons() ([]string, error) {
+	return nil, ErrGPUNotSupported
+}
+
+func (d intelDevice) PCIeThroughput() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d intelDevice) CodecUtilization() (uint32, uint32, error) {
+	// The video engine runs both encode and decode
+	video, ok := d.card.engines[EngineVideo]
+	if !ok {
+		return 0, 0, ErrGPUNotSupported
+	}
+	return video, video, nil
+}
+
+func (d intelDevice) ECCErrors() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d intelDevice) Processes() ([]GPUProcess, error) {
+	return append([]GPUProcess(nil), d.card.procs...), nil
+}
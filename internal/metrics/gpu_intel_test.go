@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntelBackend(t *testing.T) {
+	sys := t.TempDir()
+	proc := t.TempDir()
+	writeFixture(t, sys, map[string]string{
+		// Alder Lake iGPU on i915
+		"class/drm/card0/device/vendor":                     "0x8086\n",
+		"class/drm/card0/device/device":                     "0x46a6\n",
+		"class/drm/card0/device/uevent":                     "DRIVER=i915\nPCI_SLOT_NAME=0000:00:02.0\n",
+		"class/drm/card0/gt_act_freq_mhz":                   "1200\n",
+		"class/drm/card0/gt_cur_freq_mhz":                   "1300\n",
+		"class/drm/card0/gt/gt0/rc6_residency_ms":           "10000\n",
+		"class/drm/card0/device/hwmon/hwmon5/energy1_input": "1000000\n",
+		"class/drm/card0/device/hwmon/hwmon5/power1_max":    "15000000\n",
+		// Discrete card on xe
+		"class/drm/card1/device/vendor":                             "0x8086\n",
+		"class/drm/card1/device/device":                             "0xe20b\n",
+		"class/drm/card1/device/uevent":                             "DRIVER=xe\nPCI_SLOT_NAME=0000:03:00.0\n",
+		"class/drm/card1/device/tile0/gt0/freq0/act_freq":           "1800\n",
+		"class/drm/card1/device/tile0/gt0/freq0/cur_freq":           "2000\n",
+		"class/drm/card1/device/tile0/gt0/gtidle/idle_residency_ms": "5000\n",
+		"class/drm/card1/device/hwmon/hwmon6/temp1_input":           "55000\n",
+	})
+	writeDRMClients(t, proc, map[string]string{
+		// Client 7 is open through two duplicated descriptors
+		"100/fdinfo/5": "pos:\t0\ndrm-driver:\ti915\ndrm-pdev:\t0000:00:02.0\ndrm-client-id:\t7\ndrm-engine-render:\t1000000 ns\ndrm-engine-video:\t0 ns\n",
+		"100/fdinfo/6": "pos:\t0\ndrm-driver:\ti915\ndrm-pdev:\t0000:00:02.0\ndrm-client-id:\t7\ndrm-engine-render:\t1000000 ns\ndrm-engine-video:\t0 ns\n",
+		"100/fdinfo/0": "pos:\t0\nflags:\t02\n",
+		"200/fdinfo/3": "drm-driver:\txe\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t3\ndrm-cycles-rcs:\t100\ndrm-total-cycles-rcs:\t1000\n",
+	})
+
+	now := time.Unix(1000, 0)
+	backend := &IntelBackend{SysfsRoot: sys, ProcRoot: proc, now: func() time.Time { return now }}
+	if err := backend.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	// The first sample only establishes baselines
+	if n, _ := backend.DeviceCount(); n != 2 {
+		t.Fatalf("Expected 2 Intel GPUs, got %d", n)
+	}
+	dev, _ := backend.Device(0)
+	if _, _, err := dev.Utilization(); err != ErrGPUNotSupported {
+		t.Errorf("Expected no utilization before a second sample, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	writeFixture(t, sys, map[string]string{
+		"class/drm/card0/gt/gt0/rc6_residency_ms":                   "10250\n",
+		"class/drm/card0/device/hwmon/hwmon5/energy1_input":         "4000000\n",
+		"class/drm/card1/device/tile0/gt0/gtidle/idle_residency_ms": "5900\n",
+	})
+	writeDRMClients(t, proc, map[string]string{
+		"100/fdinfo/5": "drm-driver:\ti915\ndrm-pdev:\t0000:00:02.0\ndrm-client-id:\t7\ndrm-engine-render:\t501000000 ns\ndrm-engine-video:\t100000000 ns\n",
+		"100/fdinfo/6": "drm-driver:\ti915\ndrm-pdev:\t0000:00:02.0\ndrm-client-id:\t7\ndrm-engine-render:\t501000000 ns\ndrm-engine-video:\t100000000 ns\n",
+		"200/fdinfo/3": "drm-driver:\txe\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t3\ndrm-cycles-rcs:\t400\ndrm-total-cycles-rcs:\t2000\n",
+	})
+	backend.DeviceCount()
+
+	dev, _ = backend.Device(0)
+	igpu := collectGPU(0, dev)
+	if igpu.Name != "Intel Graphics 0x46a6 (i915)" || igpu.Utilization != 75 {
+		t.Errorf("Expected i915 device at 75%% busy, got %+v", igpu)
+	}
+	if igpu.GraphicsClock != 1200 || igpu.RequestedClock != 1300 {
+		t.Errorf("Expected 1200/1300 MHz actual/requested, got %d/%d", igpu.GraphicsClock, igpu.RequestedClock)
+	}
+	if igpu.PowerUsage != 3000 || igpu.PowerLimit != 15000 {
+		t.Errorf("Expected 3W of 15W, got %d/%d mW", igpu.PowerUsage, igpu.PowerLimit)
+	}
+	if igpu.Engines[EngineRender] != 50 || igpu.Engines[EngineVideo] != 10 || igpu.EncoderUtil != 10 {
+		t.Errorf("Unexpected engine utilization %v (enc %d)", igpu.Engines, igpu.EncoderUtil)
+	}
+	if len(igpu.Processes) != 1 {
+		t.Fatalf("Expected 1 client on the iGPU, got %+v", igpu.Processes)
+	}
+	if p := igpu.Processes[0]; p.PID != 100 || p.SMUtil != 50 || p.EncUtil != 10 {
+		t.Errorf("Unexpected iGPU process %+v", p)
+	}
+
+	dev, _ = backend.Device(1)
+	dgpu := collectGPU(1, dev)
+	if dgpu.Name != "Intel Graphics 0xe20b (xe)" || dgpu.Utilization != 10 || dgpu.Temperature != 55 {
+		t.Errorf("Unexpected xe device stats %+v", dgpu)
+	}
+	if dgpu.GraphicsClock != 1800 || dgpu.RequestedClock != 2000 {
+		t.Errorf("Expected 1800/2000 MHz actual/requested, got %d/%d", dgpu.GraphicsClock, dgpu.RequestedClock)
+	}
+	if dgpu.Engines[EngineRender] != 30 {
+		t.Errorf("Expected 30%% render from cycle counters, got %v", dgpu.Engines)
+	}
+	if len(dgpu.Processes) != 1 || dgpu.Processes[0].PID != 200 {
+		t.Errorf("Expected pid 200 on the xe device, got %+v", dgpu.Processes)
+	}
+}
+
+func TestIntelBackendNoDevices(t *testing.T) {
+	sys := t.TempDir()
+	writeFixture(t, sys, map[string]string{
+		"class/drm/card0/device/vendor": "0x1002\n",
+	})
+	backend := &IntelBackend{SysfsRoot: sys, ProcRoot: t.TempDir()}
+	if err := backend.Init(); err == nil {
+		t.Error("Expected Init to fail without Intel cards")
+	}
+}
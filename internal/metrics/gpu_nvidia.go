@@ -0,0 +1,240 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// NVIDIABackend implements GPUBackend on NVIDIA's official go-nvml bindings.
+// Unlike gonvml these expose process lists, clocks and power limits.
+type NVIDIABackend struct {
+	// Timestamp of the newest process utilization sample seen per device, so
+	// each query only returns samples taken since the previous tick.
+	lastSeen map[int]uint64
+}
+
+func (b *NVIDIABackend) Name() string { return "nvidia" }
+
+func (b *NVIDIABackend) Init() error {
+	b.lastSeen = make(map[int]uint64)
+	return nvmlError(nvml.Init())
+}
+
+func (b *NVIDIABackend) Shutdown() {
+	nvml.Shutdown()
+}
+
+func (b *NVIDIABackend) drmDrivers() []string { return []string{"nvidia-drm"} }
+
+func (b *NVIDIABackend) DeviceCount() (int, error) {
+	count, ret := nvml.DeviceGetCount()
+	return count, nvmlError(ret)
+}
+
+func (b *NVIDIABackend) Device(index int) (GPUDevice, error) {
+	dev, ret := nvml.DeviceGetHandleByIndex(index)
+	if err := nvmlError(ret); err != nil {
+		return nil, err
+	}
+	return nvidiaDevice{dev: dev, index: index, backend: b}, nil
+}
+
+// nvmlError converts an NVML return code into a Go error, mapping
+// "not supported" onto ErrGPUNotSupported.
+func nvmlError(ret nvml.Return) error {
+	switch ret {
+	case nvml.SUCCESS:
+		return nil
+	case nvml.ERROR_NOT_SUPPORTED:
+		return ErrGPUNotSupported
+	default:
+		return ret
+	}
+}
+
+type nvidiaDevice struct {
+	dev     nvml.Device
+	index   int
+	backend *NVIDIABackend
+}
+
+func (d nvidiaDevice) Name() (string, error) {
+	name, ret := d.dev.GetName()
+	return name, nvmlError(ret)
+}
+
+func (d nvidiaDevice) Utilization() (uint32, uint32, error) {
+	util, ret := d.dev.GetUtilizationRates()
+	return util.Gpu, util.Memory, nvmlError(ret)
+}
+
+func (d nvidiaDevice) MemoryInfo() (uint64, uint64, error) {
+	mem, ret := d.dev.GetMemoryInfo()
+	return mem.Total, mem.Used, nvmlError(ret)
+}
+
+func (d nvidiaDevice) Temperature() (uint32, error) {
+	temp, ret := d.dev.GetTemperature(nvml.TEMPERATURE_GPU)
+	return temp, nvmlError(ret)
+}
+
+func (d nvidiaDevice) FanSpeed() (uint32, error) {
+	fan, ret := d.dev.GetFanSpeed()
+	return fan, nvmlError(ret)
+}
+
+func (d nvidiaDevice) PowerUsage() (uint32, error) {
+	power, ret := d.dev.GetPowerUsage()
+	return power, nvmlError(ret)
+}
+
+func (d nvidiaDevice) PowerLimit() (uint32, error) {
+	limit, ret := d.dev.GetEnforcedPowerLimit()
+	return limit, nvmlError(ret)
+}
+
+func (d nvidiaDevice) Clocks() (uint32, uint32, error) {
+	graphics, ret := d.dev.GetClockInfo(nvml.CLOCK_GRAPHICS)
+	if err := nvmlError(ret); err != nil {
+		return 0, 0, err
+	}
+	memory, ret := d.dev.GetClockInfo(nvml.CLOCK_MEM)
+	return graphics, memory, nvmlError(ret)
+}
+
+func (d nvidiaDevice) PerformanceState() (string, error) {
+	pstate, ret := d.dev.GetPerformanceState()
+	if err := nvmlError(ret); err != nil {
+		return "", err
+	}
+	if pstate == nvml.PSTATE_UNKNOWN {
+		return "", ErrGPUNotSupported
+	}
+	return fmt.Sprintf("P%d", pstate), nil
+}
+
+// nvmlThrottleReasons maps NVML clock throttle bits onto backend-neutral names.
+var nvmlThrottleReasons = []struct {
+	bit  uint64
+	name string
+}{
+	{nvml.ClocksThrottleReasonGpuIdle, ThrottleIdle},
+	{nvml.ClocksThrottleReasonApplicationsClocksSetting, ThrottleAppClocks},
+	{nvml.ClocksThrottleReasonSwPowerCap, ThrottlePowerCap},
+	{nvml.ClocksThrottleReasonHwSlowdown, ThrottleHWSlowdown},
+	{nvml.ClocksThrottleReasonSyncBoost, ThrottleSyncBoost},
+	{nvml.ClocksThrottleReasonSwThermalSlowdown, ThrottleSWThermal},
+	{nvml.ClocksThrottleReasonHwThermalSlowdown, ThrottleHWThermal},
+	{nvml.ClocksThrottleReasonHwPowerBrakeSlowdown, ThrottlePowerBrake},
+	{nvml.ClocksThrottleReasonDisplayClockSetting, ThrottleDisplayClocks},
+}
+
+func (d nvidiaDevice) ThrottleReasons() ([]string, error) {
+	mask, ret := d.dev.GetCurrentClocksThrottleReasons()
+	if err := nvmlError(ret); err != nil {
+		return nil, err
+	}
+	var reasons []string
+	for _, r := range nvmlThrottleReasons {
+		if mask&r.bit != 0 {
+			reasons = append(reasons, r.name)
+		}
+	}
+	return reasons, nil
+}
+
+func (d nvidiaDevice) PCIeThroughput() (uint64, uint64, error) {
+	// NVML reports KB/s sampled over a 20ms window
+	rx, ret := d.dev.GetPcieThroughput(nvml.PCIE_UTIL_RX_BYTES)
+	if err := nvmlError(ret); err != nil {
+		return 0, 0, err
+	}
+	tx, ret := d.dev.GetPcieThroughput(nvml.PCIE_UTIL_TX_BYTES)
+	return uint64(rx) * 1024, uint64(tx) * 1024, nvmlError(ret)
+}
+
+func (d nvidiaDevice) CodecUtilization() (uint32, uint32, error) {
+	enc, _, ret := d.dev.GetEncoderUtilization()
+	if err := nvmlError(ret); err != nil {
+		return 0, 0, err
+	}
+	dec, _, ret := d.dev.GetDecoderUtilization()
+	return enc, dec, nvmlError(ret)
+}
+
+func (d nvidiaDevice) ECCErrors() (uint64, uint64, error) {
+	corrected, ret := d.dev.GetTotalEccErrors(nvml.MEMORY_ERROR_TYPE_CORRECTED, nvml.VOLATILE_ECC)
+	if err := nvmlError(ret); err != nil {
+		return 0, 0, err
+	}
+	uncorrected, ret := d.dev.GetTotalEccErrors(nvml.MEMORY_ERROR_TYPE_UNCORRECTED, nvml.VOLATILE_ECC)
+	return corrected, uncorrected, nvmlError(ret)
+}
+
+// nvmlProcessMemory returns the VRAM of a process, or 0 when the driver
+// reports NVML_VALUE_NOT_AVAILABLE (all ones), as it does under WDDM, in
+// containers and with MIG.
+func nvmlProcessMemory(used uint64) uint64 {
+	if used == ^uint64(0) {
+		return 0
+	}
+	return used
+}
+
+// Processes merges the compute and graphics process lists and attaches the
+// most recent per-process SM/memory/encoder/decoder utilization samples.
+func (d nvidiaDevice) Processes() ([]GPUProcess, error) {
+	compute, cret := d.dev.GetComputeRunningProcesses()
+	graphics, gret := d.dev.GetGraphicsRunningProcesses()
+	if cret != nvml.SUCCESS && gret != nvml.SUCCESS {
+		return nil, nvmlError(cret)
+	}
+
+	byPid := make(map[uint32]*GPUProcess)
+	add := func(infos []nvml.ProcessInfo, kind string) {
+		for _, info := range infos {
+			p, ok := byPid[info.Pid]
+			if !ok {
+				p = &GPUProcess{PID: info.Pid, Type: kind}
+				byPid[info.Pid] = p
+			} else if p.Type != kind {
+				p.Type = "C+G"
+			}
+			// A process in both lists reports the same allocation twice
+			p.MemoryUsed = max(p.MemoryUsed, nvmlProcessMemory(info.UsedGpuMemory))
+		}
+	}
+	add(compute, "C")
+	add(graphics, "G")
+
+	// Per-process utilization is optional; older GPUs return NOT_SUPPORTED
+	samples, ret := d.dev.GetProcessUtilization(d.backend.lastSeen[d.index])
+	if ret == nvml.SUCCESS {
+		latest := make(map[uint32]uint64)
+		for _, s := range samples {
+			if s.TimeStamp > d.backend.lastSeen[d.index] {
+				d.backend.lastSeen[d.index] = s.TimeStamp
+			}
+			p, ok := byPid[s.Pid]
+			if !ok || s.TimeStamp < latest[s.Pid] {
+				continue
+			}
+			latest[s.Pid] = s.TimeStamp
+			p.SMUtil = s.SmUtil
+			p.MemUtil = s.MemUtil
+			p.EncUtil = s.EncUtil
+			p.DecUtil = s.DecUtil
+		}
+	}
+
+	procs := make([]GPUProcess, 0, len(byPid))
+	for _, p := range byPid {
+		procs = append(procs, *p)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].MemoryUsed > procs[j].MemoryUsed
+	})
+	return procs, nil
+}
@@ -0,0 +1,371 @@
+package metrics
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NVIDIASMIBackend implements GPUBackend by parsing nvidia-smi CSV output. It
+// is the last resort for hosts (typically containers) where the nvidia-smi
+// binary is present but libnvidia-ml.so cannot be loaded.
+//
+// nvidia-smi takes one query per run and a run often takes a few hundred
+// milliseconds, so samples query the devices and only list the compute apps
+// every ProcessInterval.
+type NVIDIASMIBackend struct {
+	// Path of the nvidia-smi executable. Defaults to looking it up in PATH;
+	// tests point it at a stub script.
+	Path string
+	// Timeout bounds each invocation so a wedged driver cannot stall a tick.
+	Timeout time.Duration
+	// ProcessInterval is how often the compute apps are listed. Defaults to
+	// 5 seconds.
+	ProcessInterval time.Duration
+
+	now     func() time.Time
+	procsAt time.Time               // When the compute apps were last listed
+	fields  []string                // --query-gpu fields in use, nil until the first query
+	queries []string                // The names they are queried by on this driver
+	probed  bool                    // Whether fields was narrowed to what the driver knows
+	gpus    []map[string]string     // Query fields per device from the last sample
+	procs   map[string][]GPUProcess // Compute apps by GPU UUID
+}
+
+// nvidiaSMIGPUFields are the --query-gpu fields, in request order. The name
+// goes last since it is the only field that may contain commas.
+var nvidiaSMIGPUFields = []string{
+	"index",
+	"uuid",
+	"utilization.gpu",
+	"utilization.memory",
+	"memory.total",
+	"memory.used",
+	"temperature.gpu",
+	"fan.speed",
+	"power.draw",
+	"power.limit",
+	"clocks.gr",
+	"clocks.mem",
+	"pstate",
+	"clocks_throttle_reasons.active",
+	"ecc.errors.corrected.volatile.total",
+	"ecc.errors.uncorrected.volatile.total",
+	"name",
+}
+
+// nvidiaSMIRequiredFields are known to every nvidia-smi. The others are
+// dropped when the installed driver does not know them, since a single
+// unknown field fails the whole query.
+var nvidiaSMIRequiredFields = map[string]bool{
+	"index": true, "uuid": true, "utilization.gpu": true,
+	"memory.total": true, "memory.used": true, "name": true,
+}
+
+// nvidiaSMIFieldRenames maps fields to the names newer drivers know them by.
+var nvidiaSMIFieldRenames = map[string]string{
+	"clocks_throttle_reasons.active": "clocks_event_reasons.active",
+}
+
+// nvidiaSMIAppFields are the --query-compute-apps fields, name last as above.
+var nvidiaSMIAppFields = []string{"gpu_uuid", "pid", "used_memory", "process_name"}
+
+func (b *NVIDIASMIBackend) Name() string { return "nvidia-smi" }
+
+func (b *NVIDIASMIBackend) Init() error {
+	if b.Path == "" {
+		path, err := exec.LookPath("nvidia-smi")
+		if err != nil {
+			return err
+		}
+		b.Path = path
+	}
+	if b.Timeout == 0 {
+		b.Timeout = 2 * time.Second
+	}
+	if b.ProcessInterval == 0 {
+		b.ProcessInterval = 5 * time.Second
+	}
+	if b.now == nil {
+		b.now = time.Now
+	}
+	b.procsAt = time.Time{}
+	return b.refresh()
+}
+
+func (b *NVIDIASMIBackend) Shutdown() {}
+
+func (b *NVIDIASMIBackend) drmDrivers() []string { return []string{"nvidia-drm"} }
+
+// DeviceCount re-runs nvidia-smi; the device queries report from that
+// snapshot.
+func (b *NVIDIASMIBackend) DeviceCount() (int, error) {
+	if err := b.refresh(); err != nil {
+		return 0, err
+	}
+	return len(b.gpus), nil
+}
+
+func (b *NVIDIASMIBackend) Device(index int) (GPUDevice, error) {
+	if index < 0 || index >= len(b.gpus) {
+		return nil, fmt.Errorf("nvidia-smi: no device at index %d", index)
+	}
+	row := b.gpus[index]
+	return nvidiaSMIDevice{row: row, procs: b.procs[row["uuid"]]}, nil
+}
+
+func (b *NVIDIASMIBackend) refresh() error {
+	if b.fields == nil {
+		b.fields, b.queries = nvidiaSMIGPUFields, nvidiaSMIGPUFields
+	}
+	rows, err := b.query("--query-gpu="+strings.Join(b.queries, ","), b.fields)
+	if err != nil && !b.probed {
+		// Retry with only the fields this driver knows
+		b.probeFields()
+		rows, err = b.query("--query-gpu="+strings.Join(b.queries, ","), b.fields)
+	}
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return errors.New("nvidia-smi reported no devices")
+	}
+	b.gpus = rows
+
+	now := b.now()
+	if !b.procsAt.IsZero() && now.Sub(b.procsAt) < b.ProcessInterval {
+		// Keep the last listing
+		return nil
+	}
+	b.procsAt = now
+
+	// Process listing is optional; MIG and some vGPU setups refuse it
+	b.procs = make(map[string][]GPUProcess)
+	apps, err := b.query("--query-compute-apps="+strings.Join(nvidiaSMIAppFields, ","), nvidiaSMIAppFields)
+	if err != nil {
+		return nil
+	}
+	for _, app := range apps {
+		pid, err := strconv.ParseUint(app["pid"], 10, 32)
+		if err != nil {
+			continue
+		}
+		mib, _ := nvidiaSMIUint(app["used_memory"])
+		b.procs[app["gpu_uuid"]] = append(b.procs[app["gpu_uuid"]], GPUProcess{
+			PID:        uint32(pid),
+			Name:       app["process_name"],
+			Type:       "C",
+			MemoryUsed: mib * 1024 * 1024,
+		})
+	}
+	for _, procs := range b.procs {
+		sort.Slice(procs, func(i, j int) bool {
+			return procs[i].MemoryUsed > procs[j].MemoryUsed
+		})
+	}
+	return nil
+}
+
+// probeFields narrows the query to the fields listed by --help-query-gpu,
+// under their new names where the driver renamed them. When the list is
+// unavailable only the required fields are kept.
+func (b *NVIDIASMIBackend) probeFields() {
+	b.probed = true
+	known := make(map[string]bool)
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, b.Path, "--help-query-gpu").Output(); err == nil {
+		// Fields are listed quoted, with aliases on the same line:
+		// "clocks_event_reasons.active" or "clocks_throttle_reasons.active"
+		for _, line := range strings.Split(string(out), "\n") {
+			if !strings.HasPrefix(line, "\"") {
+				continue
+			}
+			for i, part := range strings.Split(line, "\"") {
+				if i%2 == 1 {
+					known[part] = true
+				}
+			}
+		}
+	}
+
+	b.fields, b.queries = nil, nil
+	for _, field := range nvidiaSMIGPUFields {
+		query := field
+		if renamed, ok := nvidiaSMIFieldRenames[field]; ok && known[renamed] {
+			query = renamed
+		}
+		if nvidiaSMIRequiredFields[field] || known[query] {
+			b.fields = append(b.fields, field)
+			b.queries = append(b.queries, query)
+		}
+	}
+}
+
+// query runs nvidia-smi with a CSV query and returns one field map per line.
+// Extra columns are folded into the last field, which may contain commas.
+func (b *NVIDIASMIBackend) query(arg string, fields []string) ([]map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, b.Path, arg, "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi %s: %w", arg, err)
+	}
+
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: parsing output: %w", err)
+	}
+
+	var rows []map[string]string
+	for _, rec := range records {
+		if len(rec) < len(fields) {
+			continue
+		}
+		last := len(fields) - 1
+		rec[last] = strings.Join(rec[last:], ", ")
+		row := make(map[string]string, len(fields))
+		for i, f := range fields {
+			row[f] = strings.TrimSpace(rec[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// nvidiaSMIValue returns a field, or ErrGPUNotSupported for the placeholders
+// nvidia-smi prints for missing data ("[N/A]", "[Not Supported]", ...).
+func nvidiaSMIValue(s string) (string, error) {
+	if s == "" || strings.HasPrefix(s, "[") || s == "N/A" {
+		return "", ErrGPUNotSupported
+	}
+	return s, nil
+}
+
+func nvidiaSMIUint(s string) (uint64, error) {
+	v, err := nvidiaSMIValue(s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// nvidiaSMIFloat parses fractional values such as power ("215.37").
+func nvidiaSMIFloat(s string) (float64, error) {
+	v, err := nvidiaSMIValue(s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+type nvidiaSMIDevice struct {
+	row   map[string]string
+	procs []GPUProcess
+}
+
+func (d nvidiaSMIDevice) Name() (string, error) {
+	return nvidiaSMIValue(d.row["name"])
+}
+
+func (d nvidiaSMIDevice) Utilization() (uint32, uint32, error) {
+	gpu, err := nvidiaSMIUint(d.row["utilization.gpu"])
+	if err != nil {
+		return 0, 0, err
+	}
+	mem, _ := nvidiaSMIUint(d.row["utilization.memory"])
+	return uint32(gpu), uint32(mem), nil
+}
+
+func (d nvidiaSMIDevice) MemoryInfo() (uint64, uint64, error) {
+	total, err := nvidiaSMIUint(d.row["memory.total"])
+	if err != nil {
+		return 0, 0, err
+	}
+	used, err := nvidiaSMIUint(d.row["memory.used"])
+	return total * 1024 * 1024, used * 1024 * 1024, err
+}
+
+func (d nvidiaSMIDevice) Temperature() (uint32, error) {
+	temp, err := nvidiaSMIUint(d.row["temperature.gpu"])
+	return uint32(temp), err
+}
+
+func (d nvidiaSMIDevice) FanSpeed() (uint32, error) {
+	fan, err := nvidiaSMIUint(d.row["fan.speed"])
+	return uint32(fan), err
+}
+
+func (d nvidiaSMIDevice) PowerUsage() (uint32, error) {
+	watts, err := nvidiaSMIFloat(d.row["power.draw"])
+	return uint32(watts * 1000), err
+}
+
+func (d nvidiaSMIDevice) PowerLimit() (uint32, error) {
+	watts, err := nvidiaSMIFloat(d.row["power.limit"])
+	return uint32(watts * 1000), err
+}
+
+func (d nvidiaSMIDevice) Clocks() (uint32, uint32, error) {
+	graphics, gerr := nvidiaSMIUint(d.row["clocks.gr"])
+	memory, merr := nvidiaSMIUint(d.row["clocks.mem"])
+	if gerr != nil && merr != nil {
+		return 0, 0, gerr
+	}
+	return uint32(graphics), uint32(memory), nil
+}
+
+func (d nvidiaSMIDevice) PerformanceState() (string, error) {
+	return nvidiaSMIValue(d.row["pstate"])
+}
+
+// ThrottleReasons decodes the hex bitmask nvidia-smi prints, which uses the
+// same bits as NVML.
+func (d nvidiaSMIDevice) ThrottleReasons() ([]string, error) {
+	v, err := nvidiaSMIValue(d.row["clocks_throttle_reasons.active"])
+	if err != nil {
+		return nil, err
+	}
+	mask, err := strconv.ParseUint(strings.TrimPrefix(v, "0x"), 16, 64)
+	if err != nil {
+		return nil, err
+	}
+	var reasons []string
+	for _, r := range nvmlThrottleReasons {
+		if mask&r.bit != 0 {
+			reasons = append(reasons, r.name)
+		}
+	}
+	return reasons, nil
+}
+
+func (d nvidiaSMIDevice) PCIeThroughput() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d nvidiaSMIDevice) CodecUtilization() (uint32, uint32, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d nvidiaSMIDevice) ECCErrors() (uint64, uint64, error) {
+	corrected, err := nvidiaSMIUint(d.row["ecc.errors.corrected.volatile.total"])
+	if err != nil {
+		return 0, 0, err
+	}
+	uncorrected, err := nvidiaSMIUint(d.row["ecc.errors.uncorrected.volatile.total"])
+	return corrected, uncorrected, err
+}
+
+func (d nvidiaSMIDevice) Processes() ([]GPUProcess, error) {
+	return append([]GPUProcess(nil), d.procs...), nil
+}
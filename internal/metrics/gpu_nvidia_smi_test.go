@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeNvidiaSMIStub creates an executable that prints canned CSV for the
+// --query-gpu and --query-compute-apps invocations.
+func writeNvidiaSMIStub(t *testing.T, gpus, apps string) string {
+	t.Helper()
+	return writeNvidiaSMIScript(t,
+		"--query-gpu=*) cat <<'EOF'\n"+gpus+"EOF\n;;\n"+
+			"--query-compute-apps=*) cat <<'EOF'\n"+apps+"EOF\n;;\n")
+}
+
+// writeNvidiaSMIScript creates an executable running the given shell case
+// arms on its first argument; anything else exits with an error.
+func writeNvidiaSMIScript(t *testing.T, cases string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "nvidia-smi")
+	script := "#!/bin/sh\n" +
+		"case \"$1\" in\n" + cases +
+		"*) exit 2 ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNVIDIASMIBackend(t *testing.T) {
+	gpus := "" +
+		"0, GPU-aaaa, 87, 40, 24564, 8192, 71, 55, 310.52, 450.00, 2520, 10501, P2, 0x0000000000000020, 0, 0, NVIDIA GeForce RTX 4090\n" +
+		"1, GPU-bbbb, 3, [N/A], 16384, 512, 40, [N/A], [N/A], [N/A], 210, 405, P8, [Not Supported], [N/A], [N/A], Tesla T4, PCIe\n"
+	apps := "" +
+		"GPU-aaaa, 1234, 4096, /usr/bin/python3\n" +
+		"GPU-aaaa, 1300, 6144, ./train\n" +
+		"GPU-bbbb, 4321, [N/A], /opt/app, with comma\n"
+
+	backend := &NVIDIASMIBackend{Path: writeNvidiaSMIStub(t, gpus, apps)}
+	provider := &RealProvider{GPUBackend: backend}
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if len(stats.GPUs) != 2 {
+		t.Fatalf("Expected 2 GPUs, got %d", len(stats.GPUs))
+	}
+
+	g := stats.GPUs[0]
+	if g.Name != "NVIDIA GeForce RTX 4090" || g.Utilization != 87 || g.MemoryUtil != 40 {
+		t.Errorf("Unexpected identity/utilization: %+v", g)
+	}
+	if g.MemoryTotal != 24564*1024*1024 || g.MemoryUsed != 8192*1024*1024 {
+		t.Errorf("Unexpected VRAM %d/%d", g.MemoryUsed, g.MemoryTotal)
+	}
+	if g.Temperature != 71 || g.FanSpeed != 55 || g.PowerUsage != 310520 || g.PowerLimit != 450000 {
+		t.Errorf("Unexpected thermals/power: %+v", g)
+	}
+	if g.GraphicsClock != 2520 || g.MemoryClock != 10501 || g.PState != "P2" {
+		t.Errorf("Unexpected clocks/pstate: %+v", g)
+	}
+	if len(g.Throttle) != 1 || g.Throttle[0] != ThrottleSWThermal {
+		t.Errorf("Expected sw-thermal throttling, got %v", g.Throttle)
+	}
+	if len(g.Processes) != 2 || g.Processes[0].PID != 1300 || g.Processes[0].MemoryUsed != 6144*1024*1024 {
+		t.Errorf("Expected processes sorted by VRAM, got %+v", g.Processes)
+	}
+
+	// Missing fields are left zero instead of failing the device
+	t4 := stats.GPUs[1]
+	if !t4.Available || t4.Name != "Tesla T4, PCIe" || t4.Utilization != 3 || t4.MemoryUtil != 0 {
+		t.Errorf("Unexpected T4 stats: %+v", t4)
+	}
+	if t4.FanSpeed != 0 || t4.PowerUsage != 0 || t4.Throttle != nil || t4.PState != "P8" {
+		t.Errorf("Expected N/A fields to be zero: %+v", t4)
+	}
+	if len(t4.Processes) != 1 || t4.Processes[0].Name != "/opt/app, with comma" || t4.Processes[0].MemoryUsed != 0 {
+		t.Errorf("Unexpected T4 processes: %+v", t4.Processes)
+	}
+}
+
+func TestNVIDIASMIBackendMissingBinary(t *testing.T) {
+	backend := &NVIDIASMIBackend{Path: filepath.Join(t.TempDir(), "nvidia-smi")}
+	if err := backend.Init(); err == nil {
+		t.Error("Init should fail when nvidia-smi cannot be run")
+	}
+}
+
+func TestNVIDIASMIBackendUnknownFields(t *testing.T) {
+	// Newer drivers reject clocks_throttle_reasons.* in favour of
+	// clocks_event_reasons.*, and fail the whole query over it
+	rejectOld := "--query-gpu=*clocks_throttle_reasons*) echo 'Field \"clocks_throttle_reasons.active\" is not a valid field to query.'; exit 2 ;;\n"
+
+	t.Run("renamed field", func(t *testing.T) {
+		help := "" +
+			"List of valid properties to query for the switch \"--query-gpu=\":\n\n" +
+			"\"index\"\nZero based index of the GPU.\n\n" +
+			"\"uuid\"\n\"utilization.gpu\"\n\"utilization.memory\"\n\"memory.total\"\n\"memory.used\"\n" +
+			"\"temperature.gpu\"\n\"fan.speed\"\n\"power.draw\"\n\"power.limit\"\n\"clocks.gr\" or \"clocks.current.graphics\"\n" +
+			"\"clocks.mem\"\n\"pstate\"\n\"clocks_event_reasons.active\"\nBitmask of active clock event reasons.\n" +
+			"\"ecc.errors.corrected.volatile.total\"\n\"ecc.errors.uncorrected.volatile.total\"\n\"name\" or \"gpu_name\"\n"
+		gpus := "0, GPU-aaaa, 87, 40, 24564, 8192, 71, 55, 310.52, 450.00, 2520, 10501, P2, 0x0000000000000020, 0, 0, NVIDIA RTX 6000 Ada\n"
+		backend := &NVIDIASMIBackend{Path: writeNvidiaSMIScript(t, rejectOld+
+			"--query-gpu=*clocks_event_reasons.active*) echo '"+gpus+"' ;;\n"+
+			"--help-query-gpu) cat <<'EOF'\n"+help+"EOF\n;;\n")}
+		if err := backend.Init(); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		dev, err := backend.Device(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reasons, err := dev.ThrottleReasons(); err != nil || len(reasons) != 1 || reasons[0] != ThrottleSWThermal {
+			t.Errorf("Expected sw-thermal from the renamed field, got %v (%v)", reasons, err)
+		}
+		if temp, _ := dev.Temperature(); temp != 71 {
+			t.Errorf("Expected 71°C, got %d", temp)
+		}
+	})
+
+	t.Run("no field list", func(t *testing.T) {
+		backend := &NVIDIASMIBackend{Path: writeNvidiaSMIScript(t, rejectOld+
+			"--query-gpu=index,uuid,utilization.gpu,memory.total,memory.used,name) echo '0, GPU-aaaa, 87, 24564, 8192, Tesla V100' ;;\n")}
+		if err := backend.Init(); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		dev, err := backend.Device(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name, _ := dev.Name(); name != "Tesla V100" {
+			t.Errorf("Expected the required fields, got name %q", name)
+		}
+		if util, _, err := dev.Utilization(); err != nil || util != 87 {
+			t.Errorf("Expected 87%% utilization, got %d (%v)", util, err)
+		}
+		if _, err := dev.Temperature(); err == nil {
+			t.Error("Expected dropped fields to be unsupported")
+		}
+	})
+}
+
+func TestNVIDIASMIBackendProcessInterval(t *testing.T) {
+	gpus := "0, GPU-aaaa, 87, 40, 24564, 8192, 71, 55, 310.52, 450.00, 2520, 10501, P2, 0x0, 0, 0, Tesla T4\n"
+	now := time.Unix(1000, 0)
+	backend := &NVIDIASMIBackend{
+		Path: writeNvidiaSMIStub(t, gpus, "GPU-aaaa, 1234, 4096, ./train\n"),
+		now:  func() time.Time { return now },
+	}
+	if err := backend.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	pids := func() []uint32 {
+		t.Helper()
+		if _, err := backend.DeviceCount(); err != nil {
+			t.Fatal(err)
+		}
+		dev, err := backend.Device(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		procs, _ := dev.Processes()
+		var pids []uint32
+		for _, p := range procs {
+			pids = append(pids, p.PID)
+		}
+		return pids
+	}
+
+	// Samples in between keep the last listing and only query the devices
+	backend.Path = writeNvidiaSMIScript(t, "--query-gpu=*) echo '"+gpus+"' ;;\n"+
+		"--query-compute-apps=*) echo 'GPU-aaaa, 5678, 2048, ./serve' ;;\n")
+	now = now.Add(time.Second)
+	if got := pids(); len(got) != 1 || got[0] != 1234 {
+		t.Errorf("Expected the listing from Init, got %v", got)
+	}
+
+	now = now.Add(4 * time.Second)
+	if got := pids(); len(got) != 1 || got[0] != 5678 {
+		t.Errorf("Expected a new listing after the interval, got %v", got)
+	}
+}
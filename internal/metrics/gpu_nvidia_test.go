@@ -0,0 +1,12 @@
+package metrics
+
+import "testing"
+
+func TestNVMLProcessMemory(t *testing.T) {
+	if got := nvmlProcessMemory(^uint64(0)); got != 0 {
+		t.Errorf("Expected NVML_VALUE_NOT_AVAILABLE to read as 0, got %d", got)
+	}
+	if got := nvmlProcessMemory(512 << 20); got != 512<<20 {
+		t.Errorf("Expected 512 MiB, got %d", got)
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"github.com/mindprince/gonvml"
+)
+
+// NVMLBackend implements GPUBackend on top of github.com/mindprince/gonvml.
+type NVMLBackend struct{}
+
+func (b *NVMLBackend) Name() string { return "nvml" }
+
+func (b *NVMLBackend) Init() error {
+	return gonvml.Initialize()
+}
+
+func (b *NVMLBackend) Shutdown() {
+	gonvml.Shutdown()
+}
+
+func (b *NVMLBackend) drmDrivers() []string { return []string{"nvidia-drm"} }
+
+func (b *NVMLBackend) DeviceCount() (int, error) {
+	count, err := gonvml.DeviceCount()
+	return int(count), err
+}
+
+func (b *NVMLBackend) Device(index int) (GPUDevice, error) {
+	dev, err := gonvml.DeviceHandleByIndex(uint(index))
+	if err != nil {
+		return nil, err
+	}
+	return nvmlDevice{dev: dev}, nil
+}
+
+type nvmlDevice struct {
+	dev gonvml.Device
+}
+
+func (d nvmlDevice) Name() (string, error) {
+	return d.dev.Name()
+}
+
+func (d nvmlDevice) Utilization() (uint32, uint32, error) {
+	util, memUtil, err := d.dev.UtilizationRates()
+	return uint32(util), uint32(memUtil), err
+}
+
+func (d nvmlDevice) MemoryInfo() (uint64, uint64, error) {
+	return d.dev.MemoryInfo()
+}
+
+func (d nvmlDevice) Temperature() (uint32, error) {
+	temp, err := d.dev.Temperature()
+	return uint32(temp), err
+}
+
+func (d nvmlDevice) FanSpeed() (uint32, error) {
+	fan, err := d.dev.FanSpeed()
+	return uint32(fan), err
+}
+
+func (d nvmlDevice) PowerUsage() (uint32, error) {
+	power, err := d.dev.PowerUsage()
+	return uint32(power), err
+}
+
+func (d nvmlDevice) CodecUtilization() (uint32, uint32, error) {
+	enc, _, err := d.dev.EncoderUtilization()
+	if err != nil {
+		return 0, 0, err
+	}
+	dec, _, err := d.dev.DecoderUtilization()
+	return uint32(enc), uint32(dec), err
+}
+
+// NOTE: mindprince/gonvml does not expose power limits, clocks, throttle
+// reasons, PCIe/ECC counters or process lists, so those queries report
+// ErrGPUNotSupported.
+
+func (d nvmlDevice) PowerLimit() (uint32, error) {
+	return 0, ErrGPUNotSupported
+}
+
+func (d nvmlDevice) Clocks() (uint32, uint32, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d nvmlDevice) PerformanceState() (string, error) {
+	return "", ErrGPUNotSupported
+}
+
+func (d nvmlDevice) ThrottleReasons() ([]string, error) {
+	return nil, ErrGPUNotSupported
+}
+
+func (d nvmlDevice) PCIeThroughput() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d nvmlDevice) ECCErrors() (uint64, uint64, error) {
+	return 0, 0, ErrGPUNotSupported
+}
+
+func (d nvmlDevice) Processes() ([]GPUProcess, error) {
+	return nil, ErrGPUNotSupported
+}
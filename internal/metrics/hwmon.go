@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hwmonKinds maps hwmon input prefixes to their kind and the divisor from
+// sysfs units (millidegrees, millivolts, milliamperes, microwatts) to the
+// reported ones.
+var hwmonKinds = []struct {
+	prefix  string
+	kind    SensorKind
+	divisor float64
+}{
+	{"temp", SensorTemp, 1000},
+	{"fan", SensorFan, 1},
+	{"in", SensorVoltage, 1000},
+	{"curr", SensorCurrent, 1000},
+	{"power", SensorPower, 1000000},
+}
+
+// hwmonInput is a discovered sensor: its static description and limits, and
+// the file its value is read from.
+type hwmonInput struct {
+	sensor  Sensor
+	path    string
+	divisor float64
+}
+
+// hwmonSensors reads every hwmon chip. Discovery walks the chips, and is
+// repeated only when chips come or go (a hotplugged drive, a loaded
+// driver); otherwise each sample only reads the value files.
+type hwmonSensors struct {
+	sysfsRoot string
+	dirs      []string // hwmon entries the inputs were discovered from
+	inputs    []hwmonInput
+}
+
+// discoverHwmonSensors finds every sensor input below sysfsRoot, ordered by
+// chip and then by kind and index.
+func discoverHwmonSensors(sysfsRoot string) *hwmonSensors {
+	s := &hwmonSensors{sysfsRoot: sysfsRoot}
+	s.discover(hwmonDirs(sysfsRoot))
+	return s
+}
+
+// hwmonDirs lists the hwmon entries in order.
+func hwmonDirs(sysfsRoot string) []string {
+	dirs, _ := filepath.Glob(filepath.Join(sysfsRoot, "class", "hwmon", "hwmon*"))
+	sort.Strings(dirs)
+	return dirs
+}
+
+func (s *hwmonSensors) discover(dirs []string) {
+	s.dirs, s.inputs = dirs, nil
+	for _, dir := range dirs {
+		chip := hwmonChipName(dir)
+		if chip == "" {
+			continue
+		}
+		for _, k := range hwmonKinds {
+			for _, name := range hwmonInputNames(dir, k.prefix) {
+				prefix := filepath.Join(dir, name)
+				file := prefix + "_input"
+				if _, err := os.Stat(file); err != nil && k.kind == SensorPower {
+					file = prefix + "_average" // Some chips only report a power average
+				}
+				label, err := readSysfsString(prefix + "_label")
+				if err != nil {
+					label = name
+				}
+				limit := func(attr string) float64 {
+					v, err := readSysfsInt(prefix + "_" + attr)
+					if err != nil {
+						return 0
+					}
+					return float64(v) / k.divisor
+				}
+				s.inputs = append(s.inputs, hwmonInput{
+					sensor: Sensor{
+						ID:    chip + "/" + name,
+						Chip:  chip,
+						Label: label,
+						Kind:  k.kind,
+						Min:   limit("min"),
+						Max:   limit("max"),
+						Crit:  limit("crit"),
+					},
+					path:    file,
+					divisor: k.divisor,
+				})
+			}
+		}
+	}
+}
+
+// read returns the current value of every sensor, discovering the chips
+// again when the hwmon entries changed. Inputs that cannot be read right
+// now (a drive in standby, a disabled channel) are left out.
+func (s *hwmonSensors) read() []Sensor {
+	// Chips that come back get a new hwmon number, so comparing the
+	// names catches one replaced between samples
+	if dirs := hwmonDirs(s.sysfsRoot); !slices.Equal(dirs, s.dirs) {
+		s.discover(dirs)
+	}
+	sensors := make([]Sensor, 0, len(s.inputs))
+	for _, in := range s.inputs {
+		v, err := readSysfsInt(in.path)
+		if err != nil {
+			continue
+		}
+		sensor := in.sensor
+		sensor.Value = float64(v) / in.divisor
+		sensors = append(sensors, sensor)
+	}
+	return sensors
+}
+
+// hwmonChipName names a chip after its driver and, when it has one, the
+// device it belongs to, since hwmon numbering changes between boots and
+// identical chips (two NVMe drives) share a driver name.
+func hwmonChipName(dir string) string {
+	name, err := readSysfsString(filepath.Join(dir, "name"))
+	if err != nil {
+		return ""
+	}
+	if device, err := filepath.EvalSymlinks(filepath.Join(dir, "device")); err == nil {
+		name += "-" + filepath.Base(device)
+	}
+	return name
+}
+
+// hwmonInputNames returns the inputs of one kind of a chip, such as "temp1"
+// and "temp2", in numeric order.
+func hwmonInputNames(dir, prefix string) []string {
+	files, _ := filepath.Glob(filepath.Join(dir, prefix+"*_input"))
+	if prefix == "power" {
+		avg, _ := filepath.Glob(filepath.Join(dir, prefix+"*_average"))
+		files = append(files, avg...)
+	}
+	indices := make(map[string]int)
+	for _, file := range files {
+		name, _, _ := strings.Cut(filepath.Base(file), "_")
+		// Skip names that only share the prefix, e.g. "intrusion0_input"
+		if n, err := strconv.Atoi(strings.TrimPrefix(name, prefix)); err == nil {
+			indices[name] = n
+		}
+	}
+	names := make([]string, 0, len(indices))
+	for name := range indices {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return indices[names[i]] < indices[names[j]] })
+	return names
+}
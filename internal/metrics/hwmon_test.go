@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHwmonSensors(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"class/hwmon/hwmon0/name":        "nvme\n",
+		"class/hwmon/hwmon0/temp1_input": "41850\n",
+		"class/hwmon/hwmon0/temp1_label": "Composite\n",
+		"class/hwmon/hwmon0/temp1_max":   "81850\n",
+		"class/hwmon/hwmon0/temp1_crit":  "84850\n",
+		"class/hwmon/hwmon0/temp1_min":   "-273150\n",
+		"class/hwmon/hwmon0/temp2_input": "39850\n",
+
+		"class/hwmon/hwmon1/name":           "nct6798\n",
+		"class/hwmon/hwmon1/fan2_input":     "1250\n",
+		"class/hwmon/hwmon1/fan2_min":       "300\n",
+		"class/hwmon/hwmon1/fan1_input":     "0\n",
+		"class/hwmon/hwmon1/in0_input":      "1104\n",
+		"class/hwmon/hwmon1/in0_label":      "Vcore\n",
+		"class/hwmon/hwmon1/in10_input":     "3312\n",
+		"class/hwmon/hwmon1/in2_input":      "3344\n",
+		"class/hwmon/hwmon1/in2_max":        "3632\n",
+		"class/hwmon/hwmon1/curr1_input":    "1500\n",
+		"class/hwmon/hwmon1/power1_average": "45500000\n",
+
+		// A chip without a name is not a hwmon device
+		"class/hwmon/hwmon2/temp1_input": "30000\n",
+		// Devices that only exist for the symlink
+		"devices/pci0000:00/0000:00:01.3/0000:01:00.0/nvme/nvme0/uevent": "",
+	})
+	if err := os.Symlink(filepath.Join(root, "devices/pci0000:00/0000:00:01.3/0000:01:00.0/nvme/nvme0"), filepath.Join(root, "class/hwmon/hwmon0/device")); err != nil {
+		t.Fatal(err)
+	}
+
+	hwmon := discoverHwmonSensors(root)
+	sensors := hwmon.read()
+	ids := make([]string, len(sensors))
+	for i, s := range sensors {
+		ids[i] = s.ID
+	}
+	want := []string{
+		"nvme-nvme0/temp1", "nvme-nvme0/temp2",
+		"nct6798/fan1", "nct6798/fan2", "nct6798/in0", "nct6798/in2", "nct6798/in10", "nct6798/curr1", "nct6798/power1",
+	}
+	if len(ids) != len(want) {
+		t.Fatalf("Expected sensors %v, got %v", want, ids)
+	}
+	// Chips in hwmon order, kinds in a fixed order, inputs numerically
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("Expected sensors %v, got %v", want, ids)
+		}
+	}
+
+	composite := sensors[0]
+	if composite.Label != "Composite" || composite.Kind != SensorTemp || composite.Chip != "nvme-nvme0" {
+		t.Errorf("Unexpected sensor %+v", composite)
+	}
+	if composite.Value != 41.85 || composite.Max != 81.85 || composite.Crit != 84.85 || composite.Min != -273.15 {
+		t.Errorf("Unexpected temperature or limits %+v", composite)
+	}
+	if s := sensors[1]; s.Label != "temp2" || s.Crit != 0 {
+		t.Errorf("Expected an unlabelled input named after itself without limits, got %+v", s)
+	}
+	if s := sensors[3]; s.Kind != SensorFan || s.Value != 1250 || s.Min != 300 {
+		t.Errorf("Unexpected fan %+v", s)
+	}
+	if s := sensors[4]; s.Label != "Vcore" || s.Value != 1.104 {
+		t.Errorf("Unexpected voltage %+v", s)
+	}
+	if s := sensors[5]; s.Max != 3.632 {
+		t.Errorf("Unexpected voltage limit %+v", s)
+	}
+	if s := sensors[7]; s.Kind != SensorCurrent || s.Value != 1.5 {
+		t.Errorf("Unexpected current %+v", s)
+	}
+	if s := sensors[8]; s.Kind != SensorPower || s.Value != 45.5 {
+		t.Errorf("Unexpected power %+v", s)
+	}
+
+	// A drive in standby stops answering; its sensor is left out
+	if err := os.Remove(filepath.Join(root, "class/hwmon/hwmon0/temp2_input")); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(hwmon.read()); got != len(want)-1 {
+		t.Errorf("Expected %d sensors after one went away, got %d", len(want)-1, got)
+	}
+}
+
+func TestHwmonSensorsHotplug(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"class/hwmon/hwmon0/name":        "k10temp\n",
+		"class/hwmon/hwmon0/temp1_input": "52000\n",
+	})
+	hwmon := discoverHwmonSensors(root)
+	if sensors := hwmon.read(); len(sensors) != 1 {
+		t.Fatalf("Expected the CPU sensor, got %+v", sensors)
+	}
+
+	// A drive plugged in after startup shows up on the next read
+	writeFixture(t, root, map[string]string{
+		"class/hwmon/hwmon1/name":        "drivetemp\n",
+		"class/hwmon/hwmon1/temp1_input": "35000\n",
+	})
+	if sensors := hwmon.read(); len(sensors) != 2 || sensors[1].ID != "drivetemp/temp1" || sensors[1].Value != 35 {
+		t.Errorf("Expected the new drive, got %+v", sensors)
+	}
+
+	// Replaced by another chip under a new number
+	if err := os.RemoveAll(filepath.Join(root, "class/hwmon/hwmon1")); err != nil {
+		t.Fatal(err)
+	}
+	writeFixture(t, root, map[string]string{
+		"class/hwmon/hwmon2/name":        "nvme\n",
+		"class/hwmon/hwmon2/temp1_input": "40000\n",
+	})
+	if sensors := hwmon.read(); len(sensors) != 2 || sensors[1].ID != "nvme/temp1" {
+		t.Errorf("Expected the drive replaced, got %+v", sensors)
+	}
+}
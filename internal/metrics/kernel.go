@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kernelCounters holds the cumulative /proc/stat and /proc/vmstat counters
+// used for rates.
+type kernelCounters struct {
+	ctxt, intr, softirq, processes uint64
+	pgfault, pgmajfault            uint64
+	pswpin, pswpout                uint64 // Pages
+	pgpgin, pgpgout                uint64 // KiB, despite the name
+}
+
+// kernelTracker derives scheduler and paging rates from consecutive samples
+// of /proc/stat and /proc/vmstat.
+type kernelTracker struct {
+	procRoot string
+	pageSize uint64
+
+	last     kernelCounters
+	lastTime time.Time
+}
+
+func newKernelTracker(procRoot string) *kernelTracker {
+	return &kernelTracker{procRoot: procRoot, pageSize: uint64(os.Getpagesize())}
+}
+
+// sample returns the current activity. Rates are zero on the first sample,
+// and for a counter that went backwards.
+func (t *kernelTracker) sample(now time.Time) (KernelStats, error) {
+	var cur kernelCounters
+	var stats KernelStats
+	err := scanKeyValues(filepath.Join(t.procRoot, "stat"), func(key string, v uint64) {
+		switch key {
+		case "ctxt":
+			cur.ctxt = v
+		case "intr":
+			cur.intr = v
+		case "softirq":
+			cur.softirq = v
+		case "processes":
+			cur.processes = v
+		case "procs_running":
+			stats.ProcsRunning = v
+		case "procs_blocked":
+			stats.ProcsBlocked = v
+		}
+	})
+	if err != nil {
+		return KernelStats{}, err
+	}
+	// vmstat is optional: some container runtimes hide it
+	scanKeyValues(filepath.Join(t.procRoot, "vmstat"), func(key string, v uint64) {
+		switch key {
+		case "pgfault":
+			cur.pgfault = v
+		case "pgmajfault":
+			cur.pgmajfault = v
+		case "pswpin":
+			cur.pswpin = v
+		case "pswpout":
+			cur.pswpout = v
+		case "pgpgin":
+			cur.pgpgin = v
+		case "pgpgout":
+			cur.pgpgout = v
+		}
+	})
+
+	if secs := now.Sub(t.lastTime).Seconds(); !t.lastTime.IsZero() && secs > 0 {
+		prev := t.last
+		rate := func(prev, cur, unit uint64) float64 {
+			return float64(counterDelta(prev, cur)*unit) / secs
+		}
+		stats.ContextSwitches = rate(prev.ctxt, cur.ctxt, 1)
+		stats.Interrupts = rate(prev.intr, cur.intr, 1)
+		stats.SoftIRQs = rate(prev.softirq, cur.softirq, 1)
+		stats.Forks = rate(prev.processes, cur.processes, 1)
+		// pgfault counts major faults too
+		stats.MajorFaults = rate(prev.pgmajfault, cur.pgmajfault, 1)
+		stats.MinorFaults = max(rate(prev.pgfault, cur.pgfault, 1)-stats.MajorFaults, 0)
+		stats.SwapIn = rate(prev.pswpin, cur.pswpin, t.pageSize)
+		stats.SwapOut = rate(prev.pswpout, cur.pswpout, t.pageSize)
+		stats.PageIn = rate(prev.pgpgin, cur.pgpgin, 1024)
+		stats.PageOut = rate(prev.pgpgout, cur.pgpgout, 1024)
+	}
+	t.last = cur
+	t.lastTime = now
+	return stats, nil
+}
+
+// scanKeyValues calls fn with the first number of every "key value ..."
+// line of a file. The per-IRQ counts following the total on the "intr" and
+// "softirq" lines of /proc/stat are ignored.
+func scanKeyValues(path string, fn func(key string, v uint64)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// The intr line lists a counter per IRQ and can exceed the default
+	// 64 KiB token size on large machines
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		value, _, _ := strings.Cut(strings.TrimLeft(rest, " "), " ")
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		fn(key, v)
+	}
+	return scanner.Err()
+}
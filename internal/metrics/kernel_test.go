@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKernelTracker(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"stat": "cpu  100 0 50 1000 10 0 5 0 0 0\n" +
+			"cpu0 100 0 50 1000 10 0 5 0 0 0\n" +
+			"intr 50000 10 0 20 0\n" +
+			"ctxt 100000\n" +
+			"btime 1700000000\n" +
+			"processes 2000\n" +
+			"procs_running 3\n" +
+			"procs_blocked 1\n" +
+			"softirq 8000 0 100 0\n",
+		"vmstat": "nr_free_pages 100000\n" +
+			"pgpgin 4000\n" +
+			"pgpgout 8000\n" +
+			"pswpin 0\n" +
+			"pswpout 10\n" +
+			"pgfault 90000\n" +
+			"pgmajfault 500\n",
+	})
+
+	tracker := newKernelTracker(root)
+	tracker.pageSize = 4096
+	start := time.Unix(1000, 0)
+	stats, err := tracker.sample(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ContextSwitches != 0 || stats.Forks != 0 {
+		t.Errorf("Expected no rates on the first sample, got %+v", stats)
+	}
+	if stats.ProcsRunning != 3 || stats.ProcsBlocked != 1 {
+		t.Errorf("Expected 3 running and 1 blocked, got %d/%d", stats.ProcsRunning, stats.ProcsBlocked)
+	}
+
+	// Two seconds later, with a fork storm and some swapping
+	writeFixture(t, root, map[string]string{
+		"stat": "intr 54000 10 0 20 0\n" +
+			"ctxt 120000\n" +
+			"processes 4000\n" +
+			"procs_running 40\n" +
+			"procs_blocked 0\n" +
+			"softirq 9000 0 100 0\n",
+		"vmstat": "pgpgin 6048\n" +
+			"pgpgout 8000\n" +
+			"pswpin 512\n" +
+			"pswpout 10\n" +
+			"pgfault 92000\n" +
+			"pgmajfault 600\n",
+	})
+	stats, err = tracker.sample(start.Add(2 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ContextSwitches != 10000 || stats.Interrupts != 2000 || stats.SoftIRQs != 500 || stats.Forks != 1000 {
+		t.Errorf("Unexpected scheduler rates %+v", stats)
+	}
+	if stats.ProcsRunning != 40 {
+		t.Errorf("Expected 40 running, got %d", stats.ProcsRunning)
+	}
+	if stats.MajorFaults != 50 || stats.MinorFaults != 950 {
+		t.Errorf("Expected 950 minor and 50 major faults/s, got %.0f/%.0f", stats.MinorFaults, stats.MajorFaults)
+	}
+	if stats.SwapIn != 1<<20 || stats.SwapOut != 0 || stats.PageIn != 1<<20 || stats.PageOut != 0 {
+		t.Errorf("Unexpected paging rates %+v", stats)
+	}
+}
+
+func TestKernelTrackerWithoutVMStat(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{"stat": "ctxt 100\nprocs_running 2\n"})
+
+	stats, err := newKernelTracker(root).sample(time.Unix(1000, 0))
+	if err != nil || stats.ProcsRunning != 2 {
+		t.Errorf("Expected /proc/stat alone to be enough, got %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readMemInfo parses /proc/meminfo, whose lines look like:
+//
+//	MemTotal:       32697864 kB
+//	HugePages_Total:       0
+//
+// Sizes are reported in kB; the HugePages_* lines are page counts.
+func readMemInfo(procRoot string) (MemoryStats, error) {
+	f, err := os.Open(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return MemoryStats{}, err
+	}
+	defer f.Close()
+
+	info := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		info[key] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return MemoryStats{}, err
+	}
+
+	m := MemoryStats{
+		Total:             info["MemTotal"],
+		Free:              info["MemFree"],
+		Available:         info["MemAvailable"],
+		Buffers:           info["Buffers"],
+		Cached:            info["Cached"],
+		Shared:            info["Shmem"],
+		Dirty:             info["Dirty"],
+		Writeback:         info["Writeback"],
+		SlabReclaimable:   info["SReclaimable"],
+		SlabUnreclaimable: info["SUnreclaim"],
+		HugePagesTotal:    info["HugePages_Total"] * info["Hugepagesize"],
+		HugePagesFree:     info["HugePages_Free"] * info["Hugepagesize"],
+		AnonHugePages:     info["AnonHugePages"],
+		ZswapPool:         info["Zswap"],
+		ZswapStored:       info["Zswapped"],
+		SwapTotal:         info["SwapTotal"],
+	}
+	if _, ok := info["MemAvailable"]; !ok {
+		// Kernels before 3.14
+		m.Available = m.Free + m.Buffers + m.Cached
+	}
+
+	m.Used = m.Total - m.Free
+	if cache := m.Buffers + m.Cached + m.SlabReclaimable; cache < m.Used {
+		m.Used -= cache
+	}
+	if m.Total > 0 {
+		m.UsedPercent = float64(m.Used) / float64(m.Total) * 100
+	}
+	if m.SwapTotal > 0 {
+		m.SwapUsed = m.SwapTotal - min(info["SwapFree"], m.SwapTotal)
+		m.SwapPercent = float64(m.SwapUsed) / float64(m.SwapTotal) * 100
+	}
+	return m, nil
+}
+
+// readZram sums the original and in-memory sizes over all zram devices from
+// their mm_stat, which starts with orig_data_size, compr_data_size and
+// mem_used_total in bytes.
+func readZram(sysfsRoot string) (stored, used uint64) {
+	paths, _ := filepath.Glob(filepath.Join(sysfsRoot, "block", "zram*", "mm_stat"))
+	for _, path := range paths {
+		s, err := readSysfsString(path)
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(s)
+		if len(fields) < 3 {
+			continue
+		}
+		orig, _ := strconv.ParseUint(fields[0], 10, 64)
+		total, _ := strconv.ParseUint(fields[2], 10, 64)
+		stored += orig
+		used += total
+	}
+	return stored, used
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import "testing"
+
+func TestReadMemInfo(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"meminfo": "" +
+			"MemTotal:        16000000 kB\n" +
+			"MemFree:          2000000 kB\n" +
+			"MemAvailable:     9000000 kB\n" +
+			"Buffers:           500000 kB\n" +
+			"Cached:           6000000 kB\n" +
+			"SwapCached:         10000 kB\n" +
+			"SwapTotal:        4000000 kB\n" +
+			"SwapFree:         3000000 kB\n" +
+			"Zswap:             100000 kB\n" +
+			"Zswapped:          400000 kB\n" +
+			"Dirty:               2048 kB\n" +
+			"Writeback:              0 kB\n" +
+			"AnonHugePages:    1048576 kB\n" +
+			"Shmem:             700000 kB\n" +
+			"SReclaimable:      500000 kB\n" +
+			"SUnreclaim:        200000 kB\n" +
+			"HugePages_Total:      512\n" +
+			"HugePages_Free:       256\n" +
+			"Hugepagesize:        2048 kB\n",
+	})
+
+	m, err := readMemInfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const kb = 1024
+	// 16000000 - 2000000 free - 500000 buffers - 6000000 cached - 500000 slab
+	if m.Used != 7000000*kb || m.UsedPercent != 43.75 {
+		t.Errorf("Expected 7000000 kB (43.75%%) used, got %d (%.2f%%)", m.Used/kb, m.UsedPercent)
+	}
+	if m.Available != 9000000*kb || m.Cached != 6000000*kb || m.Shared != 700000*kb || m.Dirty != 2048*kb {
+		t.Errorf("Unexpected breakdown %+v", m)
+	}
+	if m.SlabReclaimable != 500000*kb || m.SlabUnreclaimable != 200000*kb || m.AnonHugePages != 1<<30 {
+		t.Errorf("Unexpected slab or THP %+v", m)
+	}
+	if m.HugePagesTotal != 1<<30 || m.HugePagesFree != 512<<20 {
+		t.Errorf("Expected 1 GiB of hugepages, half free, got %d/%d", m.HugePagesTotal, m.HugePagesFree)
+	}
+	if m.ZswapStored != 400000*kb || m.ZswapPool != 100000*kb {
+		t.Errorf("Unexpected zswap %d -> %d", m.ZswapStored, m.ZswapPool)
+	}
+	if m.SwapUsed != 1000000*kb || m.SwapPercent != 25 {
+		t.Errorf("Expected 25%% swap used, got %d (%.1f%%)", m.SwapUsed, m.SwapPercent)
+	}
+}
+
+func TestReadZram(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"block/zram0/mm_stat": "4000000 1000000 1200000 0 1300000 10 0 0 0\n",
+		"block/zram1/mm_stat": "2000000 500000 600000 0 600000 0 0 0 0\n",
+		"block/sda/stat":      "0 0 0 0\n",
+	})
+
+	stored, used := readZram(root)
+	if stored != 6000000 || used != 1800000 {
+		t.Errorf("Expected 6000000 stored in 1800000, got %d in %d", stored, used)
+	}
+}
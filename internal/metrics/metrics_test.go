@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"testing"
 )
 
@@ -11,7 +12,7 @@ func TestMockProvider(t *testing.T) {
 		t.Fatalf("Init failed: %v", err)
 	}
 
-	stats, err := provider.GetStats()
+	stats, err := provider.GetStats(context.Background())
 	if err != nil {
 		t.Fatalf("GetStats failed: %v", err)
 	}
@@ -28,7 +29,66 @@ func TestMockProvider(t *testing.T) {
 		t.Error("No processes returned in mock mode")
 	}
 
-	if !stats.GPU.Available {
+	if len(stats.GPUs) != 1 || !stats.GPUs[0].Available {
 		t.Error("GPU should be available in mock mode")
 	}
 }
+
+func TestMockProviderMultiGPU(t *testing.T) {
+	provider := &MockProvider{GPUCount: 4}
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+
+	if len(stats.GPUs) != 4 {
+		t.Fatalf("Expected 4 GPUs, got %d", len(stats.GPUs))
+	}
+	for i, gpu := range stats.GPUs {
+		if gpu.Index != i {
+			t.Errorf("GPU %d has index %d", i, gpu.Index)
+		}
+		if len(gpu.HistoricalUtil) == 0 {
+			t.Errorf("GPU %d has no history", i)
+		}
+	}
+}
+
+func TestAggregateGPUs(t *testing.T) {
+	gpus := []GPUStats{
+		{Available: true, Index: 0, Utilization: 20, MemoryTotal: 100, MemoryUsed: 50, Temperature: 60, PowerUsage: 1000, HistoricalUtil: []float64{10, 20}},
+		{Available: true, Index: 1, Utilization: 60, MemoryTotal: 100, MemoryUsed: 25, Temperature: 80, PowerUsage: 3000, HistoricalUtil: []float64{30, 40, 60}},
+		{Available: false, Index: 2, Utilization: 100},
+	}
+
+	agg := AggregateGPUs(gpus)
+	if !agg.Available {
+		t.Fatal("Aggregate should be available")
+	}
+	if agg.Utilization != 40 {
+		t.Errorf("Expected utilization 40, got %d", agg.Utilization)
+	}
+	if agg.MemoryUsed != 75 || agg.MemoryTotal != 200 {
+		t.Errorf("Unexpected memory %d/%d", agg.MemoryUsed, agg.MemoryTotal)
+	}
+	if agg.Temperature != 80 {
+		t.Errorf("Expected hottest temperature 80, got %d", agg.Temperature)
+	}
+	if agg.PowerUsage != 4000 {
+		t.Errorf("Expected summed power 4000, got %d", agg.PowerUsage)
+	}
+	want := []float64{15, 25, 40}
+	if len(agg.HistoricalUtil) != len(want) {
+		t.Fatalf("Expected history %v, got %v", want, agg.HistoricalUtil)
+	}
+	for i := range want {
+		if agg.HistoricalUtil[i] != want[i] {
+			t.Errorf("Expected history %v, got %v", want, agg.HistoricalUtil)
+			break
+		}
+	}
+}
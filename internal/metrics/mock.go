@@ -1,73 +1,337 @@
 package metrics
 
 import (
+	"context"
 	"math/rand"
+	"slices"
 	"time"
 )
 
 type MockProvider struct {
+	GPUCount  int // Number of simulated GPUs (defaults to 1)
 	lastStats SystemStats
+	energy    *energyAccountant
 }
 
 func (m *MockProvider) Init() error {
+	if m.GPUCount <= 0 {
+		m.GPUCount = 1
+	}
+	m.energy = newEnergyAccountant()
+
+	gpus := make([]GPUStats, m.GPUCount)
+	for i := range gpus {
+		name := "NVIDIA GeForce RTX 4090"
+		if m.GPUCount > 1 {
+			name = "NVIDIA H100 80GB HBM3"
+		}
+		memTotal := uint64(24576 * 1024 * 1024)
+		if m.GPUCount > 1 {
+			memTotal = 81559 * 1024 * 1024
+		}
+		gpus[i] = GPUStats{
+			Available:      true,
+			Index:          i,
+			Name:           name,
+			MemoryTotal:    memTotal,
+			HistoricalUtil: make([]float64, 60),
+			Processes:      make([]GPUProcess, 0),
+		}
+	}
+
 	m.lastStats = SystemStats{
 		Timestamp: time.Now(),
 		Uptime:    3600,
 		CPU: CPUStats{
 			PerCoreUsage: make([]float64, 8), // Simulate 8 cores
 			PerCoreTemp:  make([]float64, 8),
+			PerCoreFreq:  make([]CoreFreq, 8),
+			PerCoreTimes: make([]CPUTimes, 8),
+			// Hybrid part: 4 P-cores followed by 4 E-cores
+			PerCoreType: []CoreType{
+				CoreTypePerformance, CoreTypePerformance, CoreTypePerformance, CoreTypePerformance,
+				CoreTypeEfficiency, CoreTypeEfficiency, CoreTypeEfficiency, CoreTypeEfficiency,
+			},
 		},
-		GPU: GPUStats{
-			Available:      true,
-			Name:           "NVIDIA GeForce RTX 4090",
-			MemoryTotal:    24576 * 1024 * 1024,
-			HistoricalUtil: make([]float64, 60),
-			Processes:      make([]GPUProcess, 0),
+		Disk: DiskStats{
+			Devices: []DiskDevice{
+				{Name: "dm-0", Label: "luks-root", Kind: DiskKindVirtual},
+				{Name: "loop0", Kind: DiskKindVirtual},
+				{Name: "nvme0n1", Kind: DiskKindDisk, MaxSpeed: 3938461538},
+				{Name: "nvme0n1p1", Kind: DiskKindPartition},
+				{Name: "nvme0n1p2", Kind: DiskKindPartition},
+				{Name: "sda", Kind: DiskKindDisk},
+			},
+		},
+		Net: NetStats{
+			Interfaces: []NetInterface{
+				{Name: "docker0", OperState: "up", Virtual: true},
+				{Name: "enp5s0", OperState: "up", Speed: 2500, Aggregated: true},
+				{Name: "lo", OperState: "unknown", Loopback: true, Virtual: true},
+				{Name: "wlp4s0", OperState: "down", Aggregated: true},
+			},
 		},
+		Filesystems: []FilesystemStats{
+			{Mount: "/", Device: "/dev/mapper/luks-root", FSType: "ext4", Total: 500 << 30, Used: 410 << 30, Inodes: 32 << 20},
+			{Mount: "/boot/efi", Device: "/dev/nvme0n1p1", FSType: "vfat", Total: 512 << 20, Used: 64 << 20},
+			{Mount: "/mnt/media", Device: "/dev/sda1", FSType: "btrfs", Total: 4 << 40, Used: 3500 << 30},
+			{Mount: "/mnt/iso", Device: "/dev/loop0", FSType: "iso9660", ReadOnly: true, Total: 4 << 30, Used: 4 << 30},
+		},
+		GPUs:      gpus,
 		Processes: make([]ProcessInfo, 50),
 	}
 	return nil
 }
 
-func (m *MockProvider) GetStats() (*SystemStats, error) {
+func (m *MockProvider) GetStats(ctx context.Context) (*SystemStats, error) {
 	// Simulate metric updates with some randomness
 	now := time.Now()
 	m.lastStats.Timestamp = now
 	m.lastStats.Uptime += 1
 
 	// CPU
-	m.lastStats.CPU.GlobalUsagePercent = 20 + rand.Float64()*10
+	m.lastStats.CPU.PackageTemp = 0
 	for i := range m.lastStats.CPU.PerCoreUsage {
-		m.lastStats.CPU.PerCoreUsage[i] = 10 + rand.Float64()*30
+		// Split the simulated load across modes, with a little iowait/steal
+		usage := 10 + rand.Float64()*30
+		times := CPUTimes{
+			User:    usage * 0.7,
+			System:  usage * 0.2,
+			SoftIRQ: usage * 0.05,
+			Steal:   usage * 0.05,
+			IOWait:  rand.Float64() * 3,
+		}
+		times.Idle = 100 - times.Busy() - times.IOWait
+		m.lastStats.CPU.PerCoreTimes[i] = times
+		m.lastStats.CPU.PerCoreUsage[i] = times.Busy()
 		m.lastStats.CPU.PerCoreTemp[i] = 40 + rand.Float64()*10
+		if m.lastStats.CPU.PerCoreTemp[i] > m.lastStats.CPU.PackageTemp {
+			m.lastStats.CPU.PackageTemp = m.lastStats.CPU.PerCoreTemp[i]
+		}
+		freq := CoreFreq{Min: 800, Max: 5400, HWMax: 5400, Governor: "powersave", EPP: "balance_performance"}
+		if m.lastStats.CPU.PerCoreType[i] == CoreTypeEfficiency {
+			freq.Max, freq.HWMax = 3900, 3900
+		}
+		freq.Cur = 1200 + uint32(rand.Intn(int(freq.Max-1200)))
+		m.lastStats.CPU.PerCoreFreq[i] = freq
 	}
+	m.lastStats.CPU.Times = averageCPUTimes(m.lastStats.CPU.PerCoreTimes)
+	m.lastStats.CPU.GlobalUsagePercent = m.lastStats.CPU.Times.Busy()
 	m.lastStats.CPU.LoadAvg = [3]float64{1.5, 1.2, 0.8}
+	core := 10 + m.lastStats.CPU.GlobalUsagePercent*0.8
+	m.lastStats.CPU.Power = CPUPower{
+		Available: true,
+		Package:   core + 8,
+		Core:      core,
+		Uncore:    2,
+		DRAM:      3 + rand.Float64(),
+	}
 
-	// Memory
-	m.lastStats.Memory.Total = 32 * 1024 * 1024 * 1024
-	m.lastStats.Memory.Used = 12 * 1024 * 1024 * 1024
-	m.lastStats.Memory.Free = m.lastStats.Memory.Total - m.lastStats.Memory.Used
+	// Memory: mostly page cache, like a desktop that has been up a while
+	const gib = 1024 * 1024 * 1024
+	m.lastStats.Memory = MemoryStats{
+		Total:             32 * gib,
+		Used:              12 * gib,
+		Free:              4*gib + gib/2,
+		Available:         18 * gib,
+		Buffers:           gib / 2,
+		Cached:            14 * gib,
+		Shared:            gib,
+		Dirty:             uint64(rand.Intn(64)) << 20,
+		SlabReclaimable:   gib,
+		SlabUnreclaimable: gib / 4,
+		AnonHugePages:     2 * gib,
+		SwapTotal:         8 * gib,
+		SwapUsed:          gib,
+		SwapPercent:       12.5,
+		ZramStored:        3 * gib,
+		ZramUsed:          gib,
+	}
 	m.lastStats.Memory.UsedPercent = float64(m.lastStats.Memory.Used) / float64(m.lastStats.Memory.Total) * 100
-	m.lastStats.Memory.SwapTotal = 8 * 1024 * 1024 * 1024
-	m.lastStats.Memory.SwapUsed = 1 * 1024 * 1024 * 1024
-	m.lastStats.Memory.SwapPercent = 12.5
-
-	// GPU
-	m.lastStats.GPU.Utilization = uint32(50 + rand.Intn(30))
-	m.lastStats.GPU.Temperature = uint32(60 + rand.Intn(10))
-	m.lastStats.GPU.MemoryUsed = uint64(8 * 1024 * 1024 * 1024)
-	m.lastStats.GPU.FanSpeed = uint32(40 + rand.Intn(10))
-	m.lastStats.GPU.GraphicsClock = 2500
-	m.lastStats.GPU.MemoryClock = 10500
-	m.lastStats.GPU.PowerUsage = 150000 // mW
-	m.lastStats.GPU.PowerLimit = 450000 // mW
-	// Compute VRAM utilization percentage
-	if m.lastStats.GPU.MemoryTotal > 0 {
-		m.lastStats.GPU.MemoryUtil = uint32(float64(m.lastStats.GPU.MemoryUsed) / float64(m.lastStats.GPU.MemoryTotal) * 100.0)
-	}
-
-	// Historical Graph
-	m.lastStats.GPU.HistoricalUtil = append(m.lastStats.GPU.HistoricalUtil[1:], float64(m.lastStats.GPU.Utilization))
+
+	// Disks: a busy NVMe root disk (through LUKS) and a mostly idle SATA disk
+	nvmeRead := uint64(rand.Intn(400)) * 1024 * 1024
+	nvmeWrite := uint64(rand.Intn(150)) * 1024 * 1024
+	sdaRead := uint64(rand.Intn(2)) * 1024 * 1024
+	activity := map[string][2]uint64{
+		"dm-0":      {nvmeRead, nvmeWrite},
+		"nvme0n1":   {nvmeRead, nvmeWrite},
+		"nvme0n1p2": {nvmeRead, nvmeWrite},
+		"sda":       {sdaRead, 0},
+	}
+	m.lastStats.Disk.ReadBytes, m.lastStats.Disk.WriteBytes = 0, 0
+	m.lastStats.Disk.ReadSpeed, m.lastStats.Disk.WriteSpeed = 0, 0
+	for i := range m.lastStats.Disk.Devices {
+		d := &m.lastStats.Disk.Devices[i]
+		io := activity[d.Name]
+		d.ReadSpeed, d.WriteSpeed = io[0], io[1]
+		d.ReadBytes += d.ReadSpeed
+		d.WriteBytes += d.WriteSpeed
+		d.ReadIOPS = float64(d.ReadSpeed / (64 * 1024))
+		d.WriteIOPS = float64(d.WriteSpeed / (64 * 1024))
+		d.Await = 0
+		d.InFlight = 0
+		d.Util = 0
+		if d.ReadSpeed+d.WriteSpeed > 0 {
+			d.Await = 0.1 + rand.Float64()
+			d.InFlight = uint64(rand.Intn(8))
+			d.Util = float64(d.ReadSpeed+d.WriteSpeed) / (600 * 1024 * 1024) * 100
+		}
+		d.History = append(d.History, float64(d.ReadSpeed+d.WriteSpeed))
+		if len(d.History) > 60 {
+			d.History = d.History[1:]
+		}
+		if d.Kind == DiskKindDisk {
+			m.lastStats.Disk.ReadBytes += d.ReadBytes
+			m.lastStats.Disk.WriteBytes += d.WriteBytes
+			m.lastStats.Disk.ReadSpeed += d.ReadSpeed
+			m.lastStats.Disk.WriteSpeed += d.WriteSpeed
+		}
+	}
+
+	// Network: traffic on the wired link, a little container and loopback chatter
+	netActivity := map[string][2]uint64{
+		"docker0": {uint64(rand.Intn(64)) * 1024, uint64(rand.Intn(16)) * 1024},
+		"enp5s0":  {uint64(rand.Intn(120)) * 1024 * 1024, uint64(rand.Intn(10)) * 1024 * 1024},
+		"lo":      {uint64(rand.Intn(256)) * 1024, 0},
+	}
+	m.lastStats.Net.BytesRecv, m.lastStats.Net.BytesSent = 0, 0
+	m.lastStats.Net.DownloadSpeed, m.lastStats.Net.UploadSpeed = 0, 0
+	for i := range m.lastStats.Net.Interfaces {
+		iface := &m.lastStats.Net.Interfaces[i]
+		io := netActivity[iface.Name]
+		if iface.Loopback {
+			io[1] = io[0]
+		}
+		iface.DownloadSpeed, iface.UploadSpeed = io[0], io[1]
+		iface.BytesRecv += iface.DownloadSpeed
+		iface.BytesSent += iface.UploadSpeed
+		iface.PacketsRecvRate = float64(iface.DownloadSpeed / 1400)
+		iface.PacketsSentRate = float64(iface.UploadSpeed / 1400)
+		iface.PacketsRecv += uint64(iface.PacketsRecvRate)
+		iface.PacketsSent += uint64(iface.PacketsSentRate)
+		if iface.Name == "enp5s0" && rand.Intn(20) == 0 {
+			iface.DropsIn++
+		}
+		iface.History = append(iface.History, float64(iface.DownloadSpeed+iface.UploadSpeed))
+		if len(iface.History) > 60 {
+			iface.History = iface.History[1:]
+		}
+		if iface.Aggregated {
+			m.lastStats.Net.BytesRecv += iface.BytesRecv
+			m.lastStats.Net.BytesSent += iface.BytesSent
+			m.lastStats.Net.DownloadSpeed += iface.DownloadSpeed
+			m.lastStats.Net.UploadSpeed += iface.UploadSpeed
+		}
+	}
+
+	// Filesystems: the root filesystem slowly fills up
+	for i := range m.lastStats.Filesystems {
+		fs := &m.lastStats.Filesystems[i]
+		if fs.Mount == "/" {
+			fs.Used += uint64(rand.Intn(64)) << 20
+			fs.GrowthRate = 32 << 20
+		}
+		fs.Free = fs.Total - fs.Used
+		fs.UsedPercent = float64(fs.Used) / float64(fs.Total) * 100
+		fs.InodesUsed = fs.Inodes / 4
+		if fs.Inodes > 0 {
+			fs.InodesPercent = 25
+		}
+		fs.TimeToFull = 0
+		if fs.GrowthRate > 0 {
+			fs.TimeToFull = time.Duration(float64(fs.Free) / fs.GrowthRate * float64(time.Second))
+		}
+	}
+
+	// Pressure: mild CPU contention, occasional IO stalls, no memory pressure
+	cpuSome := rand.Float64() * 8
+	ioSome := rand.Float64() * 3
+	m.lastStats.Pressure = PressureStats{
+		CPU:    Pressure{Available: true, Some: PressureLine{Avg10: cpuSome, Avg60: cpuSome * 0.8, Avg300: cpuSome * 0.6}},
+		Memory: Pressure{Available: true},
+		IO: Pressure{
+			Available: true,
+			Some:      PressureLine{Avg10: ioSome, Avg60: ioSome * 0.8, Avg300: ioSome * 0.6},
+			Full:      PressureLine{Avg10: ioSome / 2, Avg60: ioSome * 0.4, Avg300: ioSome * 0.3},
+		},
+		IRQ: Pressure{Available: true},
+	}
+
+	// Hardware sensors: a CPU, a warm NVMe drive and a Super I/O chip with a
+	// stopped chassis fan
+	pkgTemp := 55 + rand.Float64()*10
+	m.lastStats.Sensors = []Sensor{
+		{ID: "coretemp-coretemp.0/temp1", Chip: "coretemp-coretemp.0", Label: "Package id 0", Kind: SensorTemp, Value: pkgTemp, Max: 80, Crit: 100},
+		{ID: "coretemp-coretemp.0/temp2", Chip: "coretemp-coretemp.0", Label: "Core 0", Kind: SensorTemp, Value: pkgTemp - 3, Max: 80, Crit: 100},
+		{ID: "nvme-nvme0/temp1", Chip: "nvme-nvme0", Label: "Composite", Kind: SensorTemp, Value: 74 + rand.Float64()*4, Min: -273.15, Max: 81.85, Crit: 84.85},
+		{ID: "nct6798-nct6775.656/fan1", Chip: "nct6798-nct6775.656", Label: "fan1", Kind: SensorFan, Value: float64(1100 + rand.Intn(50)), Min: 300},
+		{ID: "nct6798-nct6775.656/fan2", Chip: "nct6798-nct6775.656", Label: "fan2", Kind: SensorFan, Value: 0, Min: 300},
+		{ID: "nct6798-nct6775.656/in0", Chip: "nct6798-nct6775.656", Label: "Vcore", Kind: SensorVoltage, Value: 1.1 + rand.Float64()*0.1, Max: 1.74},
+		{ID: "nct6798-nct6775.656/in2", Chip: "nct6798-nct6775.656", Label: "AVSB", Kind: SensorVoltage, Value: 3.344, Min: 2.98, Max: 3.63},
+		{ID: "nct6798-nct6775.656/power1", Chip: "nct6798-nct6775.656", Label: "power1", Kind: SensorPower, Value: 40 + rand.Float64()*20},
+	}
+
+	// A laptop battery slowly discharging
+	batteryPower := 9 + rand.Float64()*4
+	m.lastStats.PowerSupply = PowerSupplyStats{
+		Batteries: []Battery{{
+			Name:         "BAT0",
+			Status:       "Discharging",
+			Percent:      78,
+			Power:        batteryPower,
+			Energy:       39,
+			EnergyFull:   50,
+			EnergyDesign: 57,
+			Health:       50.0 / 57 * 100,
+			CycleCount:   312,
+			TimeToEmpty:  time.Duration(39 / batteryPower * float64(time.Hour)),
+		}},
+	}
+
+	// Scheduler and paging activity of a busy desktop
+	m.lastStats.Kernel = KernelStats{
+		ContextSwitches: 8000 + rand.Float64()*4000,
+		Interrupts:      3000 + rand.Float64()*2000,
+		SoftIRQs:        1500 + rand.Float64()*1000,
+		Forks:           float64(rand.Intn(20)),
+		ProcsRunning:    uint64(1 + rand.Intn(4)),
+		ProcsBlocked:    uint64(rand.Intn(2)),
+		MinorFaults:     2000 + rand.Float64()*3000,
+		MajorFaults:     float64(rand.Intn(3)),
+		PageIn:          float64(rand.Intn(4)) * 1024 * 1024,
+		PageOut:         float64(rand.Intn(8)) * 1024 * 1024,
+	}
+
+	// GPUs
+	for i := range m.lastStats.GPUs {
+		gpu := &m.lastStats.GPUs[i]
+		gpu.Utilization = uint32(50 + rand.Intn(30))
+		gpu.Temperature = uint32(60 + rand.Intn(10))
+		gpu.MemoryUsed = uint64(8 * 1024 * 1024 * 1024)
+		gpu.FanSpeed = uint32(40 + rand.Intn(10))
+		gpu.GraphicsClock = 2500
+		gpu.MemoryClock = 10500
+		gpu.PowerUsage = 150000 // mW
+		gpu.PowerLimit = 450000 // mW
+		gpu.PState = "P2"
+		gpu.PCIeRxSpeed = uint64(rand.Intn(2000)) * 1024 * 1024
+		gpu.PCIeTxSpeed = uint64(rand.Intn(500)) * 1024 * 1024
+		gpu.EncoderUtil = uint32(rand.Intn(20))
+		gpu.DecoderUtil = uint32(rand.Intn(5))
+		gpu.Throttle = nil
+		if gpu.Temperature > 67 {
+			gpu.Throttle = []string{ThrottleSWThermal}
+		}
+		// Compute VRAM utilization percentage
+		if gpu.MemoryTotal > 0 {
+			gpu.MemoryUtil = uint32(float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100.0)
+		}
+
+		// Historical Graph
+		gpu.HistoricalUtil = append(gpu.HistoricalUtil[1:], float64(gpu.Utilization))
+		gpu.Processes = gpu.Processes[:0]
+	}
 
 	// Fake Processes
 	users := []string{"root", "jules", "systemd"}
@@ -86,20 +350,85 @@ func (m *MockProvider) GetStats() (*SystemStats, error) {
 			IsGPUUser:  isGpu,
 			Threads:    int32(1 + rand.Intn(10)),
 			Priority:   0,
+			Cgroup:     "/user.slice/user-1000.slice/session-1.scope",
+			CPUStall:   Pressure{Available: true, Some: PressureLine{Avg10: cpuSome}},
+			MemStall:   Pressure{Available: true},
 		}
 
-		if isGpu {
-			// Add to GPU process list if not already there (simplified for mock)
-			if len(m.lastStats.GPU.Processes) < 5 {
-				m.lastStats.GPU.Processes = append(m.lastStats.GPU.Processes, GPUProcess{
-					PID:        uint32(pid),
-					MemoryUsed: uint64(rand.Int63n(1000) * 1024 * 1024),
-				})
+		if isGpu && len(m.lastStats.GPUs) > 0 {
+			// Spread GPU users round-robin across the simulated devices
+			gpu := &m.lastStats.GPUs[i%len(m.lastStats.GPUs)]
+			gpuProc := GPUProcess{
+				PID:        uint32(pid),
+				Name:       m.lastStats.Processes[i].Command,
+				Type:       "C",
+				MemoryUsed: uint64(rand.Int63n(1000) * 1024 * 1024),
+				SMUtil:     uint32(rand.Intn(100)),
+				EncUtil:    uint32(rand.Intn(10)),
 			}
+			gpu.Processes = append(gpu.Processes, gpuProc)
+			m.lastStats.Processes[i].GPUMemory = gpuProc.MemoryUsed
+			m.lastStats.Processes[i].GPUUtil = gpuProc.SMUtil
 		}
 	}
+	m.energy.attribute(&m.lastStats, now)
 
-	return &m.lastStats, nil
+	return m.snapshot(), nil
+}
+
+// snapshot copies the state the mock updates in place, so the next call
+// does not modify a sample the UI is still rendering.
+func (m *MockProvider) snapshot() *SystemStats {
+	s := m.lastStats
+	s.CPU.PerCoreUsage = slices.Clone(s.CPU.PerCoreUsage)
+	s.CPU.PerCoreTemp = slices.Clone(s.CPU.PerCoreTemp)
+	s.CPU.PerCoreFreq = slices.Clone(s.CPU.PerCoreFreq)
+	s.CPU.PerCoreTimes = slices.Clone(s.CPU.PerCoreTimes)
+	s.Disk.Devices = slices.Clone(s.Disk.Devices)
+	for i := range s.Disk.Devices {
+		s.Disk.Devices[i].History = slices.Clone(s.Disk.Devices[i].History)
+	}
+	s.Net.Interfaces = slices.Clone(s.Net.Interfaces)
+	for i := range s.Net.Interfaces {
+		s.Net.Interfaces[i].History = slices.Clone(s.Net.Interfaces[i].History)
+	}
+	s.Filesystems = slices.Clone(s.Filesystems)
+	s.GPUs = slices.Clone(s.GPUs)
+	for i := range s.GPUs {
+		s.GPUs[i].HistoricalUtil = slices.Clone(s.GPUs[i].HistoricalUtil)
+		s.GPUs[i].Processes = slices.Clone(s.GPUs[i].Processes)
+	}
+	return &s
 }
 
 func (m *MockProvider) Shutdown() {}
+
+// averageCPUTimes averages per-core breakdowns into a host-wide one.
+func averageCPUTimes(cores []CPUTimes) CPUTimes {
+	var avg CPUTimes
+	if len(cores) == 0 {
+		return avg
+	}
+	for _, t := range cores {
+		avg.User += t.User
+		avg.Nice += t.Nice
+		avg.System += t.System
+		avg.IOWait += t.IOWait
+		avg.IRQ += t.IRQ
+		avg.SoftIRQ += t.SoftIRQ
+		avg.Steal += t.Steal
+		avg.Guest += t.Guest
+		avg.Idle += t.Idle
+	}
+	n := float64(len(cores))
+	avg.User /= n
+	avg.Nice /= n
+	avg.System /= n
+	avg.IOWait /= n
+	avg.IRQ /= n
+	avg.SoftIRQ /= n
+	avg.Steal /= n
+	avg.Guest /= n
+	avg.Idle /= n
+	return avg
+}
@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// netHistoryLength is the number of throughput samples kept per interface.
+const netHistoryLength = 100
+
+// netCounters holds the cumulative /proc/net/dev counters of an interface.
+type netCounters struct {
+	bytesRecv, packetsRecv, errorsIn, dropsIn   uint64
+	bytesSent, packetsSent, errorsOut, dropsOut uint64
+}
+
+// netClass is the cached classification of an interface.
+type netClass struct {
+	loopback bool
+	virtual  bool
+}
+
+// netTracker derives per-interface rates from consecutive samples of
+// /proc/net/dev and reads link state from sysfs.
+type netTracker struct {
+	procRoot  string
+	sysfsRoot string
+	patterns  []string // Interfaces counted in the aggregate; empty means physical ones
+
+	classes  map[string]netClass
+	last     map[string]netCounters
+	lastTime time.Time
+	history  map[string][]float64
+}
+
+func newNetTracker(procRoot, sysfsRoot string, patterns []string) *netTracker {
+	return &netTracker{
+		procRoot:  procRoot,
+		sysfsRoot: sysfsRoot,
+		patterns:  patterns,
+		classes:   make(map[string]netClass),
+		last:      make(map[string]netCounters),
+		history:   make(map[string][]float64),
+	}
+}
+
+// sample returns the aggregate over the selected interfaces along with every
+// interface, ordered by name. Rates are zero for interfaces that just
+// appeared, and for an interval in which a counter went backwards.
+func (t *netTracker) sample(now time.Time) (NetStats, error) {
+	counters, err := readNetDev(filepath.Join(t.procRoot, "net", "dev"))
+	if err != nil {
+		return NetStats{}, err
+	}
+
+	secs := now.Sub(t.lastTime).Seconds()
+	var stats NetStats
+	for name, cur := range counters {
+		class, ok := t.classes[name]
+		if !ok {
+			class = classifyNetInterface(t.sysfsRoot, name)
+			t.classes[name] = class
+		}
+		dir := filepath.Join(t.sysfsRoot, "class", "net", name)
+		iface := NetInterface{
+			Name:        name,
+			Loopback:    class.loopback,
+			Virtual:     class.virtual,
+			BytesRecv:   cur.bytesRecv,
+			BytesSent:   cur.bytesSent,
+			PacketsRecv: cur.packetsRecv,
+			PacketsSent: cur.packetsSent,
+			ErrorsIn:    cur.errorsIn,
+			ErrorsOut:   cur.errorsOut,
+			DropsIn:     cur.dropsIn,
+			DropsOut:    cur.dropsOut,
+		}
+		iface.OperState, _ = readSysfsString(filepath.Join(dir, "operstate"))
+		// speed reads -1 or fails with EINVAL while the link is down
+		if speed, err := readSysfsString(filepath.Join(dir, "speed")); err == nil {
+			if mbps, err := strconv.ParseInt(speed, 10, 64); err == nil && mbps > 0 {
+				iface.Speed = uint64(mbps)
+			}
+		}
+
+		if prev, ok := t.last[name]; ok && !t.lastTime.IsZero() && secs > 0 {
+			iface.DownloadSpeed = uint64(float64(counterDelta(prev.bytesRecv, cur.bytesRecv)) / secs)
+			iface.UploadSpeed = uint64(float64(counterDelta(prev.bytesSent, cur.bytesSent)) / secs)
+			iface.PacketsRecvRate = float64(counterDelta(prev.packetsRecv, cur.packetsRecv)) / secs
+			iface.PacketsSentRate = float64(counterDelta(prev.packetsSent, cur.packetsSent)) / secs
+			history := append(t.history[name], float64(iface.DownloadSpeed+iface.UploadSpeed))
+			if len(history) > netHistoryLength {
+				history = history[1:]
+			}
+			t.history[name] = history
+			iface.History = append([]float64(nil), history...)
+		}
+		stats.Interfaces = append(stats.Interfaces, iface)
+	}
+
+	// Forget interfaces that went away so a new one reusing the name (a
+	// container veth, a USB adapter) starts from scratch
+	for name := range t.last {
+		if _, ok := counters[name]; !ok {
+			delete(t.classes, name)
+			delete(t.history, name)
+		}
+	}
+	t.last = counters
+	t.lastTime = now
+
+	sort.Slice(stats.Interfaces, func(i, j int) bool { return stats.Interfaces[i].Name < stats.Interfaces[j].Name })
+	t.aggregate(&stats)
+	return stats, nil
+}
+
+// aggregate marks the interfaces counted in the totals and sums them. With
+// no configured patterns every physical interface counts, or every
+// non-loopback one when there are none (containers only see veths).
+func (t *netTracker) aggregate(stats *NetStats) {
+	selected := func(iface NetInterface) bool {
+		return !iface.Loopback && !iface.Virtual
+	}
+	if len(t.patterns) > 0 {
+		selected = func(iface NetInterface) bool {
+			for _, p := range t.patterns {
+				if ok, _ := filepath.Match(p, iface.Name); ok {
+					return true
+				}
+			}
+			return false
+		}
+	} else if !anyInterface(stats.Interfaces, selected) {
+		selected = func(iface NetInterface) bool { return !iface.Loopback }
+	}
+
+	for i := range stats.Interfaces {
+		iface := &stats.Interfaces[i]
+		if !selected(*iface) {
+			continue
+		}
+		iface.Aggregated = true
+		stats.BytesSent += iface.BytesSent
+		stats.BytesRecv += iface.BytesRecv
+		stats.UploadSpeed += iface.UploadSpeed
+		stats.DownloadSpeed += iface.DownloadSpeed
+	}
+}
+
+func anyInterface(ifaces []NetInterface, pred func(NetInterface) bool) bool {
+	for _, iface := range ifaces {
+		if pred(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// readNetDev parses /proc/net/dev. After two header lines, each line holds
+// an interface name followed by eight receive and eight transmit counters:
+//
+//	eth0: 1234 10 0 0 0 0 0 0 5678 12 0 0 0 0 0 0
+func readNetDev(path string) (map[string]netCounters, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]netCounters)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue // Header
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			continue
+		}
+		var v [16]uint64
+		for i := range v {
+			v[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+		stats[strings.TrimSpace(name)] = netCounters{
+			bytesRecv:   v[0],
+			packetsRecv: v[1],
+			errorsIn:    v[2],
+			dropsIn:     v[3],
+			bytesSent:   v[8],
+			packetsSent: v[9],
+			errorsOut:   v[10],
+			dropsOut:    v[11],
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// classifyNetInterface tells loopback and virtual interfaces (bridges,
+// veths, tunnels, VPNs), which have no backing device, apart from physical
+// ones.
+func classifyNetInterface(sysfsRoot, name string) netClass {
+	dir := filepath.Join(sysfsRoot, "class", "net", name)
+	if typ, err := readSysfsUint(filepath.Join(dir, "type")); (err == nil && typ == 772) || name == "lo" { // ARPHRD_LOOPBACK
+		return netClass{loopback: true, virtual: true}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+		return netClass{virtual: true}
+	}
+	return netClass{}
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+const netDevHeader = "Inter-|   Receive                                                |  Transmit\n" +
+	" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n"
+
+func TestNetTracker(t *testing.T) {
+	procRoot := t.TempDir()
+	sysRoot := t.TempDir()
+	writeFixture(t, sysRoot, map[string]string{
+		"class/net/lo/type":             "772\n",
+		"class/net/lo/operstate":        "unknown\n",
+		"class/net/eth0/type":           "1\n",
+		"class/net/eth0/operstate":      "up\n",
+		"class/net/eth0/speed":          "10000\n",
+		"class/net/eth0/device/uevent":  "DRIVER=ixgbe\n",
+		"class/net/wlan0/type":          "1\n",
+		"class/net/wlan0/operstate":     "down\n",
+		"class/net/wlan0/speed":         "-1\n",
+		"class/net/wlan0/device/uevent": "DRIVER=iwlwifi\n",
+		"class/net/docker0/type":        "1\n",
+		"class/net/docker0/operstate":   "up\n",
+	})
+	writeFixture(t, procRoot, map[string]string{
+		"net/dev": netDevHeader +
+			"    lo: 5000 50 0 0 0 0 0 0 5000 50 0 0 0 0 0 0\n" +
+			"  eth0: 1000000 1000 1 2 0 0 0 0 400000 500 3 4 0 0 0 0\n" +
+			" wlan0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n" +
+			"docker0: 9000 90 0 0 0 0 0 0 9000 90 0 0 0 0 0 0\n",
+	})
+
+	tracker := newNetTracker(procRoot, sysRoot, nil)
+	start := time.Unix(1000, 0)
+	stats, err := tracker.sample(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Interfaces) != 4 {
+		t.Fatalf("Expected 4 interfaces, got %d", len(stats.Interfaces))
+	}
+	byName := map[string]NetInterface{}
+	for _, iface := range stats.Interfaces {
+		byName[iface.Name] = iface
+	}
+	eth0 := byName["eth0"]
+	if eth0.Virtual || !eth0.Aggregated || eth0.Speed != 10000 || eth0.OperState != "up" {
+		t.Errorf("Unexpected eth0 %+v", eth0)
+	}
+	if eth0.ErrorsIn != 1 || eth0.DropsIn != 2 || eth0.ErrorsOut != 3 || eth0.DropsOut != 4 {
+		t.Errorf("Unexpected eth0 error counters %+v", eth0)
+	}
+	if lo := byName["lo"]; !lo.Loopback || lo.Aggregated {
+		t.Errorf("Loopback should not count in totals: %+v", lo)
+	}
+	if d := byName["docker0"]; !d.Virtual || d.Aggregated {
+		t.Errorf("Bridges should not count in totals: %+v", d)
+	}
+	if w := byName["wlan0"]; w.Speed != 0 || !w.Aggregated {
+		t.Errorf("Unexpected wlan0 %+v", w)
+	}
+	if stats.BytesRecv != 1000000 || stats.DownloadSpeed != 0 {
+		t.Errorf("Unexpected first sample totals %+v", stats)
+	}
+
+	// Two seconds later: eth0 moved 2 MB in and 200 KB out, wlan0's driver
+	// was reloaded (counters reset), docker0 went away and a veth appeared.
+	writeFixture(t, procRoot, map[string]string{
+		"net/dev": netDevHeader +
+			"    lo: 9000 90 0 0 0 0 0 0 9000 90 0 0 0 0 0 0\n" +
+			"  eth0: 3000000 2500 1 2 0 0 0 0 600000 900 3 4 0 0 0 0\n" +
+			" wlan0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n" +
+			"veth1a2b: 100 1 0 0 0 0 0 0 100 1 0 0 0 0 0 0\n",
+	})
+	tracker.last["wlan0"] = netCounters{bytesRecv: 1 << 40}
+	stats, err = tracker.sample(start.Add(2 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName = map[string]NetInterface{}
+	for _, iface := range stats.Interfaces {
+		byName[iface.Name] = iface
+	}
+	if _, ok := byName["docker0"]; ok {
+		t.Error("docker0 should be gone")
+	}
+	eth0 = byName["eth0"]
+	if eth0.DownloadSpeed != 1000000 || eth0.UploadSpeed != 100000 || eth0.PacketsRecvRate != 750 || eth0.PacketsSentRate != 200 {
+		t.Errorf("Unexpected eth0 rates %+v", eth0)
+	}
+	if len(eth0.History) != 1 || eth0.History[0] != 1100000 {
+		t.Errorf("Unexpected eth0 history %v", eth0.History)
+	}
+	if w := byName["wlan0"]; w.DownloadSpeed != 0 {
+		t.Errorf("Expected a counter reset to report no traffic, got %d", w.DownloadSpeed)
+	}
+	if v := byName["veth1a2b"]; !v.Virtual || v.DownloadSpeed != 0 || v.History != nil {
+		t.Errorf("Expected a new veth without rates, got %+v", v)
+	}
+	if stats.DownloadSpeed != 1000000 || stats.UploadSpeed != 100000 {
+		t.Errorf("Unexpected totals ↓%d ↑%d", stats.DownloadSpeed, stats.UploadSpeed)
+	}
+}
+
+func TestNetAggregateSelection(t *testing.T) {
+	ifaces := []NetInterface{
+		{Name: "eth0", Virtual: true, DownloadSpeed: 10},
+		{Name: "lo", Loopback: true, Virtual: true, DownloadSpeed: 100},
+		{Name: "wg0", Virtual: true, DownloadSpeed: 1000},
+	}
+
+	// Inside a container every interface is virtual: count all but loopback
+	stats := NetStats{Interfaces: append([]NetInterface(nil), ifaces...)}
+	(&netTracker{}).aggregate(&stats)
+	if stats.DownloadSpeed != 1010 {
+		t.Errorf("Expected non-loopback fallback total 1010, got %d", stats.DownloadSpeed)
+	}
+
+	stats = NetStats{Interfaces: append([]NetInterface(nil), ifaces...)}
+	(&netTracker{patterns: []string{"wg*", "lo"}}).aggregate(&stats)
+	if stats.DownloadSpeed != 1100 || stats.Interfaces[0].Aggregated {
+		t.Errorf("Expected patterns to select wg0 and lo, got %d", stats.DownloadSpeed)
+	}
+}
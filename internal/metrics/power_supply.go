@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// readPowerSupplies reads the AC state and system batteries from
+// /sys/class/power_supply. Peripheral batteries (mice, headsets) report a
+// "Device" scope and are left out.
+func readPowerSupplies(sysfsRoot string) PowerSupplyStats {
+	dirs, _ := filepath.Glob(filepath.Join(sysfsRoot, "class", "power_supply", "*"))
+	sort.Strings(dirs)
+
+	var stats PowerSupplyStats
+	for _, dir := range dirs {
+		typ, _ := readSysfsString(filepath.Join(dir, "type"))
+		switch typ {
+		case "Mains", "USB":
+			if online, err := readSysfsUint(filepath.Join(dir, "online")); err == nil && online == 1 {
+				stats.OnAC = true
+			}
+		case "Battery":
+			if scope, _ := readSysfsString(filepath.Join(dir, "scope")); scope == "Device" {
+				continue
+			}
+			if present, err := readSysfsUint(filepath.Join(dir, "present")); err == nil && present == 0 {
+				continue // Empty bay
+			}
+			stats.Batteries = append(stats.Batteries, readBattery(dir))
+		}
+	}
+	return stats
+}
+
+// readBattery reads one battery. Drivers report either energy (µWh) and
+// power (µW), or charge (µAh) and current (µA), which are converted using
+// the present voltage (µV).
+func readBattery(dir string) Battery {
+	// Some drivers report the current as negative while discharging
+	read := func(name string) float64 {
+		v, err := readSysfsInt(filepath.Join(dir, name))
+		if err != nil {
+			return 0
+		}
+		return math.Abs(float64(v))
+	}
+
+	b := Battery{Name: filepath.Base(dir)}
+	b.Status, _ = readSysfsString(filepath.Join(dir, "status"))
+	b.CycleCount = int(read("cycle_count"))
+
+	if _, err := readSysfsString(filepath.Join(dir, "energy_now")); err == nil {
+		b.Energy = read("energy_now") / 1e6
+		b.EnergyFull = read("energy_full") / 1e6
+		b.EnergyDesign = read("energy_full_design") / 1e6
+		b.Power = read("power_now") / 1e6
+	} else {
+		volts := read("voltage_now") / 1e6
+		b.Energy = read("charge_now") / 1e6 * volts
+		b.EnergyFull = read("charge_full") / 1e6 * volts
+		b.EnergyDesign = read("charge_full_design") / 1e6 * volts
+		b.Power = read("current_now") / 1e6 * volts
+	}
+
+	if capacity, err := readSysfsUint(filepath.Join(dir, "capacity")); err == nil {
+		b.Percent = float64(capacity)
+	} else if b.EnergyFull > 0 {
+		b.Percent = b.Energy / b.EnergyFull * 100
+	}
+	if b.EnergyDesign > 0 && b.EnergyFull > 0 {
+		b.Health = b.EnergyFull / b.EnergyDesign * 100
+	}
+
+	if b.Power > 0 {
+		hours := func(wh float64) time.Duration {
+			return time.Duration(wh / b.Power * float64(time.Hour))
+		}
+		switch b.Status {
+		case "Discharging":
+			b.TimeToEmpty = hours(b.Energy)
+		case "Charging":
+			if b.EnergyFull > b.Energy {
+				b.TimeToFull = hours(b.EnergyFull - b.Energy)
+			}
+		}
+	}
+	return b
+}
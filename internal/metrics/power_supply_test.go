@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadPowerSupplies(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"class/power_supply/AC/type":   "Mains\n",
+		"class/power_supply/AC/online": "0\n",
+
+		// Energy-reporting battery, discharging at 10 W
+		"class/power_supply/BAT0/type":               "Battery\n",
+		"class/power_supply/BAT0/present":            "1\n",
+		"class/power_supply/BAT0/status":             "Discharging\n",
+		"class/power_supply/BAT0/capacity":           "50\n",
+		"class/power_supply/BAT0/energy_now":         "25000000\n",
+		"class/power_supply/BAT0/energy_full":        "50000000\n",
+		"class/power_supply/BAT0/energy_full_design": "57000000\n",
+		"class/power_supply/BAT0/power_now":          "10000000\n",
+		"class/power_supply/BAT0/cycle_count":        "312\n",
+
+		// Charge-reporting battery with a negative current, charging
+		"class/power_supply/BAT1/type":               "Battery\n",
+		"class/power_supply/BAT1/status":             "Charging\n",
+		"class/power_supply/BAT1/charge_now":         "2000000\n",
+		"class/power_supply/BAT1/charge_full":        "4000000\n",
+		"class/power_supply/BAT1/charge_full_design": "4000000\n",
+		"class/power_supply/BAT1/current_now":        "-1000000\n",
+		"class/power_supply/BAT1/voltage_now":        "12000000\n",
+
+		// A wireless mouse and an empty bay
+		"class/power_supply/hidpp_battery_0/type":     "Battery\n",
+		"class/power_supply/hidpp_battery_0/scope":    "Device\n",
+		"class/power_supply/hidpp_battery_0/capacity": "90\n",
+		"class/power_supply/BAT2/type":                "Battery\n",
+		"class/power_supply/BAT2/present":             "0\n",
+	})
+
+	stats := readPowerSupplies(root)
+	if stats.OnAC {
+		t.Error("Expected to be on battery")
+	}
+	if len(stats.Batteries) != 2 {
+		t.Fatalf("Expected BAT0 and BAT1, got %+v", stats.Batteries)
+	}
+
+	bat0 := stats.Batteries[0]
+	if bat0.Name != "BAT0" || bat0.Percent != 50 || bat0.Power != 10 || bat0.Energy != 25 || bat0.CycleCount != 312 {
+		t.Errorf("Unexpected BAT0 %+v", bat0)
+	}
+	if bat0.TimeToEmpty != 150*time.Minute || bat0.TimeToFull != 0 {
+		t.Errorf("Expected 2h30m to empty, got %s", bat0.TimeToEmpty)
+	}
+	if bat0.Health < 87.7 || bat0.Health > 87.8 {
+		t.Errorf("Expected 87.7%% health, got %.2f", bat0.Health)
+	}
+
+	// 2 Ah at 12 V is 24 Wh, charging at 12 W
+	bat1 := stats.Batteries[1]
+	if bat1.Energy != 24 || bat1.EnergyFull != 48 || bat1.Power != 12 || bat1.Percent != 50 || bat1.Health != 100 {
+		t.Errorf("Unexpected BAT1 %+v", bat1)
+	}
+	if bat1.TimeToFull != 2*time.Hour || bat1.TimeToEmpty != 0 {
+		t.Errorf("Expected 2h to full, got %s", bat1.TimeToFull)
+	}
+}
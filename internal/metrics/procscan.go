@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// userHZ is the unit of the CPU times in /proc/<pid>/stat. The kernel
+// exports them in USER_HZ, which is 100 on every architecture it supports.
+const userHZ = 100
+
+// procSample is what the scanner keeps of a process between samples.
+type procSample struct {
+	jiffies   uint64 // utime + stime
+	startTime uint64 // Jiffies after boot, which tells a reused PID apart
+	command   string
+	cgroup    string
+}
+
+// procScanner reads the process table straight from /proc. Each process
+// costs one read of stat, statm, status and cgroup per sample, into a
+// buffer that is reused across files and samples. User names are looked up
+// once per UID, and commands and cgroup paths only allocate when they
+// change.
+type procScanner struct {
+	procRoot string
+	pageSize uint64
+	buf      []byte
+	users    map[uint32]string
+
+	last     map[int32]procSample
+	lastTime time.Time
+}
+
+func newProcScanner(procRoot string) *procScanner {
+	return &procScanner{
+		procRoot: procRoot,
+		pageSize: uint64(os.Getpagesize()),
+		buf:      make([]byte, 4096),
+		users:    make(map[uint32]string),
+		last:     make(map[int32]procSample),
+	}
+}
+
+// scan returns every process, with MemPercent taken against memTotal bytes.
+// CPUPercent is relative to one core, as in top, and zero for processes
+// seen for the first time. Processes that exit while being read are left
+// out.
+func (s *procScanner) scan(now time.Time, memTotal uint64) ([]ProcessInfo, error) {
+	dir, err := os.Open(s.procRoot)
+	if err != nil {
+		return nil, err
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	secs := now.Sub(s.lastTime).Seconds()
+	if s.lastTime.IsZero() {
+		secs = 0
+	}
+	procs := make([]ProcessInfo, 0, len(names))
+	last := make(map[int32]procSample, len(names))
+	for _, name := range names {
+		pid, err := strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			continue // Not a process directory
+		}
+		base := s.procRoot + "/" + name + "/"
+
+		data, err := s.readFile(base + "stat")
+		if err != nil {
+			continue
+		}
+		p := ProcessInfo{PID: int32(pid)}
+		cur, ok := s.parseStat(data, &p)
+		if !ok {
+			continue
+		}
+		prev, seen := s.last[p.PID]
+		if seen && prev.startTime != cur.startTime {
+			seen = false // The PID was reused
+		}
+		cur.command = p.Command
+		p.StartTime = cur.startTime
+		if seen && secs > 0 {
+			p.CPUPercent = float64(counterDelta(prev.jiffies, cur.jiffies)) / userHZ / secs * 100
+		}
+
+		if data, err := s.readFile(base + "statm"); err == nil {
+			p.Memory = nthField(data, 1) * s.pageSize
+			if memTotal > 0 {
+				p.MemPercent = float64(p.Memory) / float64(memTotal) * 100
+			}
+		}
+		if data, err := s.readFile(base + "status"); err == nil {
+			if uid, ok := statusUID(data); ok {
+				p.User = s.username(uid)
+			}
+		}
+		if data, err := s.readFile(base + "cgroup"); err == nil {
+			if path := cgroupV2Path(data); seen && prev.cgroup == string(path) {
+				cur.cgroup = prev.cgroup
+			} else {
+				cur.cgroup = string(path)
+			}
+			p.Cgroup = cur.cgroup
+		}
+		last[p.PID] = cur
+		procs = append(procs, p)
+	}
+
+	s.last = last
+	s.lastTime = now
+	return procs, nil
+}
+
+// readFile reads a whole file into the scanner's buffer, growing it as
+// needed. The result is only valid until the next call. It goes through
+// raw syscalls: an os.File costs several allocations and a poller
+// registration, which dominate for files this small.
+func (s *procScanner) readFile(path string) ([]byte, error) {
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	// procfs files report a size of zero, so read until EOF
+	n := 0
+	for {
+		if n == len(s.buf) {
+			s.buf = append(s.buf, make([]byte, len(s.buf))...)
+		}
+		m, err := syscall.Read(fd, s.buf[n:])
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if m == 0 {
+			return s.buf[:n], nil
+		}
+		n += m
+	}
+}
+
+// parseStat fills in the command, state, parent, nice value and thread
+// count from /proc/<pid>/stat and returns the CPU counters. The command
+// sits in parentheses and may itself hold spaces and parentheses, so
+// fields are counted from the last ")".
+func (s *procScanner) parseStat(data []byte, p *ProcessInfo) (procSample, bool) {
+	open := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open || end+2 > len(data) {
+		return procSample{}, false
+	}
+	// Reuse last sample's string rather than allocating a new one; the
+	// comparison itself does not allocate
+	command := data[open+1 : end]
+	if prev, ok := s.last[p.PID]; ok && prev.command == string(command) {
+		p.Command = prev.command
+	} else {
+		p.Command = string(command)
+	}
+
+	// Index 0 is field 3 (state) in proc(5)
+	var cur procSample
+	var utime, stime uint64
+	rest := data[end+2:]
+	for i := 0; i <= 19 && len(rest) > 0; i++ {
+		field := rest
+		if sp := bytes.IndexByte(rest, ' '); sp >= 0 {
+			field, rest = rest[:sp], rest[sp+1:]
+		} else {
+			rest = nil
+		}
+		switch i {
+		case 0:
+			p.State = string(field)
+		case 1:
+			p.ParentPID = int32(parseDecimal(field))
+		case 11:
+			utime = uint64(parseDecimal(field))
+		case 12:
+			stime = uint64(parseDecimal(field))
+		case 16:
+			p.Priority = int32(parseDecimal(field))
+		case 17:
+			p.Threads = int32(parseDecimal(field))
+		case 19:
+			cur.startTime = uint64(parseDecimal(field))
+		}
+	}
+	cur.jiffies = utime + stime
+	return cur, true
+}
+
+// username resolves a UID, falling back to the number for users that are
+// not in the passwd database (containers, deleted accounts).
+func (s *procScanner) username(uid uint32) string {
+	if name, ok := s.users[uid]; ok {
+		return name
+	}
+	id := strconv.FormatUint(uint64(uid), 10)
+	name := id
+	if u, err := user.LookupId(id); err == nil {
+		name = u.Username
+	}
+	s.users[uid] = name
+	return name
+}
+
+// statusUID returns the real UID from the "Uid:" line of
+// /proc/<pid>/status.
+func statusUID(data []byte) (uint32, bool) {
+	i := bytes.Index(data, []byte("\nUid:"))
+	if i < 0 {
+		return 0, false
+	}
+	line := bytes.TrimLeft(data[i+len("\nUid:"):], " \t")
+	end := bytes.IndexAny(line, " \t\n")
+	if end <= 0 {
+		return 0, false
+	}
+	return uint32(parseDecimal(line[:end])), true
+}
+
+// nthField parses the n-th space-separated number of a line, counting from
+// zero, or returns zero when it is missing.
+func nthField(data []byte, n int) uint64 {
+	for ; n > 0; n-- {
+		sp := bytes.IndexByte(data, ' ')
+		if sp < 0 {
+			return 0
+		}
+		data = data[sp+1:]
+	}
+	if sp := bytes.IndexAny(data, " \n"); sp >= 0 {
+		data = data[:sp]
+	}
+	return uint64(parseDecimal(data))
+}
+
+// parseDecimal parses a signed decimal integer without allocating,
+// stopping at the first byte that is not a digit.
+func parseDecimal(b []byte) int64 {
+	neg := len(b) > 0 && b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+	var v int64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		v = v*10 + int64(c-'0')
+	}
+	if neg {
+		return -v
+	}
+	return v
+}
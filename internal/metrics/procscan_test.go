@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// procStat formats a /proc/<pid>/stat line with the fields the scanner
+// reads and zeroes elsewhere.
+func procStat(pid int, comm, state string, ppid int, utime, stime uint64, nice, threads int, start uint64) string {
+	return fmt.Sprintf("%d (%s) %s %d %d %d 0 -1 4194560 1200 0 0 0 %d %d 0 0 20 %d %d 0 %d 12345678 2500 "+
+		"18446744073709551615 1 1 0 0 0 0 0 4096 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 0\n",
+		pid, comm, state, ppid, pid, pid, utime, stime, nice, threads, start)
+}
+
+// procStatus is a /proc/<pid>/status trimmed to the lines around Uid.
+func procStatus(comm string, uid int) string {
+	return fmt.Sprintf("Name:\t%s\nUmask:\t0022\nState:\tS (sleeping)\nTgid:\t1\nPid:\t1\nPPid:\t0\n"+
+		"Uid:\t%d\t%d\t%d\t%d\nGid:\t0\t0\t0\t0\nThreads:\t1\n", comm, uid, uid, uid, uid)
+}
+
+func TestProcScanner(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"stat":        "cpu  1 2 3 4\n", // Not a process
+		"10/stat":     procStat(10, "nginx", "S", 1, 100, 50, 0, 4, 5000),
+		"10/statm":    "5000 2048 300 100 0 900 0\n",
+		"10/status":   procStatus("nginx", 0),
+		"10/cgroup":   "0::/system.slice/nginx.service\n",
+		"20/stat":     procStat(20, "tmux: server (1) ", "R", 1, 0, 0, -5, 1, 6000),
+		"20/statm":    "1000 512 100 10 0 200 0\n",
+		"20/status":   procStatus("tmux: server", 4242),
+		"self/status": procStatus("omnitop", 0),
+	})
+
+	scanner := newProcScanner(root)
+	scanner.pageSize = 4096
+	start := time.Unix(1000, 0)
+	procs, err := scanner.scan(start, 64<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 2 {
+		t.Fatalf("Expected 2 processes, got %+v", procs)
+	}
+	byPID := func() map[int32]ProcessInfo {
+		m := make(map[int32]ProcessInfo)
+		for _, p := range procs {
+			m[p.PID] = p
+		}
+		return m
+	}
+
+	nginx := byPID()[10]
+	if nginx.Command != "nginx" || nginx.State != "S" || nginx.ParentPID != 1 || nginx.Threads != 4 || nginx.StartTime != 5000 || nginx.CPUPercent != 0 {
+		t.Errorf("Unexpected nginx %+v", nginx)
+	}
+	if nginx.Cgroup != "/system.slice/nginx.service" || byPID()[20].Cgroup != "" {
+		t.Errorf("Expected nginx's cgroup only, got %q and %q", nginx.Cgroup, byPID()[20].Cgroup)
+	}
+	if nginx.Memory != 8<<20 || nginx.MemPercent != 12.5 {
+		t.Errorf("Expected 8 MiB, 12.5%% RSS, got %d, %.2f%%", nginx.Memory, nginx.MemPercent)
+	}
+	tmux := byPID()[20]
+	if tmux.Command != "tmux: server (1) " || tmux.Priority != -5 || tmux.User != "4242" {
+		t.Errorf("Unexpected tmux %+v", tmux)
+	}
+
+	// Two seconds later nginx used 150 more jiffies, and PID 20 belongs to
+	// a new process that started after the last sample
+	writeFixture(t, root, map[string]string{
+		"10/stat":   procStat(10, "nginx", "S", 1, 200, 100, 0, 4, 5000),
+		"20/stat":   procStat(20, "make", "R", 1, 900, 0, 0, 1, 7000),
+		"10/cgroup": "0::/system.slice/nginx.service/reload\n",
+	})
+	procs, err = scanner.scan(start.Add(2*time.Second), 64<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := byPID()[10].CPUPercent; got != 75 {
+		t.Errorf("Expected nginx at 75%%, got %.2f", got)
+	}
+	if got := byPID()[10].Cgroup; got != "/system.slice/nginx.service/reload" {
+		t.Errorf("Expected nginx's new cgroup, got %q", got)
+	}
+	if p := byPID()[20]; p.Command != "make" || p.CPUPercent != 0 {
+		t.Errorf("Expected no CPU for a reused PID, got %+v", p)
+	}
+}
+
+// writeSyntheticProcfs creates n processes, each with the files both
+// scanners read, plus the host-wide files gopsutil needs.
+func writeSyntheticProcfs(b *testing.B, n int) string {
+	root := b.TempDir()
+	files := map[string]string{
+		"stat":    "cpu  1 2 3 4 5 6 7 0 0 0\nbtime 1700000000\n",
+		"meminfo": "MemTotal:       65536000 kB\nMemFree:        32768000 kB\nMemAvailable:   40000000 kB\n",
+		"uptime":  "12345.67 54321.00\n",
+	}
+	uid := os.Getuid()
+	for pid := 1; pid <= n; pid++ {
+		dir := strconv.Itoa(pid) + "/"
+		comm := fmt.Sprintf("worker-%d", pid%50)
+		files[dir+"stat"] = procStat(pid, comm, "S", 1, uint64(pid*3), uint64(pid), 0, 1+pid%8, uint64(pid*10))
+		files[dir+"statm"] = "5000 2048 300 100 0 900 0\n"
+		files[dir+"status"] = procStatus(comm, uid)
+		files[dir+"cgroup"] = fmt.Sprintf("0::/system.slice/worker-%d.service\n", pid%50)
+	}
+	writeFixture(b, root, files)
+	return root
+}
+
+const benchmarkProcesses = 5000
+
+func BenchmarkProcScanner(b *testing.B) {
+	root := writeSyntheticProcfs(b, benchmarkProcesses)
+	scanner := newProcScanner(root)
+	now := time.Now()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		now = now.Add(time.Second)
+		if _, err := scanner.scan(now, 64<<30); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGopsutilProcesses measures the per-field gopsutil calls the
+// scanner replaced, and the cgroup read that went with them, against the
+// same tree.
+func BenchmarkGopsutilProcesses(b *testing.B) {
+	root := writeSyntheticProcfs(b, benchmarkProcesses)
+	b.Setenv("HOST_PROC", root)
+	cache := make(map[int32]*process.Process)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pids, err := process.Pids()
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, pid := range pids {
+			// process.NewProcess checks that the PID exists on the host
+			p, ok := cache[pid]
+			if !ok {
+				p = &process.Process{Pid: pid}
+				cache[pid] = p
+			}
+			p.Name()
+			p.Username()
+			p.Percent(0)
+			p.MemoryPercent()
+			p.MemoryInfo()
+			p.Ppid()
+			p.NumThreads()
+			p.Nice()
+			p.Status()
+			if data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(int(pid)), "cgroup")); err == nil {
+				for _, line := range strings.Split(string(data), "\n") {
+					if _, ok := strings.CutPrefix(line, "0::"); ok {
+						break
+					}
+				}
+			}
+		}
+	}
+	if len(cache) != benchmarkProcesses {
+		b.Fatalf("gopsutil found %d of %d processes", len(cache), benchmarkProcesses)
+	}
+}
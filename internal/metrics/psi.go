@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readPressure parses a PSI file (/proc/pressure/* or a cgroup's
+// *.pressure) of the form:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPressure(path string) (Pressure, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pressure{}, err
+	}
+	p := Pressure{Available: true}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var target *PressureLine
+		switch fields[0] {
+		case "some":
+			target = &p.Some
+		case "full":
+			target = &p.Full
+		default:
+			continue
+		}
+		for _, kv := range fields[1:] {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "avg10":
+				target.Avg10, _ = strconv.ParseFloat(value, 64)
+			case "avg60":
+				target.Avg60, _ = strconv.ParseFloat(value, 64)
+			case "avg300":
+				target.Avg300, _ = strconv.ParseFloat(value, 64)
+			case "total":
+				target.Total, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+	return p, nil
+}
+
+// readSystemPressure reads host-wide PSI. Resources the kernel does not
+// expose (irq needs CONFIG_IRQ_TIME_ACCOUNTING, everything needs 4.20+) are
+// left unavailable.
+func readSystemPressure(procRoot string) PressureStats {
+	dir := filepath.Join(procRoot, "pressure")
+	var stats PressureStats
+	stats.CPU, _ = readPressure(filepath.Join(dir, "cpu"))
+	stats.Memory, _ = readPressure(filepath.Join(dir, "memory"))
+	stats.IO, _ = readPressure(filepath.Join(dir, "io"))
+	stats.IRQ, _ = readPressure(filepath.Join(dir, "irq"))
+	return stats
+}
+
+// cgroupV2Path returns the cgroup v2 path ("/user.slice/...") from the
+// contents of /proc/<pid>/cgroup, or nil on cgroup v1-only hosts. The
+// unified hierarchy is the "0::" line, listed after any v1 controllers.
+func cgroupV2Path(data []byte) []byte {
+	i := 0
+	if !bytes.HasPrefix(data, []byte("0::")) {
+		i = bytes.Index(data, []byte("\n0::"))
+		if i < 0 {
+			return nil
+		}
+		i++
+	}
+	path := data[i+len("0::"):]
+	if end := bytes.IndexByte(path, '\n'); end >= 0 {
+		path = path[:end]
+	}
+	return path
+}
+
+// cgroupPressure caches per-cgroup PSI for one sample, since most processes
+// share a handful of cgroups.
+type cgroupPressure struct {
+	root  string // cgroup2 mount point
+	cache map[string][2]Pressure
+}
+
+func newCgroupPressure(sysfsRoot string) *cgroupPressure {
+	return &cgroupPressure{
+		root:  filepath.Join(sysfsRoot, "fs", "cgroup"),
+		cache: make(map[string][2]Pressure),
+	}
+}
+
+// get returns the CPU and memory pressure of a cgroup.
+func (c *cgroupPressure) get(cgroup string) (cpu, memory Pressure) {
+	if p, ok := c.cache[cgroup]; ok {
+		return p[0], p[1]
+	}
+	dir := filepath.Join(c.root, cgroup)
+	cpu, _ = readPressure(filepath.Join(dir, "cpu.pressure"))
+	memory, _ = readPressure(filepath.Join(dir, "memory.pressure"))
+	c.cache[cgroup] = [2]Pressure{cpu, memory}
+	return cpu, memory
+}
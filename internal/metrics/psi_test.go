@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSystemPressure(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"pressure/cpu": "some avg10=12.50 avg60=8.00 avg300=2.25 total=123456789\n" +
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"pressure/memory": "some avg10=3.10 avg60=1.00 avg300=0.50 total=4000\n" +
+			"full avg10=1.20 avg60=0.40 avg300=0.10 total=1500\n",
+		"pressure/io": "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n" +
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		// No irq file: kernel built without CONFIG_IRQ_TIME_ACCOUNTING
+	})
+
+	psi := readSystemPressure(root)
+	if !psi.CPU.Available || psi.CPU.Some.Avg10 != 12.5 || psi.CPU.Some.Avg60 != 8 || psi.CPU.Some.Avg300 != 2.25 {
+		t.Errorf("Unexpected CPU pressure %+v", psi.CPU)
+	}
+	if psi.CPU.Some.Total != 123456789 {
+		t.Errorf("Expected CPU total 123456789, got %d", psi.CPU.Some.Total)
+	}
+	if psi.Memory.Full.Avg10 != 1.2 || psi.Memory.Full.Total != 1500 {
+		t.Errorf("Unexpected memory full pressure %+v", psi.Memory.Full)
+	}
+	if !psi.IO.Available {
+		t.Error("IO pressure should be available")
+	}
+	if psi.IRQ.Available {
+		t.Error("IRQ pressure should be unavailable without the file")
+	}
+}
+
+func TestCgroupPressure(t *testing.T) {
+	sysRoot := t.TempDir()
+	writeFixture(t, sysRoot, map[string]string{
+		"fs/cgroup/system.slice/nginx.service/cpu.pressure": "some avg10=40.00 avg60=20.00 avg300=5.00 total=99\n" +
+			"full avg10=10.00 avg60=5.00 avg300=1.00 total=9\n",
+		"fs/cgroup/system.slice/nginx.service/memory.pressure": "some avg10=2.00 avg60=1.00 avg300=0.00 total=7\n" +
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+	})
+
+	for _, c := range []struct{ data, want string }{
+		{"0::/system.slice/nginx.service\n", "/system.slice/nginx.service"},
+		// Hybrid hierarchy: v1 controllers listed before the unified entry
+		{"12:cpu,cpuacct:/user.slice\n1:name=systemd:/user.slice\n0::/user.slice\n", "/user.slice"},
+		{"0::/", "/"},
+		// cgroup v1 only
+		{"4:memory:/\n", ""},
+		{"", ""},
+	} {
+		if got := string(cgroupV2Path([]byte(c.data))); got != c.want {
+			t.Errorf("cgroupV2Path(%q) = %q, want %q", c.data, got, c.want)
+		}
+	}
+
+	cgroups := newCgroupPressure(sysRoot)
+	cpu, memory := cgroups.get("/system.slice/nginx.service")
+	if cpu.Some.Avg10 != 40 || cpu.Full.Avg10 != 10 || memory.Some.Avg10 != 2 {
+		t.Errorf("Unexpected cgroup pressure cpu=%+v memory=%+v", cpu, memory)
+	}
+
+	// Later lookups of the same cgroup come from the per-sample cache
+	writeFixture(t, sysRoot, map[string]string{
+		"fs/cgroup/system.slice/nginx.service/cpu.pressure": "some avg10=99.00 avg60=0.00 avg300=0.00 total=0\n",
+	})
+	if cpu, _ := cgroups.get("/system.slice/nginx.service"); cpu.Some.Avg10 != 40 {
+		t.Errorf("Expected cached pressure, got %+v", cpu)
+	}
+
+	if cpu, memory := cgroups.get("/user.slice"); cpu.Available || memory.Available {
+		t.Errorf("Expected no pressure for a cgroup without PSI files, got %+v %+v", cpu, memory)
+	}
+}
+
+func TestReadPressureMissing(t *testing.T) {
+	if _, err := readPressure(filepath.Join(t.TempDir(), "cpu")); err == nil {
+		t.Error("Expected an error for a missing PSI file")
+	}
+}
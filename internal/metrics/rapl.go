@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// raplZone is one RAPL energy counter: a package (socket) or one of its
+// core, uncore and dram subzones.
+type raplZone struct {
+	dir      string
+	name     string // "package-0", "core", "uncore" or "dram"
+	maxRange uint64 // µJ at which energy_uj wraps back to zero
+}
+
+// raplTracker derives CPU power from consecutive samples of the RAPL energy
+// counters in /sys/class/powercap. AMD CPUs expose theirs through the same
+// intel-rapl interface.
+type raplTracker struct {
+	zones    []raplZone
+	last     map[string]uint64 // µJ, by zone directory
+	lastTime time.Time
+}
+
+// newRAPLTracker discovers the RAPL zones below sysfsRoot. The MMIO
+// interface duplicates the package counters and is skipped, as is psys,
+// which covers the whole platform rather than the CPU.
+func newRAPLTracker(sysfsRoot string) *raplTracker {
+	dirs, _ := filepath.Glob(filepath.Join(sysfsRoot, "class", "powercap", "intel-rapl:*"))
+	sort.Strings(dirs)
+
+	t := &raplTracker{last: make(map[string]uint64)}
+	for _, dir := range dirs {
+		name, err := readSysfsString(filepath.Join(dir, "name"))
+		if err != nil || name == "psys" {
+			continue
+		}
+		maxRange, _ := readSysfsUint(filepath.Join(dir, "max_energy_range_uj"))
+		t.zones = append(t.zones, raplZone{dir: dir, name: name, maxRange: maxRange})
+	}
+	return t
+}
+
+// sample returns the power drawn since the previous sample, summed over
+// sockets. It is unavailable without RAPL, on the first sample, and when
+// the counters are not readable (they are root-only on recent kernels).
+func (t *raplTracker) sample(now time.Time) CPUPower {
+	secs := now.Sub(t.lastTime).Seconds()
+	var power CPUPower
+	for _, z := range t.zones {
+		energy, err := readSysfsUint(filepath.Join(z.dir, "energy_uj"))
+		if err != nil {
+			continue
+		}
+		prev, ok := t.last[z.dir]
+		t.last[z.dir] = energy
+		if !ok || t.lastTime.IsZero() || secs <= 0 {
+			continue
+		}
+
+		delta := energy - prev
+		if energy < prev {
+			// The counter wrapped, which happens every few minutes under load
+			if z.maxRange <= prev {
+				continue
+			}
+			delta = z.maxRange - prev + energy
+		}
+		watts := float64(delta) / 1e6 / secs
+
+		power.Available = true
+		switch {
+		case strings.HasPrefix(z.name, "package"):
+			power.Package += watts
+		case z.name == "core":
+			power.Core += watts
+		case z.name == "uncore":
+			power.Uncore += watts
+		case z.name == "dram":
+			power.DRAM += watts
+		}
+	}
+	t.lastTime = now
+	return power
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRAPLTracker(t *testing.T) {
+	root := t.TempDir()
+	const pkg = "class/powercap/intel-rapl:0"
+	writeFixture(t, root, map[string]string{
+		pkg + "/name":                             "package-0\n",
+		pkg + "/energy_uj":                        "999000000\n",
+		pkg + "/max_energy_range_uj":              "1000000000\n",
+		"class/powercap/intel-rapl:0:0/name":      "core\n",
+		"class/powercap/intel-rapl:0:0/energy_uj": "5000000\n",
+		"class/powercap/intel-rapl:0:1/name":      "dram\n",
+		"class/powercap/intel-rapl:0:1/energy_uj": "1000000\n",
+		"class/powercap/intel-rapl:1/name":        "psys\n",
+		"class/powercap/intel-rapl:1/energy_uj":   "0\n",
+	})
+
+	tracker := newRAPLTracker(root)
+	if len(tracker.zones) != 3 {
+		t.Fatalf("Expected package, core and dram zones, got %+v", tracker.zones)
+	}
+
+	start := time.Unix(1000, 0)
+	if power := tracker.sample(start); power.Available {
+		t.Errorf("Expected no power on the first sample, got %+v", power)
+	}
+
+	// Two seconds later the package counter has wrapped past its range
+	writeFixture(t, root, map[string]string{
+		pkg + "/energy_uj":                        "59000000\n",
+		"class/powercap/intel-rapl:0:0/energy_uj": "45000000\n",
+		"class/powercap/intel-rapl:0:1/energy_uj": "7000000\n",
+		"class/powercap/intel-rapl:1/energy_uj":   "500000000\n",
+	})
+	power := tracker.sample(start.Add(2 * time.Second))
+	if !power.Available || power.Package != 30 || power.Core != 20 || power.DRAM != 3 || power.Uncore != 0 {
+		t.Errorf("Unexpected power %+v", power)
+	}
+}
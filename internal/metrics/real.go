@@ -1,272 +1,399 @@
 package metrics
 
 import (
+	"context"
 	"log"
 	"time"
 
-	"github.com/mindprince/gonvml"
 	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/shirou/gopsutil/v3/net"
-	"github.com/shirou/gopsutil/v3/process"
+	"github.com/shirou/gopsutil/v3/load"
 )
 
 type RealProvider struct {
-	hasGPU     bool
-	gpuHistory []float64
-	lastNet    NetStats
-	lastDisk   DiskStats
-	lastTime   time.Time
+	// GPUBackend is the driver used for GPU metrics. Defaults to
+	// DefaultGPUBackend().
+	GPUBackend GPUBackend
+	// SysfsRoot is where sensors and cgroups are read from. Defaults to
+	// "/sys".
+	SysfsRoot string
+	// ProcRoot is where pressure and per-process files are read from.
+	// Defaults to "/proc".
+	ProcRoot string
+	// FilesystemFilter selects the reported mounts. Nil lists default to
+	// those of DefaultFilesystemFilter; empty ones exclude nothing.
+	FilesystemFilter FilesystemFilter
+	// NetInterfaces holds glob patterns of the interfaces counted in the
+	// network totals. Empty counts every physical interface.
+	NetInterfaces []string
+	// CollectorTimeout is how long each subsystem may take to sample
+	// before it is reported stale. Defaults to DefaultCollectorTimeout.
+	CollectorTimeout time.Duration
+	// Intervals sets how often each subsystem is sampled. Subsystems not
+	// listed are sampled on every call; intervals shorter than the time
+	// between calls have no effect.
+	Intervals map[Subsystem]time.Duration
+
+	cpuTemps     *cpuTempSensors // nil when the host exposes no CPU sensor
+	coreTypes    []CoreType      // nil on non-hybrid CPUs
+	cpuProbed    bool
+	hasGPU       bool
+	gpuHistory   map[int][]float64 // Utilization history keyed by device index
+	procs        *procScanner
+	disks        *diskStatsTracker
+	filesystems  *filesystemTracker
+	net          *netTracker
+	kernel       *kernelTracker
+	sensors      *hwmonSensors
+	collectors   map[Subsystem]*collector
+	lastSample   time.Time
+	rapl         *raplTracker
+	energy       *energyAccountant
+	lastCPUTotal cpu.TimesStat
+	lastCPUTimes []cpu.TimesStat
 }
 
 func (r *RealProvider) Init() error {
-	// Initialize NVML
-	if err := gonvml.Initialize(); err != nil {
-		log.Printf("NVML initialization failed (GPU metrics unavailable): %v", err)
-		r.hasGPU = false
-	} else {
-		r.hasGPU = true
-		// Initialize GPU history buffer
-		r.gpuHistory = make([]float64, 0, 100)
+	if r.SysfsRoot == "" {
+		r.SysfsRoot = "/sys"
+	}
+	if r.ProcRoot == "" {
+		r.ProcRoot = "/proc"
+	}
+	if r.CollectorTimeout <= 0 {
+		r.CollectorTimeout = DefaultCollectorTimeout
 	}
-	r.lastTime = time.Now()
-	r.procCache = make(map[int32]*process.Process)
+	r.initGPUBackend()
+	r.gpuHistory = make(map[int][]float64)
+	r.procs = newProcScanner(r.ProcRoot)
+	r.disks = newDiskStatsTracker(r.ProcRoot, r.SysfsRoot)
+	if r.FilesystemFilter.ExcludeTypes == nil {
+		r.FilesystemFilter.ExcludeTypes = DefaultFilesystemFilter.ExcludeTypes
+	}
+	if r.FilesystemFilter.ExcludeMounts == nil {
+		r.FilesystemFilter.ExcludeMounts = DefaultFilesystemFilter.ExcludeMounts
+	}
+	r.filesystems = newFilesystemTracker(r.ProcRoot, r.FilesystemFilter)
+	r.net = newNetTracker(r.ProcRoot, r.SysfsRoot, r.NetInterfaces)
+	r.kernel = newKernelTracker(r.ProcRoot)
+	r.sensors = discoverHwmonSensors(r.SysfsRoot)
+	r.rapl = newRAPLTracker(r.SysfsRoot)
+	r.energy = newEnergyAccountant()
+	r.initCollectors()
 	return nil
 }
 
-func (r *RealProvider) GetStats() (*SystemStats, error) {
-	now := time.Now()
-	duration := now.Sub(r.lastTime).Seconds()
-	if duration < 0.1 {
-		duration = 0.1 // Prevent division by zero
+// initCollectors sets up a collector per subsystem, with what resetting the
+// rate baselines of each takes.
+func (r *RealProvider) initCollectors() {
+	resets := map[Subsystem]func(){
+		SubsystemCPU: func() {
+			r.lastCPUTotal, r.lastCPUTimes = cpu.TimesStat{}, nil
+			r.rapl.lastTime = time.Time{}
+			r.kernel.lastTime = time.Time{}
+		},
+		SubsystemDisks:     func() { r.disks.lastTime = time.Time{} },
+		SubsystemNetwork:   func() { r.net.lastTime = time.Time{} },
+		SubsystemProcesses: func() { r.procs.lastTime = time.Time{} },
+	}
+	r.collectors = make(map[Subsystem]*collector, len(Subsystems))
+	for _, sub := range Subsystems {
+		r.collectors[sub] = &collector{interval: r.Intervals[sub], reset: resets[sub]}
+	}
+}
+
+// initGPUBackend initializes the configured GPU backend, or the default set
+// of vendor backends when none is configured.
+func (r *RealProvider) initGPUBackend() {
+	if r.GPUBackend == nil {
+		r.GPUBackend = DefaultGPUBackend()
+	}
+	if err := r.GPUBackend.Init(); err != nil {
+		log.Printf("%s initialization failed (GPU metrics unavailable): %v", r.GPUBackend.Name(), err)
+		r.hasGPU = false
+		return
 	}
+	r.hasGPU = true
+}
 
+func (r *RealProvider) GetStats(ctx context.Context) (*SystemStats, error) {
+	now := time.Now()
 	stats := &SystemStats{
 		Timestamp: now,
 	}
 
-	// Host Info (Uptime)
+	// Uptime
 	if uptime, err := host.Uptime(); err == nil {
 		stats.Uptime = uptime
 	}
 
-	// Uptime
-	uptime, _ := host.Uptime()
-	stats.Uptime = uptime
+	// Rates are measured on the monotonic clock, which stops during a
+	// suspend while some counters carry on, so they start over after one
+	if !r.lastSample.IsZero() && suspended(now.Round(0).Sub(r.lastSample.Round(0)), now.Sub(r.lastSample)) {
+		for _, c := range r.collectors {
+			c.resyncRates()
+		}
+		r.energy.lastTime = time.Time{}
+	}
+	r.lastSample = now
+
+	r.collect(ctx, stats, SubsystemCPU, func() func(*SystemStats) { return r.sampleCPU(now) })
+	r.collect(ctx, stats, SubsystemSensors, r.sampleSensors)
+	r.collect(ctx, stats, SubsystemMemory, r.sampleMemory)
+	r.collect(ctx, stats, SubsystemDisks, func() func(*SystemStats) { return r.sampleDisks(now) })
+	r.collect(ctx, stats, SubsystemFilesystems, func() func(*SystemStats) { return r.sampleFilesystems(now) })
+	r.collect(ctx, stats, SubsystemNetwork, func() func(*SystemStats) { return r.sampleNetwork(now) })
+	if r.hasGPU {
+		r.collect(ctx, stats, SubsystemGPU, r.sampleGPUs)
+	}
+
+	// Processes are matched against the memory total and GPU users read
+	// above, captured now as the sample may outlive this call
+	memTotal, gpuPids := stats.Memory.Total, gpuUsageByPid(stats.GPUs)
+	r.collect(ctx, stats, SubsystemProcesses, func() func(*SystemStats) {
+		return r.sampleProcesses(now, memTotal, gpuPids)
+	})
+
+	stats.GPUs = nameGPUProcesses(stats.GPUs, stats.Processes)
 
-	// CPU
-	cpuPercent, err := cpu.Percent(0, true)
+	// Per-process power, from the CPU and GPU shares just collected
+	r.energy.attribute(stats, now)
+
+	return stats, nil
+}
+
+// collect samples one subsystem into stats, marking it stale when its
+// collector misses the deadline.
+func (r *RealProvider) collect(ctx context.Context, stats *SystemStats, sub Subsystem, sample func() func(*SystemStats)) {
+	if r.collectors[sub].collect(ctx, stats.Timestamp, r.CollectorTimeout, stats, sample) {
+		stats.markStale(sub)
+	}
+}
+
+func (r *RealProvider) sampleCPU(now time.Time) func(*SystemStats) {
+	var c CPUStats
+
+	// Per-mode breakdown from /proc/stat deltas
+	if total, err := cpu.Times(false); err == nil && len(total) > 0 {
+		c.Times = cpuTimesPercent(r.lastCPUTotal, total[0])
+		c.GlobalUsagePercent = c.Times.Busy()
+		r.lastCPUTotal = total[0]
+	}
+	cpuTimes, err := cpu.Times(true)
 	if err == nil {
-		stats.CPU.PerCoreUsage = cpuPercent
-		stats.CPU.GlobalUsagePercent = 0
-		for _, p := range cpuPercent {
-			stats.CPU.GlobalUsagePercent += p
+		cpuPercent := make([]float64, len(cpuTimes))
+		c.PerCoreTimes = make([]CPUTimes, len(cpuTimes))
+		for i, t := range cpuTimes {
+			var prev cpu.TimesStat
+			if i < len(r.lastCPUTimes) {
+				prev = r.lastCPUTimes[i]
+			}
+			c.PerCoreTimes[i] = cpuTimesPercent(prev, t)
+			cpuPercent[i] = c.PerCoreTimes[i].Busy()
+		}
+		c.PerCoreUsage = cpuPercent
+		r.lastCPUTimes = cpuTimes
+
+		// Sensor and topology discovery needs the CPU count, so it happens
+		// on first use
+		if !r.cpuProbed {
+			r.cpuTemps = discoverCPUTemps(r.SysfsRoot, len(cpuPercent))
+			r.coreTypes = readCoreTypes(r.SysfsRoot, len(cpuPercent))
+			r.cpuProbed = true
 		}
-		if len(cpuPercent) > 0 {
-			stats.CPU.GlobalUsagePercent /= float64(len(cpuPercent))
+		if r.cpuTemps != nil {
+			c.PerCoreTemp, c.PackageTemp = r.cpuTemps.read()
 		}
+		c.PerCoreFreq = readCoreFreqs(r.SysfsRoot, len(cpuPercent))
+		c.PerCoreType = r.coreTypes
 	}
 
+	c.Power = r.rapl.sample(now)
+
 	// Load Average
 	if avg, err := load.Avg(); err == nil {
-		stats.CPU.LoadAvg = [3]float64{avg.Load1, avg.Load5, avg.Load15}
+		c.LoadAvg = [3]float64{avg.Load1, avg.Load5, avg.Load15}
 	}
 
-	// Memory & Swap
-	vm, err := mem.VirtualMemory()
-	if err == nil {
-		stats.Memory.Total = vm.Total
-		stats.Memory.Used = vm.Used
-		stats.Memory.Free = vm.Free
-		stats.Memory.UsedPercent = vm.UsedPercent
-	}
-	sw, err := mem.SwapMemory()
-	if err == nil {
-		stats.Memory.SwapTotal = sw.Total
-		stats.Memory.SwapUsed = sw.Used
-		stats.Memory.SwapPercent = sw.UsedPercent
-	}
+	// Pressure stall information
+	pressure := readSystemPressure(r.ProcRoot)
 
-	// Disk
-	ioCounters, err := disk.IOCounters()
-	if err == nil {
-		for _, v := range ioCounters {
-			stats.Disk.ReadBytes += v.ReadBytes
-			stats.Disk.WriteBytes += v.WriteBytes
-		}
-	}
+	// Scheduler and paging activity
+	kernel, _ := r.kernel.sample(now)
 
-	// Calculate Disk Speed
-	if r.lastDiskRead > 0 {
-		stats.Disk.ReadSpeed = uint64(float64(stats.Disk.ReadBytes-r.lastDiskRead) / duration)
-		stats.Disk.WriteSpeed = uint64(float64(stats.Disk.WriteBytes-r.lastDiskWrite) / duration)
+	return func(s *SystemStats) {
+		s.CPU = c
+		s.Pressure = pressure
+		s.Kernel = kernel
 	}
-	r.lastDiskRead = stats.Disk.ReadBytes
-	r.lastDiskWrite = stats.Disk.WriteBytes
-
+}
 
-	// Network
-	netCounters, err := net.IOCounters(false)
-	if err == nil && len(netCounters) > 0 {
-		stats.Net.BytesSent = netCounters[0].BytesSent
-		stats.Net.BytesRecv = netCounters[0].BytesRecv
+// sampleSensors reads the hardware sensors and batteries.
+func (r *RealProvider) sampleSensors() func(*SystemStats) {
+	sensors := r.sensors.read()
+	power := readPowerSupplies(r.SysfsRoot)
+	return func(s *SystemStats) {
+		s.Sensors = sensors
+		s.PowerSupply = power
 	}
+}
 
-	// Calculate speeds
-	now := time.Now()
-	if !r.lastTime.IsZero() {
-		duration := now.Sub(r.lastTime).Seconds()
-		if duration > 0 {
-			if stats.Disk.ReadBytes >= r.lastDisk.ReadBytes {
-				stats.Disk.ReadSpeed = uint64(float64(stats.Disk.ReadBytes-r.lastDisk.ReadBytes) / duration)
-			}
-			if stats.Disk.WriteBytes >= r.lastDisk.WriteBytes {
-				stats.Disk.WriteSpeed = uint64(float64(stats.Disk.WriteBytes-r.lastDisk.WriteBytes) / duration)
-			}
-			if stats.Net.BytesSent >= r.lastNet.BytesSent {
-				stats.Net.UploadSpeed = uint64(float64(stats.Net.BytesSent-r.lastNet.BytesSent) / duration)
-			}
-			if stats.Net.BytesRecv >= r.lastNet.BytesRecv {
-				stats.Net.DownloadSpeed = uint64(float64(stats.Net.BytesRecv-r.lastNet.BytesRecv) / duration)
-			}
-		}
+// sampleMemory reads memory and swap, including zram.
+func (r *RealProvider) sampleMemory() func(*SystemStats) {
+	m, err := readMemInfo(r.ProcRoot)
+	if err != nil {
+		return func(*SystemStats) {}
 	}
-	r.lastTime = now
-	r.lastDisk = stats.Disk
-	r.lastNet = stats.Net
+	m.ZramStored, m.ZramUsed = readZram(r.SysfsRoot)
+	return func(s *SystemStats) { s.Memory = m }
+}
 
-	// GPU (if available)
-	gpuPids := make(map[uint32]bool)
-	if r.hasGPU {
-		count, err := gonvml.DeviceCount()
-		if err == nil && count > 0 {
-			dev, err := gonvml.DeviceHandleByIndex(0)
-			if err == nil {
-				stats.GPU.Available = true
-				name, _ := dev.Name()
-				stats.GPU.Name = name
-				util, memUtil, _ := dev.UtilizationRates()
-				stats.GPU.Utilization = uint32(util)
-				stats.GPU.MemoryUtil = uint32(memUtil)
-				total, used, _ := dev.MemoryInfo()
-				stats.GPU.MemoryTotal = total
-				stats.GPU.MemoryUsed = used
-				temp, _ := dev.Temperature()
-				stats.GPU.Temperature = uint32(temp)
-				fan, _ := dev.FanSpeed()
-				stats.GPU.FanSpeed = uint32(fan)
-				power, _ := dev.PowerUsage()
-				stats.GPU.PowerUsage = uint32(power)
-
-				// Update GPU history
-				r.gpuHistory = append(r.gpuHistory, float64(util))
-				if len(r.gpuHistory) > 100 {
-					r.gpuHistory = r.gpuHistory[1:]
-				}
-				stats.GPU.HistoricalUtil = make([]float64, len(r.gpuHistory))
-				copy(stats.GPU.HistoricalUtil, r.gpuHistory)
-
-				// NOTE: mindprince/gonvml does not support process lists or power limits.
-				// Leaving stats.GPU.Processes empty for RealProvider.
-			}
+// sampleDisks reads per-device rates, with totals over whole physical disks
+// only.
+func (r *RealProvider) sampleDisks(now time.Time) func(*SystemStats) {
+	devices, err := r.disks.sample(now)
+	if err != nil {
+		return func(*SystemStats) {}
+	}
+	disk := DiskStats{Devices: devices}
+	for _, d := range devices {
+		if d.Kind != DiskKindDisk {
+			continue
 		}
+		disk.ReadBytes += d.ReadBytes
+		disk.WriteBytes += d.WriteBytes
+		disk.ReadSpeed += d.ReadSpeed
+		disk.WriteSpeed += d.WriteSpeed
 	}
+	return func(s *SystemStats) { s.Disk = disk }
+}
 
-	// Processes
-	pids, err := process.Pids()
-	if err == nil {
-		// New cache for next iteration to clean up old processes
-		newCache := make(map[int32]*process.Process)
-
-		count := 0
-		limit := 200 // Limit for MVP performance
-
-		for _, pid := range pids {
-			if count >= limit {
-				break
-			}
+func (r *RealProvider) sampleFilesystems(now time.Time) func(*SystemStats) {
+	filesystems, err := r.filesystems.sample(now)
+	if err != nil {
+		return func(*SystemStats) {}
+	}
+	return func(s *SystemStats) { s.Filesystems = filesystems }
+}
 
-			// Reuse existing process struct if available
-			var p *process.Process
-			if existing, ok := r.procCache[pid]; ok {
-				p = existing
-			} else {
-				p, err = process.NewProcess(pid)
-				if err != nil {
-					continue
-				}
-			}
-			newCache[pid] = p
-
-			// Gather metrics
-			name, _ := p.Name()
-			user, _ := p.Username()
-			cpuP, _ := p.Percent(0) // This now works correctly with cached process
-			memP, _ := p.MemoryPercent()
-			memInfo, _ := p.MemoryInfo()
-			rss := uint64(0)
-			if memInfo != nil {
-				rss = memInfo.RSS
-			}
+// sampleNetwork reads per-interface rates, with totals over the selected
+// interfaces.
+func (r *RealProvider) sampleNetwork(now time.Time) func(*SystemStats) {
+	net, err := r.net.sample(now)
+	if err != nil {
+		return func(*SystemStats) {}
+	}
+	return func(s *SystemStats) { s.Net = net }
+}
 
-			// Detailed info
-			ppid, _ := p.Ppid()
-			threads, _ := p.NumThreads()
-			nice, _ := p.Nice()
-			state, _ := p.Status()
+func (r *RealProvider) sampleGPUs() func(*SystemStats) {
+	gpus := r.collectGPUs()
+	return func(s *SystemStats) { s.GPUs = gpus }
+}
 
-			// Handle state slice if it returns multiple characters
-			stateStr := "U"
-			if len(state) > 0 {
-				stateStr = state[0]
-			}
+// sampleProcesses reads every process. The UI sorts and truncates the
+// list, so the busiest ones are never dropped by PID order.
+func (r *RealProvider) sampleProcesses(now time.Time, memTotal uint64, gpuPids map[uint32]gpuUsage) func(*SystemStats) {
+	procs, err := r.procs.scan(now, memTotal)
+	if err != nil {
+		return func(*SystemStats) {}
+	}
+	cgroups := newCgroupPressure(r.SysfsRoot)
+	for i := range procs {
+		p := &procs[i]
+		gpuUse, isGpu := gpuPids[uint32(p.PID)]
+		p.IsGPUUser = isGpu
+		p.GPUMemory = gpuUse.memory
+		p.GPUUtil = gpuUse.util
+		if p.Cgroup != "" {
+			p.CPUStall, p.MemStall = cgroups.get(p.Cgroup)
+		}
+	}
+	// Each sample gets its own copy, as energy attribution writes to it
+	return func(s *SystemStats) { s.Processes = append([]ProcessInfo(nil), procs...) }
+}
 
-			// Check if using GPU
-			isGpu := false
-			if gpuPids[uint32(p.Pid)] {
-				isGpu = true
+// nameGPUProcesses resolves GPU process names from the system process list.
+// The per-device lists are copied rather than updated in place, since a
+// stale GPU sample shares them with earlier samples.
+func nameGPUProcesses(gpus []GPUStats, procs []ProcessInfo) []GPUStats {
+	if len(gpus) == 0 {
+		return gpus
+	}
+	names := make(map[int32]string, len(procs))
+	for _, p := range procs {
+		names[p.PID] = p.Command
+	}
+	named := make([]GPUStats, len(gpus))
+	for g, gpu := range gpus {
+		gpu.Processes = append([]GPUProcess(nil), gpu.Processes...)
+		for i := range gpu.Processes {
+			if name, ok := names[int32(gpu.Processes[i].PID)]; ok {
+				gpu.Processes[i].Name = name
 			}
-
-			stats.Processes = append(stats.Processes, ProcessInfo{
-				PID:        p.Pid,
-				User:       user,
-				Command:    name,
-				State:      stateStr,
-				CPUPercent: cpuP,
-				MemPercent: float64(memP),
-				Memory:     rss,
-				Threads:    threads,
-				Priority:   nice,
-				ParentPID:  ppid,
-				IsGPUUser:  isGpu,
-			})
-			count++
 		}
+		named[g] = gpu
+	}
+	return named
+}
 
-		// Update cache
-		r.procCache = newCache
+// collectGPUs samples every device exposed by the GPU backend. A device whose
+// handle cannot be opened is reported as unavailable so indices stay stable.
+func (r *RealProvider) collectGPUs() []GPUStats {
+	count, err := r.GPUBackend.DeviceCount()
+	if err != nil {
+		return nil
 	}
 
-	// Resolve GPU Process Names from System Process List
-	if len(stats.GPU.Processes) > 0 {
-		pidMap := make(map[int32]string)
-		for _, p := range stats.Processes {
-			pidMap[p.PID] = p.Command
-		}
-		for i := range stats.GPU.Processes {
-			if name, ok := pidMap[int32(stats.GPU.Processes[i].PID)]; ok {
-				stats.GPU.Processes[i].Name = name
-			}
+	gpus := make([]GPUStats, 0, count)
+	for i := 0; i < count; i++ {
+		dev, err := r.GPUBackend.Device(i)
+		if err != nil {
+			gpus = append(gpus, GPUStats{Index: i})
+			continue
 		}
+		gpu := collectGPU(i, dev)
+		gpu.HistoricalUtil = r.recordGPUHistory(i, float64(gpu.Utilization))
+		gpus = append(gpus, gpu)
 	}
+	return gpus
+}
 
-	return stats, nil
+// recordGPUHistory appends a utilization sample to the device's history and
+// returns a copy that is safe to hand to the UI.
+func (r *RealProvider) recordGPUHistory(index int, util float64) []float64 {
+	history := append(r.gpuHistory[index], util)
+	if len(history) > 100 {
+		history = history[1:]
+	}
+	r.gpuHistory[index] = history
+
+	out := make([]float64, len(history))
+	copy(out, history)
+	return out
 }
 
+// shutdownTimeout bounds how long Shutdown waits for samples that missed
+// their deadline and are still running.
+const shutdownTimeout = 2 * time.Second
+
+// Shutdown waits for running samples and releases the GPU backend. A GPU
+// sample that is still stuck in the driver keeps the backend alive, since
+// tearing it down under the sample (NVML shutdown) may crash it.
 func (r *RealProvider) Shutdown() {
-	if r.hasGPU {
-		gonvml.Shutdown()
+	deadline := time.Now().Add(shutdownTimeout)
+	gpuIdle := true
+	for sub, c := range r.collectors {
+		if !c.wait(deadline) && sub == SubsystemGPU {
+			gpuIdle = false
+		}
+	}
+	if !r.hasGPU {
+		return
+	}
+	if !gpuIdle {
+		log.Printf("%s: skipping shutdown, a GPU sample is still running", r.GPUBackend.Name())
+		return
 	}
+	r.GPUBackend.Shutdown()
 }
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readSysfsString returns the trimmed contents of a sysfs/procfs attribute.
+func readSysfsString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSysfsUint parses a sysfs attribute holding a single unsigned integer.
+func readSysfsUint(path string) (uint64, error) {
+	s, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// readSysfsInt parses a sysfs attribute holding a single signed integer.
+func readSysfsInt(path string) (int64, error) {
+	s, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
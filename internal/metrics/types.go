@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"time"
 )
 
@@ -11,20 +12,113 @@ type SystemStats struct {
 	CPU       CPUStats
 	Memory    MemoryStats
 	Disk      DiskStats
-	Net       NetStats
-	GPU       GPUStats
-	Processes []ProcessInfo
+	// Mounted filesystems, ordered by mount point
+	Filesystems []FilesystemStats
+	Net         NetStats
+	GPUs        []GPUStats // One entry per device, ordered by device index
+	Pressure    PressureStats
+	Kernel      KernelStats
+	Sensors     []Sensor // hwmon readings, ordered by chip then input
+	PowerSupply PowerSupplyStats
+	Processes   []ProcessInfo
+	// Subsystems whose collector missed its deadline; their values come
+	// from an earlier sample
+	Stale map[Subsystem]bool
+}
+
+// markStale records that a subsystem's values come from an earlier sample.
+func (s *SystemStats) markStale(sub Subsystem) {
+	if s.Stale == nil {
+		s.Stale = make(map[Subsystem]bool)
+	}
+	s.Stale[sub] = true
 }
 
 // CPUStats holds CPU related metrics.
 type CPUStats struct {
 	GlobalUsagePercent float64
 	PerCoreUsage       []float64  // Percent usage per core
+	Times              CPUTimes   // Time breakdown across all cores
+	PerCoreTimes       []CPUTimes // Time breakdown per core
 	PerCoreTemp        []float64  // Temperature per core (if available)
+	PackageTemp        float64    // Hottest CPU package temperature in Celsius (0 if unknown)
+	PerCoreFreq        []CoreFreq // cpufreq state per core (empty without cpufreq)
+	PerCoreType        []CoreType // Core type per core (empty on non-hybrid CPUs)
 	LoadAvg            [3]float64 // 1, 5, 15 min load average
+	Power              CPUPower   // RAPL power draw
+}
+
+// CPUPower holds RAPL power draw in watts, summed over sockets. Core and
+// uncore (integrated graphics) are part of Package; DRAM is not.
+type CPUPower struct {
+	Available bool
+	Package   float64
+	Core      float64
+	Uncore    float64
+	DRAM      float64
+}
+
+// CPUTimes breaks CPU time down by mode, in percent of the sample interval.
+// Guest time is reported separately rather than as part of User.
+type CPUTimes struct {
+	User    float64
+	Nice    float64
+	System  float64
+	IOWait  float64
+	IRQ     float64
+	SoftIRQ float64
+	Steal   float64
+	Guest   float64
+	Idle    float64
+}
+
+// Busy returns the share of time spent running code. Like top, iowait counts
+// as idle since the CPU was free to run something else.
+func (t CPUTimes) Busy() float64 {
+	return t.User + t.Nice + t.System + t.IRQ + t.SoftIRQ + t.Steal + t.Guest
+}
+
+// CoreType distinguishes performance and efficiency cores on hybrid CPUs.
+type CoreType string
+
+const (
+	CoreTypePerformance CoreType = "P"
+	CoreTypeEfficiency  CoreType = "E"
+)
+
+// CoreFreq holds the cpufreq state of a logical CPU. Frequencies are in MHz.
+type CoreFreq struct {
+	Cur      uint32
+	Min      uint32 // Policy minimum (scaling_min_freq)
+	Max      uint32 // Policy maximum (scaling_max_freq)
+	HWMax    uint32 // Hardware maximum (cpuinfo_max_freq)
+	Governor string
+	EPP      string // Energy-performance preference (empty if unsupported)
+}
+
+// Capped reports whether the policy maximum is held below what the hardware
+// supports, e.g. by a power profile or thermal daemon.
+func (f CoreFreq) Capped() bool {
+	return f.Max > 0 && f.HWMax > 0 && f.Max < f.HWMax
+}
+
+// AverageFreq returns the mean current frequency across cores in MHz.
+func (c CPUStats) AverageFreq() uint32 {
+	var sum, n uint64
+	for _, f := range c.PerCoreFreq {
+		if f.Cur > 0 {
+			sum += uint64(f.Cur)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return uint32(sum / n)
 }
 
-// MemoryStats holds memory related metrics.
+// MemoryStats holds memory related metrics, in bytes. Used excludes
+// buffers, page cache and reclaimable slab, like free(1).
 type MemoryStats struct {
 	Total       uint64
 	Used        uint64
@@ -33,38 +127,224 @@ type MemoryStats struct {
 	SwapTotal   uint64
 	SwapUsed    uint64
 	SwapPercent float64
+
+	Available         uint64 // Estimate of what can be allocated without swapping
+	Buffers           uint64 // Block device metadata cache
+	Cached            uint64 // Page cache, including Shared
+	Shared            uint64 // tmpfs and shared memory (Shmem)
+	Dirty             uint64 // Page cache waiting to be written back
+	Writeback         uint64 // Page cache being written back
+	SlabReclaimable   uint64 // Kernel caches (dentries, inodes) freed under pressure
+	SlabUnreclaimable uint64
+	HugePagesTotal    uint64 // Reserved for hugetlbfs, counted as used
+	HugePagesFree     uint64
+	AnonHugePages     uint64 // Transparent hugepages backing anonymous memory
+	ZswapStored       uint64 // Uncompressed size of the pages held by zswap
+	ZswapPool         uint64 // Memory taken by the zswap pool
+	ZramStored        uint64 // Uncompressed size of the data in zram devices
+	ZramUsed          uint64 // Memory taken by zram devices
 }
 
-// DiskStats holds disk I/O metrics.
+// DiskStats holds disk I/O metrics. Totals only cover whole physical disks,
+// so I/O is not counted again for partitions or stacked devices.
 type DiskStats struct {
-	ReadBytes  uint64 // Total read bytes
-	WriteBytes uint64 // Total write bytes
-	ReadSpeed  uint64 // Bytes per second
-	WriteSpeed uint64 // Bytes per second
+	ReadBytes  uint64       // Total read bytes
+	WriteBytes uint64       // Total write bytes
+	ReadSpeed  uint64       // Bytes per second
+	WriteSpeed uint64       // Bytes per second
+	Devices    []DiskDevice // Every block device, ordered by name
+}
+
+// DiskKind classifies block devices.
+type DiskKind string
+
+const (
+	DiskKindDisk      DiskKind = "disk"
+	DiskKindPartition DiskKind = "part"
+	DiskKindVirtual   DiskKind = "virtual" // device-mapper, md, loop, zram, ...
+)
+
+// DiskDevice holds I/O metrics for a single block device.
+type DiskDevice struct {
+	Name       string // Kernel name, e.g. "nvme0n1"
+	Label      string // device-mapper name, e.g. "vg0-root" (empty otherwise)
+	Kind       DiskKind
+	ReadBytes  uint64    // Total read bytes
+	WriteBytes uint64    // Total write bytes
+	ReadSpeed  uint64    // Bytes per second
+	WriteSpeed uint64    // Bytes per second
+	ReadIOPS   float64   // Completed reads per second
+	WriteIOPS  float64   // Completed writes per second
+	Await      float64   // Average time per completed request in ms
+	InFlight   uint64    // Requests currently in flight
+	Util       float64   // Percent of time the device was busy
+	MaxSpeed   uint64    // Bytes per second the device's link allows (0 if unknown)
+	History    []float64 // Throughput (read+write bytes per second) history
+}
+
+// FilesystemStats holds capacity metrics for a mounted filesystem.
+type FilesystemStats struct {
+	Mount         string
+	Device        string // Mount source, e.g. "/dev/nvme0n1p2"
+	FSType        string
+	ReadOnly      bool
+	Total         uint64        // Size in bytes
+	Used          uint64        // Used bytes
+	Free          uint64        // Bytes available to unprivileged users
+	UsedPercent   float64       // Used share of the space usable by unprivileged users, like df
+	Inodes        uint64        // Total inodes (0 if the filesystem has no fixed inode table)
+	InodesUsed    uint64        // Used inodes
+	InodesPercent float64       // Used inodes in percent
+	GrowthRate    float64       // Recent change of Used in bytes per second
+	TimeToFull    time.Duration // Time until full at GrowthRate (0 if not growing)
 }
 
-// NetStats holds network I/O metrics.
+// NetStats holds network I/O metrics. Totals cover the interfaces marked
+// Aggregated.
 type NetStats struct {
-	BytesSent     uint64 // Total bytes sent
-	BytesRecv     uint64 // Total bytes received
-	UploadSpeed   uint64 // Bytes per second
-	DownloadSpeed uint64 // Bytes per second
+	BytesSent     uint64         // Total bytes sent
+	BytesRecv     uint64         // Total bytes received
+	UploadSpeed   uint64         // Bytes per second
+	DownloadSpeed uint64         // Bytes per second
+	Interfaces    []NetInterface // Every interface, ordered by name
+}
+
+// NetInterface holds metrics for a single network interface.
+type NetInterface struct {
+	Name            string
+	OperState       string // "up", "down", "dormant", "unknown", ...
+	Speed           uint64 // Link speed in Mbit/s (0 if unknown)
+	Loopback        bool
+	Virtual         bool      // No backing device: bridge, veth, tunnel, ...
+	Aggregated      bool      // Counted in the NetStats totals
+	BytesSent       uint64    // Total bytes sent
+	BytesRecv       uint64    // Total bytes received
+	PacketsSent     uint64    // Total packets sent
+	PacketsRecv     uint64    // Total packets received
+	ErrorsIn        uint64    // Total receive errors
+	ErrorsOut       uint64    // Total transmit errors
+	DropsIn         uint64    // Total dropped incoming packets
+	DropsOut        uint64    // Total dropped outgoing packets
+	UploadSpeed     uint64    // Bytes per second
+	DownloadSpeed   uint64    // Bytes per second
+	PacketsSentRate float64   // Packets per second
+	PacketsRecvRate float64   // Packets per second
+	History         []float64 // Throughput (received+sent bytes per second) history
+}
+
+// KernelStats holds scheduler and virtual memory activity from /proc/stat
+// and /proc/vmstat. Rates are per second and zero on the first sample.
+type KernelStats struct {
+	ContextSwitches float64
+	Interrupts      float64
+	SoftIRQs        float64
+	Forks           float64 // Processes and threads created
+	ProcsRunning    uint64  // Runnable tasks right now
+	ProcsBlocked    uint64  // Tasks waiting for I/O right now
+	MinorFaults     float64 // Page faults served without I/O
+	MajorFaults     float64 // Page faults that had to read from disk
+	SwapIn          float64 // Bytes per second read back from swap
+	SwapOut         float64 // Bytes per second written to swap
+	PageIn          float64 // Bytes per second read from block devices
+	PageOut         float64 // Bytes per second written to block devices
+}
+
+// SensorKind is the quantity a hardware sensor measures.
+type SensorKind string
+
+const (
+	SensorTemp    SensorKind = "temp"  // Degrees Celsius
+	SensorFan     SensorKind = "fan"   // RPM
+	SensorVoltage SensorKind = "in"    // Volts
+	SensorCurrent SensorKind = "curr"  // Amperes
+	SensorPower   SensorKind = "power" // Watts
+)
+
+// Sensor is one hwmon input with its limits, in the unit of its kind.
+// Limits the chip does not report are 0.
+type Sensor struct {
+	ID    string // Stable "chip/input" key, e.g. "nvme-nvme0/temp1"
+	Chip  string // Chip name and device, e.g. "nvme-nvme0" or "acpitz"
+	Label string // From the chip's *_label, or the input name
+	Kind  SensorKind
+	Value float64
+	Min   float64
+	Max   float64
+	Crit  float64
+}
+
+// PowerSupplyStats holds the AC adapter state and the system batteries.
+type PowerSupplyStats struct {
+	OnAC      bool      // A mains or USB supply is online
+	Batteries []Battery // Ordered by name; empty on desktops
+}
+
+// Battery holds the state of one system battery, in watts and watt-hours.
+type Battery struct {
+	Name         string // e.g. "BAT0"
+	Status       string // "Charging", "Discharging", "Full", "Not charging" or "Unknown"
+	Percent      float64
+	Power        float64 // Charge or discharge rate, always positive
+	Energy       float64 // Remaining
+	EnergyFull   float64 // Capacity when last fully charged
+	EnergyDesign float64 // Capacity when new
+	Health       float64 // EnergyFull as a percentage of EnergyDesign (0 if unknown)
+	CycleCount   int     // 0 if unknown
+	TimeToEmpty  time.Duration
+	TimeToFull   time.Duration
+}
+
+// PressureStats holds host-wide Pressure Stall Information (Linux 4.20+).
+type PressureStats struct {
+	CPU    Pressure
+	Memory Pressure
+	IO     Pressure
+	IRQ    Pressure // Only "full" is reported for IRQ
+}
+
+// Pressure holds the PSI lines of one resource. "some" is the share of time
+// at least one task was stalled on the resource, "full" the share of time all
+// non-idle tasks were.
+type Pressure struct {
+	Available bool // False if the kernel does not expose this resource
+	Some      PressureLine
+	Full      PressureLine
+}
+
+// PressureLine holds stall averages in percent over 10s, 60s and 300s
+// windows, plus the cumulative stall time.
+type PressureLine struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64 // Microseconds
 }
 
-// GPUStats holds NVIDIA GPU metrics.
+// GPUStats holds GPU metrics for a single device.
 type GPUStats struct {
 	Available      bool // True if GPU is present and accessible
+	Index          int  // Device index as reported by the driver
 	Name           string
-	Utilization    uint32 // GPU Utilization in percent
-	MemoryTotal    uint64 // Total VRAM in bytes
-	MemoryUsed     uint64 // Used VRAM in bytes
-	MemoryUtil     uint32 // Memory utilization in percent
-	Temperature    uint32 // GPU Temperature in Celsius
-	FanSpeed       uint32 // Fan speed in percent
-	GraphicsClock  uint32 // Graphics clock in MHz
-	MemoryClock    uint32 // Memory clock in MHz
-	PowerUsage     uint32 // Power usage in milliwatts
-	PowerLimit     uint32 // Power limit in milliwatts
+	Utilization    uint32            // GPU Utilization in percent
+	MemoryTotal    uint64            // Total VRAM in bytes
+	MemoryUsed     uint64            // Used VRAM in bytes
+	MemoryUtil     uint32            // Memory utilization in percent
+	Temperature    uint32            // GPU Temperature in Celsius
+	FanSpeed       uint32            // Fan speed in percent
+	GraphicsClock  uint32            // Graphics clock in MHz
+	MemoryClock    uint32            // Memory clock in MHz
+	RequestedClock uint32            // Graphics clock requested by the driver in MHz (0 if unknown)
+	PowerUsage     uint32            // Power usage in milliwatts
+	PowerLimit     uint32            // Power limit in milliwatts
+	PState         string            // Performance state, e.g. "P0" (empty if unknown)
+	Throttle       []string          // Active clock throttle reasons (see Throttle* constants)
+	PCIeRxSpeed    uint64            // PCIe receive throughput in bytes per second
+	PCIeTxSpeed    uint64            // PCIe transmit throughput in bytes per second
+	EncoderUtil    uint32            // Video encoder (NVENC) utilization in percent
+	DecoderUtil    uint32            // Video decoder (NVDEC) utilization in percent
+	Engines        map[string]uint32 // Per engine-class utilization in percent (Intel)
+	ECCCorrected   uint64            // Volatile corrected ECC errors since driver load
+	ECCUncorrected uint64            // Volatile uncorrected ECC errors since driver load
 	Processes      []GPUProcess
 	HistoricalUtil []float64 // Last N data points for the big graph
 }
@@ -73,7 +353,12 @@ type GPUStats struct {
 type GPUProcess struct {
 	PID        uint32
 	Name       string
-	MemoryUsed uint64
+	Type       string // "C" (compute), "G" (graphics) or "C+G"
+	MemoryUsed uint64 // VRAM in bytes
+	SMUtil     uint32 // Streaming multiprocessor utilization in percent
+	MemUtil    uint32 // Memory controller utilization in percent
+	EncUtil    uint32 // Encoder utilization in percent
+	DecUtil    uint32 // Decoder utilization in percent
 }
 
 // ProcessInfo represents a system process.
@@ -88,12 +373,22 @@ type ProcessInfo struct {
 	Threads    int32
 	Priority   int32 // Nice value
 	ParentPID  int32
-	IsGPUUser  bool // True if this process is using the GPU
+	StartTime  uint64   // Clock ticks after boot; with PID, tells a reused PID apart
+	IsGPUUser  bool     // True if this process is using the GPU
+	GPUMemory  uint64   // VRAM used across all GPUs in bytes
+	GPUUtil    uint32   // Highest per-device SM utilization in percent
+	Cgroup     string   // cgroup v2 path (empty on cgroup v1 hosts)
+	CPUStall   Pressure // CPU pressure of the process's cgroup
+	MemStall   Pressure // Memory pressure of the process's cgroup
+	Power      float64  // Estimated CPU and GPU power draw in watts
+	Energy     float64  // Estimated joules drawn since the process was first seen
 }
 
 // Provider defines the interface for fetching system metrics.
 type Provider interface {
 	Init() error
-	GetStats() (*SystemStats, error)
+	// GetStats returns a new sample, which the caller owns. Calls must not
+	// overlap. Cancelling ctx abandons collectors still running.
+	GetStats(ctx context.Context) (*SystemStats, error)
 	Shutdown()
 }
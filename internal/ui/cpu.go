@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,6 +16,7 @@ type CPUModel struct {
 	height int
 	stats  metrics.SystemStats // Holds all for summary
 	Alert  bool
+	Stale  bool // Values come from an earlier sample
 }
 
 func NewCPUModel() CPUModel {
@@ -56,15 +59,45 @@ func (m CPUModel) View() string {
 	uptimeStr := fmt.Sprintf("Up: %dd %02dh %02dm", days, hours, mins)
 
 	// CPU Header
+	cpuTitle := fmt.Sprintf("CPU: %.1f%%", m.stats.CPU.GlobalUsagePercent)
+	if m.stats.CPU.PackageTemp > 0 {
+		cpuTitle += fmt.Sprintf(" %.0f°C", m.stats.CPU.PackageTemp)
+	}
+	if avg := m.stats.CPU.AverageFreq(); avg > 0 {
+		cpuTitle += fmt.Sprintf(" %.2fGHz", float64(avg)/1000)
+	}
+	if power := m.stats.CPU.Power; power.Available {
+		cpuTitle += fmt.Sprintf(" %.0fW", power.Package)
+	}
+	cpuTitle = staleTitle(cpuTitle, m.Stale)
 	cpuHeader := lipgloss.JoinHorizontal(lipgloss.Left,
-		TitleStyle.Render(fmt.Sprintf("CPU: %.1f%%", m.stats.CPU.GlobalUsagePercent)),
-		lipgloss.PlaceHorizontal(m.width-20-len(uptimeStr), lipgloss.Right, " "),
+		TitleStyle.Render(cpuTitle),
+		lipgloss.PlaceHorizontal(m.width-lipgloss.Width(cpuTitle)-10-len(uptimeStr), lipgloss.Right, " "),
 		MetricLabelStyle.Render(uptimeStr),
 	)
 
 	// Load Average
 	loadStr := fmt.Sprintf("Load: %.2f %.2f %.2f", m.stats.CPU.LoadAvg[0], m.stats.CPU.LoadAvg[1], m.stats.CPU.LoadAvg[2])
+	if gov := cpuGovernor(m.stats.CPU.PerCoreFreq); gov != "" {
+		loadStr += "  Gov: " + gov
+	}
 	load := MetricLabelStyle.Render(loadStr)
+	limits := MetricLabelStyle.Render("Clk limits: N/A")
+	if l := cpuFreqLimits(m.stats.CPU.PerCoreFreq); l != "" {
+		limits = MetricLabelStyle.Render("Clk ") + l
+	}
+
+	// Pressure stall information: CPU "some" over 10s/60s/300s, and IRQ
+	// which the kernel only reports as "full"
+	pressureStr := "PSI: N/A"
+	if psi := m.stats.Pressure; psi.CPU.Available {
+		pressureStr = fmt.Sprintf("PSI cpu %.1f %.1f %.1f", psi.CPU.Some.Avg10, psi.CPU.Some.Avg60, psi.CPU.Some.Avg300)
+		if psi.IRQ.Available {
+			pressureStr += fmt.Sprintf("  irq %.1f", psi.IRQ.Full.Avg10)
+		}
+	}
+	pressure := MetricLabelStyle.Render(pressureStr)
+	power := MetricLabelStyle.Render(formatCPUPower(m.stats.CPU.Power))
 
 	// Calculate space for Cores
 	// We need space for Memory and GPU summary at bottom?
@@ -86,25 +119,41 @@ func (m CPUModel) View() string {
 	// Requirement: Per-core bars, load averages, quick GPU summary.
 
 	// Cores
-	availHeight := m.height - 8 // Reserve for header, load, gpu summary
+	availHeight := m.height - 15 - len(m.stats.GPUs) // Reserve for header, breakdown, load, clock limits, PSI, power, kernel, gpu summary
 	if availHeight < 5 {
 		availHeight = 5
 	}
 
-	cores := renderCores(m.stats.CPU.PerCoreUsage, m.stats.CPU.PerCoreTemp, m.width-4, availHeight)
+	cores := renderCores(m.stats.CPU, m.width-4, availHeight)
 
-	// GPU Summary Mini-Graph
-	gpuSummary := ""
-	if m.stats.GPU.Available {
-		gpuSummary = renderBar(int(m.stats.GPU.Utilization), 100, m.width-4, fmt.Sprintf("GPU %d%%", m.stats.GPU.Utilization))
-	} else {
-		gpuSummary = MetricLabelStyle.Render("GPU: N/A")
+	// GPU Summary Mini-Graph (one bar per card)
+	var gpuLines []string
+	for _, gpu := range m.stats.GPUs {
+		if !gpu.Available {
+			continue
+		}
+		gpuLines = append(gpuLines, renderBar(int(gpu.Utilization), 100, m.width-4, fmt.Sprintf("GPU%d %3d%% %3d°C", gpu.Index, gpu.Utilization, gpu.Temperature)))
+	}
+	gpuSummary := MetricLabelStyle.Render("GPU: N/A")
+	if len(gpuLines) > 0 {
+		gpuSummary = lipgloss.JoinVertical(lipgloss.Left, gpuLines...)
 	}
 
 	// Combine
+	// Host-wide time breakdown
+	breakdown := lipgloss.JoinVertical(lipgloss.Left,
+		renderBarCompact(cpuSegments(m.stats.CPU.Times), m.width-4, "All"),
+		renderCPULegend(m.stats.CPU.Times),
+	)
+
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		cpuHeader,
+		breakdown,
 		load,
+		limits,
+		pressure,
+		power,
+		renderKernelActivity(m.stats.Kernel),
 		"\n",
 		cores,
 		"\n",
@@ -115,28 +164,133 @@ func (m CPUModel) View() string {
 	return style.Render(content)
 }
 
-func renderCores(usage []float64, temps []float64, width, height int) string {
+// formatCPUPower renders the RAPL domains, leaving out those the CPU does
+// not have, e.g. "Power pkg 45.2W core 30.1W dram 3.4W".
+func formatCPUPower(p metrics.CPUPower) string {
+	if !p.Available {
+		return "Power: N/A"
+	}
+	s := fmt.Sprintf("Power pkg %.1fW", p.Package)
+	for _, d := range []struct {
+		name  string
+		watts float64
+	}{{"core", p.Core}, {"uncore", p.Uncore}, {"dram", p.DRAM}} {
+		if d.watts > 0 {
+			s += fmt.Sprintf(" %s %.1fW", d.name, d.watts)
+		}
+	}
+	return s
+}
+
+// Kernel activity rates above which a counter is highlighted: a fork storm,
+// swap thrashing, or a working set that no longer fits in the page cache.
+const (
+	forkStormRate   = 500     // Forks per second
+	swapThrashRate  = 1 << 20 // Bytes per second swapped in and out
+	majorFaultStorm = 100     // Major faults per second
+)
+
+// renderKernelActivity draws scheduler, task and paging rates per second on
+// three lines, with abnormal counters in the alert colour.
+func renderKernelActivity(k metrics.KernelStats) string {
+	label := func(s string, alert bool) string {
+		if alert {
+			return AlertStyle.Render(s)
+		}
+		return MetricLabelStyle.Render(s)
+	}
+	sched := strings.Join([]string{
+		label("Sched cs "+formatCount(k.ContextSwitches), false),
+		label("irq "+formatCount(k.Interrupts+k.SoftIRQs), false),
+		label("fork "+formatCount(k.Forks), k.Forks > forkStormRate),
+	}, " ")
+	tasks := strings.Join([]string{
+		label(fmt.Sprintf("Tasks run %d blk %d", k.ProcsRunning, k.ProcsBlocked), false),
+		label("flt "+formatCount(k.MinorFaults), false),
+		label("maj "+formatCount(k.MajorFaults), k.MajorFaults > majorFaultStorm),
+	}, " ")
+	paging := strings.Join([]string{
+		label(fmt.Sprintf("Swap ↓%s ↑%s", formatBytesShort(uint64(k.SwapIn)), formatBytesShort(uint64(k.SwapOut))), k.SwapIn+k.SwapOut > swapThrashRate),
+		label(fmt.Sprintf("Page ↓%s ↑%s", formatBytesShort(uint64(k.PageIn)), formatBytesShort(uint64(k.PageOut))), false),
+	}, " ")
+	return lipgloss.JoinVertical(lipgloss.Left, sched, tasks, paging)
+}
+
+// formatCount renders a per-second rate compactly, e.g. "950" or "12k".
+func formatCount(v float64) string {
+	switch {
+	case v < 1000:
+		return fmt.Sprintf("%.0f", v)
+	case v < 10000:
+		return fmt.Sprintf("%.1fk", v/1000)
+	case v < 1000000:
+		return fmt.Sprintf("%.0fk", v/1000)
+	default:
+		return fmt.Sprintf("%.1fM", v/1000000)
+	}
+}
+
+// renderCores draws a compact bar per core with its temperature and clock.
+// On hybrid CPUs P-cores and E-cores are drawn as separate, colour-coded
+// groups.
+func renderCores(cpu metrics.CPUStats, width, height int) string {
+	usage := cpu.PerCoreUsage
 	if len(usage) == 0 {
 		return "No CPU Data"
 	}
 
+	if len(cpu.PerCoreType) != len(usage) {
+		all := make([]int, len(usage))
+		for i := range all {
+			all[i] = i
+		}
+		return renderCoreGrid(cpu, all, width, height, MetricLabelStyle)
+	}
+
+	var pCores, eCores []int
+	for i, t := range cpu.PerCoreType {
+		if t == metrics.CoreTypeEfficiency {
+			eCores = append(eCores, i)
+		} else {
+			pCores = append(pCores, i)
+		}
+	}
+
+	// Split rows between the groups in proportion to their size, keeping a
+	// line for each heading
+	rows := height - 2
+	pRows := rows * len(pCores) / len(usage)
+	if pRows < 1 {
+		pRows = 1
+	}
+	eRows := rows - pRows
+	if eRows < 1 {
+		eRows = 1
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		PCoreStyle.Render(fmt.Sprintf("P-cores (%d)", len(pCores))),
+		renderCoreGrid(cpu, pCores, width, pRows, PCoreStyle),
+		ECoreStyle.Render(fmt.Sprintf("E-cores (%d)", len(eCores))),
+		renderCoreGrid(cpu, eCores, width, eRows, ECoreStyle),
+	)
+}
+
+// renderCoreGrid lays out the given cores in as many columns as fit.
+func renderCoreGrid(cpu metrics.CPUStats, cores []int, width, height int, labelStyle lipgloss.Style) string {
 	// Dynamic columns based on width and count
-	// Target roughly 20 chars per column?
 	colWidth := 20
+	if len(cpu.PerCoreFreq) > 0 {
+		colWidth += 5 // " 4.8G"
+	}
 	numCols := width / colWidth
 	if numCols < 1 {
 		numCols = 1
 	}
 
-	// Ensure we don't exceed height too much
-	// Rows needed = ceil(count / cols)
-	// If rows > height, increase cols if possible?
-	// Or scroll? TUI usually doesn't scroll automatically without viewport.
-	// Let's stick to simple grid.
-
 	var sb strings.Builder
 
-	rows := (len(usage) + numCols - 1) / numCols
+	rows := (len(cores) + numCols - 1) / numCols
 
 	for r := 0; r < rows; r++ {
 		if r >= height {
@@ -146,49 +300,207 @@ func renderCores(usage []float64, temps []float64, width, height int) string {
 
 		rowStr := ""
 		for c := 0; c < numCols; c++ {
-			idx := r*numCols + c
-			if idx >= len(usage) {
+			i := r*numCols + c
+			if i >= len(cores) {
 				break
 			}
+			idx := cores[i]
 
 			// Render individual core bar
 			// [ 0] ||||| 50%
 			label := fmt.Sprintf("%2d", idx)
-			// Compact bar
-			u := usage[idx]
+			u := cpu.PerCoreUsage[idx]
 			// We have colWidth - padding
 			w := (width / numCols) - 2
 
-			bar := renderBarCompact(int(u), 100, w, label)
-			rowStr += bar + "  "
+			// Temperature after the bar when the core has a sensor reading
+			temp := ""
+			if idx < len(cpu.PerCoreTemp) && cpu.PerCoreTemp[idx] > 0 {
+				temp = fmt.Sprintf(" %2.0f°", cpu.PerCoreTemp[idx])
+				w -= lipgloss.Width(temp)
+			}
+
+			// Clock in GHz, highlighted when the policy caps the core
+			freq := ""
+			freqStyle := MetricLabelStyle
+			if idx < len(cpu.PerCoreFreq) && cpu.PerCoreFreq[idx].Cur > 0 {
+				f := cpu.PerCoreFreq[idx]
+				freq = fmt.Sprintf(" %.1fG", float64(f.Cur)/1000)
+				w -= lipgloss.Width(freq)
+				if f.Capped() {
+					freqStyle = AlertStyle
+				}
+			}
+
+			segments := usageSegments(u)
+			if idx < len(cpu.PerCoreTimes) {
+				segments = cpuSegments(cpu.PerCoreTimes[idx])
+			}
+			bar := renderBarCompact(segments, w, label)
+			bar = labelStyle.Render(label) + strings.TrimPrefix(bar, label)
+			rowStr += bar + MetricLabelStyle.Render(temp) + freqStyle.Render(freq) + "  "
 		}
 		sb.WriteString(rowStr + "\n")
 	}
 
-	return sb.String()
+	return strings.TrimSuffix(sb.String(), "\n")
 }
 
-func renderBarCompact(value, max, width int, label string) string {
-	// [Label ||||| ]
-	labelLen := len(label)
-	barLen := width - labelLen - 3 // [ ] and space
-	if barLen < 5 {
-		// Just text if too small
-		return fmt.Sprintf("%s %d%%", label, value)
+// cpuGovernor summarizes the cpufreq governor and EPP across cores, marking
+// mixed settings with "*".
+func cpuGovernor(freqs []metrics.CoreFreq) string {
+	if len(freqs) == 0 || freqs[0].Governor == "" {
+		return ""
+	}
+	gov, epp := freqs[0].Governor, freqs[0].EPP
+	mixed := false
+	for _, f := range freqs[1:] {
+		if f.Governor != gov || f.EPP != epp {
+			mixed = true
+			break
+		}
+	}
+	s := gov
+	if epp != "" {
+		s += "/" + epp
 	}
+	if mixed {
+		s += "*"
+	}
+	return s
+}
 
-	filled := int(float64(value) / float64(max) * float64(barLen))
-	if filled > barLen {
-		filled = barLen
+// cpuFreqLimits lists the cpufreq policy limits per group of cores sharing
+// them, e.g. "0-7 0.8-5.4G 8-15 0.8-3.9G". Groups whose maximum is held
+// below the hardware's are marked with it and drawn in the alert colour.
+func cpuFreqLimits(freqs []metrics.CoreFreq) string {
+	type limit struct{ min, max, hwMax uint32 }
+	var order []limit
+	cores := make(map[limit][]int)
+	for i, f := range freqs {
+		if f.Max == 0 {
+			continue
+		}
+		l := limit{f.Min, f.Max, f.HWMax}
+		if _, ok := cores[l]; !ok {
+			order = append(order, l)
+		}
+		cores[l] = append(cores[l], i)
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, l := range order {
+		s := fmt.Sprintf("%s %.1f-%.1fG", formatCoreList(cores[l]), float64(l.min)/1000, float64(l.max)/1000)
+		if (metrics.CoreFreq{Max: l.max, HWMax: l.hwMax}).Capped() {
+			parts = append(parts, AlertStyle.Render(fmt.Sprintf("%s (hw %.1fG)", s, float64(l.hwMax)/1000)))
+		} else {
+			parts = append(parts, MetricLabelStyle.Render(s))
+		}
 	}
-	empty := barLen - filled
+	return strings.Join(parts, " ")
+}
+
+// formatCoreList collapses ascending core numbers into ranges, e.g.
+// "0-7,16".
+func formatCoreList(cores []int) string {
+	var parts []string
+	for i := 0; i < len(cores); {
+		j := i
+		for j+1 < len(cores) && cores[j+1] == cores[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", cores[i], cores[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(cores[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// barSegment is one colour-coded slice of a stacked bar, in percent.
+type barSegment struct {
+	percent float64
+	style   lipgloss.Style
+}
+
+// cpuModes lists CPU time modes in stacking order with their legend names.
+var cpuModes = []struct {
+	name  string
+	style lipgloss.Style
+	value func(metrics.CPUTimes) float64
+}{
+	{"usr", CPUUserStyle, func(t metrics.CPUTimes) float64 { return t.User }},
+	{"nice", CPUNiceStyle, func(t metrics.CPUTimes) float64 { return t.Nice }},
+	{"sys", CPUSystemStyle, func(t metrics.CPUTimes) float64 { return t.System }},
+	{"irq", CPUIRQStyle, func(t metrics.CPUTimes) float64 { return t.IRQ }},
+	{"sirq", CPUIRQStyle, func(t metrics.CPUTimes) float64 { return t.SoftIRQ }},
+	{"gst", CPUGuestStyle, func(t metrics.CPUTimes) float64 { return t.Guest }},
+	{"st", CPUStealStyle, func(t metrics.CPUTimes) float64 { return t.Steal }},
+	{"wa", CPUIOWaitStyle, func(t metrics.CPUTimes) float64 { return t.IOWait }},
+}
 
-	bar := strings.Repeat("|", filled) + strings.Repeat(" ", empty)
+// cpuSegments turns a time breakdown into stacked bar segments. iowait is
+// drawn last so it reads as "would be idle".
+func cpuSegments(t metrics.CPUTimes) []barSegment {
+	segments := make([]barSegment, len(cpuModes))
+	for i, mode := range cpuModes {
+		segments[i] = barSegment{percent: mode.value(t), style: mode.style}
+	}
+	return segments
+}
 
+// usageSegments is the single-colour fallback when no breakdown is known.
+func usageSegments(usage float64) []barSegment {
 	style := BarStyle
-	if value > 80 {
+	if usage > 80 {
 		style = AlertBarStyle
 	}
+	return []barSegment{{percent: usage, style: style}}
+}
+
+// renderCPULegend lists the non-trivial modes of a breakdown in their colours.
+func renderCPULegend(t metrics.CPUTimes) string {
+	var parts []string
+	for _, mode := range cpuModes {
+		v := mode.value(t)
+		if v < 0.05 && mode.name != "usr" && mode.name != "sys" {
+			continue
+		}
+		parts = append(parts, mode.style.Render(fmt.Sprintf("%s %.1f", mode.name, v)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func renderBarCompact(segments []barSegment, width int, label string) string {
+	// [Label ||||| ]
+	labelLen := len(label)
+	barLen := width - labelLen - 3 // [ ] and space
+	if barLen < 5 {
+		// Just text if too small
+		total := 0.0
+		for _, seg := range segments {
+			total += seg.percent
+		}
+		return fmt.Sprintf("%s %d%%", label, int(total))
+	}
+
+	// Round cumulative boundaries so segments never overflow the bar
+	var sb strings.Builder
+	cum, filled := 0.0, 0
+	for _, seg := range segments {
+		cum += seg.percent
+		end := int(math.Round(cum / 100 * float64(barLen)))
+		if end > barLen {
+			end = barLen
+		}
+		if end > filled {
+			sb.WriteString(seg.style.Render(strings.Repeat("|", end-filled)))
+			filled = end
+		}
+	}
+	sb.WriteString(strings.Repeat(" ", barLen-filled))
 
-	return fmt.Sprintf("%s [%s]", label, style.Render(bar))
+	return fmt.Sprintf("%s [%s]", label, sb.String())
 }
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestRenderCoresHybrid(t *testing.T) {
+	cpu := metrics.CPUStats{
+		PerCoreUsage: []float64{10, 20, 30},
+		PerCoreTemp:  []float64{50, 51, 42},
+		PerCoreType:  []metrics.CoreType{metrics.CoreTypePerformance, metrics.CoreTypeEfficiency, metrics.CoreTypePerformance},
+		PerCoreFreq: []metrics.CoreFreq{
+			{Cur: 4800, Max: 5000, HWMax: 5000},
+			{Cur: 2100, Max: 3900, HWMax: 3900},
+			{Cur: 1600, Max: 2000, HWMax: 5000},
+		},
+	}
+
+	out := renderCores(cpu, 60, 10)
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[0], "P-cores (2)") {
+		t.Fatalf("Expected P-core group first, got:\n%s", out)
+	}
+	eIdx := -1
+	for i, l := range lines {
+		if strings.Contains(l, "E-cores (1)") {
+			eIdx = i
+		}
+	}
+	if eIdx < 0 {
+		t.Fatalf("Missing E-core group:\n%s", out)
+	}
+	before := strings.Join(lines[:eIdx], "\n")
+	after := strings.Join(lines[eIdx:], "\n")
+	if !strings.Contains(before, " 0 [") || !strings.Contains(before, " 2 [") || strings.Contains(before, " 1 [") {
+		t.Errorf("P-core group should hold cores 0 and 2:\n%s", before)
+	}
+	if !strings.Contains(after, " 1 [") {
+		t.Errorf("E-core group should hold core 1:\n%s", after)
+	}
+	for _, want := range []string{"4.8G", "50°", "1.6G"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestCPUGovernor(t *testing.T) {
+	same := []metrics.CoreFreq{{Governor: "powersave", EPP: "power"}, {Governor: "powersave", EPP: "power"}}
+	if got := cpuGovernor(same); got != "powersave/power" {
+		t.Errorf("cpuGovernor = %q", got)
+	}
+	mixed := []metrics.CoreFreq{{Governor: "performance"}, {Governor: "schedutil"}}
+	if got := cpuGovernor(mixed); got != "performance*" {
+		t.Errorf("cpuGovernor = %q", got)
+	}
+	if got := cpuGovernor(nil); got != "" {
+		t.Errorf("cpuGovernor(nil) = %q", got)
+	}
+}
+
+func TestCPUFreqLimits(t *testing.T) {
+	freqs := []metrics.CoreFreq{
+		{Min: 800, Max: 5000, HWMax: 5000},
+		{Min: 800, Max: 5000, HWMax: 5000},
+		{Min: 800, Max: 3900, HWMax: 3900},
+		{Min: 800, Max: 2000, HWMax: 5000},
+		{Min: 800, Max: 5000, HWMax: 5000},
+	}
+	got := cpuFreqLimits(freqs)
+	for _, want := range []string{"0-1,4 0.8-5.0G", "2 0.8-3.9G", "3 0.8-2.0G (hw 5.0G)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in %q", want, got)
+		}
+	}
+	if got := cpuFreqLimits(nil); got != "" {
+		t.Errorf("cpuFreqLimits(nil) = %q", got)
+	}
+}
+
+func TestRenderBarCompactStacked(t *testing.T) {
+	segments := cpuSegments(metrics.CPUTimes{User: 50, System: 25, IOWait: 25})
+	bar := renderBarCompact(segments, 24, "0") // 20 cells
+	if bar != "0 ["+strings.Repeat("|", 20)+"]" {
+		t.Errorf("Expected a full bar, got %q", bar)
+	}
+
+	bar = renderBarCompact(cpuSegments(metrics.CPUTimes{User: 30, Steal: 20}), 24, "0")
+	if got := strings.Count(bar, "|"); got != 10 {
+		t.Errorf("Expected 10 filled cells for 50%%, got %d in %q", got, bar)
+	}
+
+	if got := renderBarCompact(usageSegments(42), 6, "12"); got != "12 42%" {
+		t.Errorf("Expected text fallback for narrow bars, got %q", got)
+	}
+}
+
+func TestRenderKernelActivity(t *testing.T) {
+	out := renderKernelActivity(metrics.KernelStats{
+		ContextSwitches: 12345,
+		Interrupts:      4000,
+		SoftIRQs:        1500,
+		Forks:           2500,
+		ProcsRunning:    40,
+		ProcsBlocked:    2,
+		MinorFaults:     950,
+		SwapIn:          3 << 20,
+	})
+	for _, want := range []string{"cs 12k", "irq 5.5k", "fork 2.5k", "run 40 blk 2", "flt 950", "Swap ↓3M ↑0B"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+	if lines := strings.Split(out, "\n"); len(lines) != 3 {
+		t.Errorf("Expected 3 lines, got %d", len(lines))
+	}
+}
+
+func TestFormatCPUPower(t *testing.T) {
+	if got := formatCPUPower(metrics.CPUPower{}); got != "Power: N/A" {
+		t.Errorf("Expected N/A without RAPL, got %q", got)
+	}
+	// AMD exposes no uncore or dram domain
+	got := formatCPUPower(metrics.CPUPower{Available: true, Package: 45.23, Core: 30.06, DRAM: 3.4})
+	if want := "Power pkg 45.2W core 30.1W dram 3.4W"; got != want {
+		t.Errorf("formatCPUPower() = %q, want %q", got, want)
+	}
+}
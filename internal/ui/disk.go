@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+// DiskModel lists block devices with their throughput graph, busiest first.
+type DiskModel struct {
+	width  int
+	height int
+	stats  metrics.DiskStats
+	Alert  bool
+	Stale  bool // Values come from an earlier sample
+
+	ShowPartitions bool
+	ShowVirtual    bool // device-mapper, md, loop, zram
+}
+
+func NewDiskModel() DiskModel {
+	return DiskModel{}
+}
+
+func (m DiskModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m DiskModel) Update(msg tea.Msg) (DiskModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "p": // Toggle partitions
+			m.ShowPartitions = !m.ShowPartitions
+		case "x": // Toggle device-mapper/loop/md devices
+			m.ShowVirtual = !m.ShowVirtual
+		}
+	}
+	return m, nil
+}
+
+func (m *DiskModel) SetStats(stats metrics.DiskStats) {
+	m.stats = stats
+}
+
+func (m *DiskModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m DiskModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+
+	style := PanelStyle
+	if m.Alert {
+		style = AlertPanelStyle
+	}
+	style = style.Copy().Width(m.width).Height(m.height)
+
+	title := fmt.Sprintf("Disks  R %s/s  W %s/s", formatBytes(m.stats.ReadSpeed), formatBytes(m.stats.WriteSpeed))
+	title = staleTitle(title, m.Stale)
+	filterStr := "disks"
+	if m.ShowPartitions {
+		filterStr += "+part"
+	}
+	if m.ShowVirtual {
+		filterStr += "+virt"
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Left,
+		TitleStyle.Render(title),
+		lipgloss.PlaceHorizontal(m.width-lipgloss.Width(title)-lipgloss.Width(filterStr)-5, lipgloss.Right, " "),
+		MetricLabelStyle.Render(fmt.Sprintf("[%s]", filterStr)),
+	)
+
+	devices := visibleDisks(m.stats.Devices, m.ShowPartitions, m.ShowVirtual)
+	lines := []string{header}
+	if len(devices) == 0 {
+		lines = append(lines, MetricLabelStyle.Render("No block devices"))
+	}
+	for i, d := range devices {
+		// Each device takes four lines: name, rates, graph and a spacer
+		if len(lines)+4 > m.height-2 {
+			lines = append(lines, MetricLabelStyle.Render(fmt.Sprintf("... %d more", len(devices)-i)))
+			break
+		}
+		lines = append(lines, renderDiskDevice(d, m.width-4)...)
+	}
+
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// renderDiskDevice draws one device: name and throughput, request stats with
+// a utilization bar, and a throughput history graph.
+func renderDiskDevice(d metrics.DiskDevice, width int) []string {
+	name := d.Name
+	if d.Label != "" {
+		name += " (" + d.Label + ")"
+	}
+	if d.Kind != metrics.DiskKindDisk {
+		name += " " + string(d.Kind)
+	}
+	rates := fmt.Sprintf("R %s/s  W %s/s", formatBytes(d.ReadSpeed), formatBytes(d.WriteSpeed))
+	nameLine := lipgloss.JoinHorizontal(lipgloss.Left,
+		TitleStyle.Render(name),
+		lipgloss.PlaceHorizontal(width-lipgloss.Width(name)-lipgloss.Width(rates), lipgloss.Right, " "),
+		MetricValueStyle.Render(rates),
+	)
+
+	stats := fmt.Sprintf("IOPS %.0f/%.0f await %.1fms q %d util %3.0f%%", d.ReadIOPS, d.WriteIOPS, d.Await, d.InFlight, d.Util)
+	statsLine := renderBar(int(d.Util), 100, width, stats)
+
+	return []string{
+		nameLine,
+		statsLine,
+		BarStyle.Render(renderSparkline(d.History, width)),
+		"",
+	}
+}
+
+// visibleDisks filters devices by kind and orders them by activity: highest
+// throughput first, then utilization, then name so idle devices keep a
+// stable order.
+func visibleDisks(devices []metrics.DiskDevice, showPartitions, showVirtual bool) []metrics.DiskDevice {
+	var out []metrics.DiskDevice
+	for _, d := range devices {
+		switch d.Kind {
+		case metrics.DiskKindPartition:
+			if !showPartitions {
+				continue
+			}
+		case metrics.DiskKindVirtual:
+			if !showVirtual {
+				continue
+			}
+		}
+		out = append(out, d)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].ReadSpeed+out[i].WriteSpeed, out[j].ReadSpeed+out[j].WriteSpeed
+		if a != b {
+			return a > b
+		}
+		if out[i].Util != out[j].Util {
+			return out[i].Util > out[j].Util
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// renderSparkline draws the most recent points of data as a one-line graph
+// scaled to the peak of the visible window, right-aligned to width.
+func renderSparkline(data []float64, width int) string {
+	if width < 1 {
+		return ""
+	}
+	if len(data) > width {
+		data = data[len(data)-width:]
+	}
+	peak := 0.0
+	for _, v := range data {
+		peak = max(peak, v)
+	}
+
+	symbols := []rune("▁▂▃▄▅▆▇█")
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", width-len(data)))
+	for _, v := range data {
+		if v <= 0 || peak <= 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		idx := int(v / peak * float64(len(symbols)-1))
+		sb.WriteRune(symbols[idx])
+	}
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestVisibleDisks(t *testing.T) {
+	devices := []metrics.DiskDevice{
+		{Name: "dm-0", Kind: metrics.DiskKindVirtual, ReadSpeed: 500},
+		{Name: "nvme0n1", Kind: metrics.DiskKindDisk, ReadSpeed: 500},
+		{Name: "nvme0n1p1", Kind: metrics.DiskKindPartition, ReadSpeed: 500},
+		{Name: "sda", Kind: metrics.DiskKindDisk},
+		{Name: "sdb", Kind: metrics.DiskKindDisk, WriteSpeed: 900},
+		{Name: "sdc", Kind: metrics.DiskKindDisk, Util: 10},
+	}
+
+	names := func(ds []metrics.DiskDevice) string {
+		var out []string
+		for _, d := range ds {
+			out = append(out, d.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := names(visibleDisks(devices, false, false)); got != "sdb,nvme0n1,sdc,sda" {
+		t.Errorf("Whole disks by activity = %s", got)
+	}
+	if got := names(visibleDisks(devices, true, true)); got != "sdb,dm-0,nvme0n1,nvme0n1p1,sdc,sda" {
+		t.Errorf("All devices by activity = %s", got)
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	if got := renderSparkline([]float64{0, 50, 100}, 5); got != "   ▄█" {
+		t.Errorf("renderSparkline = %q", got)
+	}
+	if got := renderSparkline([]float64{1, 2, 3, 4}, 2); got != "▆█" {
+		t.Errorf("Expected only the latest points, got %q", got)
+	}
+	if got := renderSparkline(nil, 3); got != "   " {
+		t.Errorf("Expected blank graph without data, got %q", got)
+	}
+}
@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+// FilesystemModel lists mounted filesystems with their space and inode
+// usage and a time until full estimate.
+type FilesystemModel struct {
+	width       int
+	height      int
+	filesystems []metrics.FilesystemStats
+	Alert       bool
+	Stale       bool    // Values come from an earlier sample
+	Threshold   float64 // Usage percent above which a mount is highlighted (0 disables)
+}
+
+func NewFilesystemModel() FilesystemModel {
+	return FilesystemModel{}
+}
+
+func (m FilesystemModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m FilesystemModel) Update(msg tea.Msg) (FilesystemModel, tea.Cmd) {
+	return m, nil
+}
+
+func (m *FilesystemModel) SetStats(filesystems []metrics.FilesystemStats) {
+	m.filesystems = filesystems
+}
+
+func (m *FilesystemModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m FilesystemModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+
+	style := PanelStyle
+	if m.Alert {
+		style = AlertPanelStyle
+	}
+	style = style.Copy().Width(m.width).Height(m.height)
+
+	lines := []string{TitleStyle.Render(staleTitle(fmt.Sprintf("Filesystems (%d)", len(m.filesystems)), m.Stale))}
+	if len(m.filesystems) == 0 {
+		lines = append(lines, MetricLabelStyle.Render("No filesystems"))
+	}
+	for i, fs := range m.filesystems {
+		// Each mount takes four lines: name, usage bar, inodes/growth and a spacer
+		if len(lines)+4 > m.height-2 {
+			lines = append(lines, MetricLabelStyle.Render(fmt.Sprintf("... %d more", len(m.filesystems)-i)))
+			break
+		}
+		lines = append(lines, m.renderFilesystem(fs, m.width-4)...)
+	}
+
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// renderFilesystem draws one mount: name and capacity, a usage bar, and
+// inode usage with the growth estimate.
+func (m FilesystemModel) renderFilesystem(fs metrics.FilesystemStats, width int) []string {
+	name := fmt.Sprintf("%s (%s %s)", fs.Mount, fs.Device, fs.FSType)
+	if fs.ReadOnly {
+		name += " ro"
+	}
+	nameStyle := TitleStyle
+	if FilesystemOverThreshold(fs, m.Threshold) {
+		nameStyle = AlertStyle
+	}
+	capacity := fmt.Sprintf("%s / %s", formatBytes(fs.Used), formatBytes(fs.Total))
+	nameLine := lipgloss.JoinHorizontal(lipgloss.Left,
+		nameStyle.Render(name),
+		lipgloss.PlaceHorizontal(width-lipgloss.Width(name)-lipgloss.Width(capacity), lipgloss.Right, " "),
+		MetricValueStyle.Render(capacity),
+	)
+
+	usageBar := renderBar(int(fs.UsedPercent), 100, width, fmt.Sprintf("Used %5.1f%%", fs.UsedPercent))
+
+	details := "inodes n/a"
+	if fs.Inodes > 0 {
+		details = fmt.Sprintf("inodes %.1f%%", fs.InodesPercent)
+	}
+	details += "  " + formatGrowth(fs)
+
+	return []string{
+		nameLine,
+		usageBar,
+		MetricLabelStyle.Render(details),
+		"",
+	}
+}
+
+// FilesystemOverThreshold reports whether a writable mount's space or inode
+// usage exceeds threshold. Read-only mounts (images, media) are always full
+// and never count.
+func FilesystemOverThreshold(fs metrics.FilesystemStats, threshold float64) bool {
+	if threshold <= 0 || fs.ReadOnly {
+		return false
+	}
+	return fs.UsedPercent > threshold || fs.InodesPercent > threshold
+}
+
+// formatGrowth describes the recent growth of a filesystem and when it will
+// be full at that rate.
+func formatGrowth(fs metrics.FilesystemStats) string {
+	if fs.ReadOnly {
+		return "read-only"
+	}
+	if fs.TimeToFull <= 0 {
+		if fs.GrowthRate < 0 {
+			return fmt.Sprintf("-%s/s", formatBytes(uint64(-fs.GrowthRate)))
+		}
+		return "stable"
+	}
+	return fmt.Sprintf("+%s/s, full in %s", formatBytes(uint64(fs.GrowthRate)), formatDuration(fs.TimeToFull))
+}
+
+// formatDuration renders a duration with its two most significant units,
+// e.g. "3d4h" or "12m".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
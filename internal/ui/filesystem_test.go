@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestFilesystemOverThreshold(t *testing.T) {
+	cases := []struct {
+		fs   metrics.FilesystemStats
+		want bool
+	}{
+		{metrics.FilesystemStats{UsedPercent: 95}, true},
+		{metrics.FilesystemStats{UsedPercent: 10, InodesPercent: 99}, true},
+		{metrics.FilesystemStats{UsedPercent: 80}, false},
+		{metrics.FilesystemStats{UsedPercent: 100, ReadOnly: true}, false},
+	}
+	for _, c := range cases {
+		if got := FilesystemOverThreshold(c.fs, 90); got != c.want {
+			t.Errorf("FilesystemOverThreshold(%+v) = %v, want %v", c.fs, got, c.want)
+		}
+	}
+	if FilesystemOverThreshold(metrics.FilesystemStats{UsedPercent: 100}, 0) {
+		t.Error("A zero threshold should disable the check")
+	}
+}
+
+func TestFormatGrowth(t *testing.T) {
+	growing := metrics.FilesystemStats{GrowthRate: 2 << 20, TimeToFull: 27*time.Hour + 30*time.Minute}
+	if got := formatGrowth(growing); got != "+2.0 MB/s, full in 1d3h" {
+		t.Errorf("formatGrowth = %q", got)
+	}
+	if got := formatGrowth(metrics.FilesystemStats{}); got != "stable" {
+		t.Errorf("formatGrowth = %q", got)
+	}
+	if got := formatDuration(90 * time.Minute); got != "1h30m" {
+		t.Errorf("formatDuration = %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,6 +13,9 @@ import (
 type FooterModel struct {
 	width int
 	help  string
+	power metrics.PowerSupplyStats
+	Alert bool // Battery below the low battery threshold
+	Stale bool // Values come from an earlier sample
 }
 
 func NewFooterModel() FooterModel {
@@ -30,6 +34,10 @@ func (m *FooterModel) SetSize(w int) {
 	m.width = w
 }
 
+func (m *FooterModel) SetStats(power metrics.PowerSupplyStats) {
+	m.power = power
+}
+
 func (m *FooterModel) SetHelp(h string) {
 	m.help = h
 }
@@ -64,7 +72,21 @@ func (m FooterModel) View() string {
 	left := fmt.Sprintf("OmniTop | %s", time.Now().Format("15:04:05"))
 
 	// Right: Hotkeys
-	right := "q: Quit | Arrows: Select | [ ] { }: Resize | /: Filter | k: Kill"
+	right := "q: Quit | Tab: Panels | Arrows: Select | [ ] { }: Resize | /: Filter | k: Kill"
+
+	// Battery after the clock, without health and cycles when short of room
+	battery := formatBattery(m.power, true)
+	if lipgloss.Width(left+battery+right)+4 > m.width {
+		battery = formatBattery(m.power, false)
+	}
+	if battery != "" {
+		battery = staleTitle(battery, m.Stale)
+		batteryStyle := lipgloss.NewStyle().Background(lipgloss.Color(ColorSteelGray))
+		if m.Alert {
+			batteryStyle = batteryStyle.Foreground(lipgloss.Color(ColorBloodCrimson)).Bold(true)
+		}
+		left += " | " + batteryStyle.Render(battery)
+	}
 
 	// Spacer
 	spacerWidth := m.width - lipgloss.Width(left) - lipgloss.Width(right) - 4
@@ -75,3 +97,82 @@ func (m FooterModel) View() string {
 
 	return style.Render(left + spacer + right)
 }
+
+// batterySummary combines all batteries into one, as laptops with two
+// batteries drain and charge them as a pair.
+type batterySummary struct {
+	percent      float64
+	power        float64 // Watts, positive
+	charging     bool
+	discharging  bool
+	energy, full float64 // Watt-hours
+	design       float64
+	cycles       int // Highest cycle count
+}
+
+func summarizeBatteries(batteries []metrics.Battery) batterySummary {
+	var s batterySummary
+	var percentSum float64
+	for _, b := range batteries {
+		s.energy += b.Energy
+		s.full += b.EnergyFull
+		s.design += b.EnergyDesign
+		s.power += b.Power
+		s.cycles = max(s.cycles, b.CycleCount)
+		percentSum += b.Percent
+		switch b.Status {
+		case "Charging":
+			s.charging = true
+		case "Discharging":
+			s.discharging = true
+		}
+	}
+	if s.full > 0 {
+		s.percent = s.energy / s.full * 100
+	} else if len(batteries) > 0 {
+		s.percent = percentSum / float64(len(batteries))
+	}
+	return s
+}
+
+// BatteryPercent returns the combined charge of all batteries, and whether
+// they are running the machine.
+func BatteryPercent(power metrics.PowerSupplyStats) (float64, bool) {
+	if len(power.Batteries) == 0 {
+		return 0, false
+	}
+	s := summarizeBatteries(power.Batteries)
+	return s.percent, s.discharging && !power.OnAC
+}
+
+// formatBattery describes the batteries in one line, e.g. "BAT 78% -11.2W
+// 3h29m left, health 88%, 312 cycles". It is empty without a battery.
+func formatBattery(power metrics.PowerSupplyStats, detailed bool) string {
+	if len(power.Batteries) == 0 {
+		return ""
+	}
+	s := summarizeBatteries(power.Batteries)
+	parts := []string{fmt.Sprintf("BAT %.0f%%", s.percent)}
+	switch {
+	case s.discharging && s.power > 0:
+		left := time.Duration(s.energy / s.power * float64(time.Hour))
+		parts = append(parts, fmt.Sprintf("-%.1fW", s.power), formatDuration(left)+" left")
+	case s.charging && s.power > 0 && s.full > s.energy:
+		toFull := time.Duration((s.full - s.energy) / s.power * float64(time.Hour))
+		parts = append(parts, fmt.Sprintf("+%.1fW", s.power), formatDuration(toFull)+" to full")
+	}
+	if power.OnAC {
+		parts = append([]string{"AC"}, parts...)
+	}
+	line := strings.Join(parts, " ")
+	if !detailed {
+		return line
+	}
+	if s.design > 0 && s.full > 0 {
+		line += fmt.Sprintf(", health %.0f%%", s.full/s.design*100)
+	}
+	if s.cycles > 0 {
+		line += fmt.Sprintf(", %d cycles", s.cycles)
+	}
+	return line
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestFormatBattery(t *testing.T) {
+	if got := formatBattery(metrics.PowerSupplyStats{OnAC: true}, true); got != "" {
+		t.Errorf("Expected nothing without a battery, got %q", got)
+	}
+
+	// Two batteries drained as a pair: 30 of 60 Wh at 10 W
+	power := metrics.PowerSupplyStats{Batteries: []metrics.Battery{
+		{Name: "BAT0", Status: "Discharging", Percent: 20, Power: 10, Energy: 10, EnergyFull: 40, EnergyDesign: 50, CycleCount: 300},
+		{Name: "BAT1", Status: "Unknown", Percent: 100, Energy: 20, EnergyFull: 20, EnergyDesign: 25, CycleCount: 120},
+	}}
+	if got, want := formatBattery(power, true), "BAT 50% -10.0W 3h00m left, health 80%, 300 cycles"; got != want {
+		t.Errorf("formatBattery() = %q, want %q", got, want)
+	}
+	if got, want := formatBattery(power, false), "BAT 50% -10.0W 3h00m left"; got != want {
+		t.Errorf("formatBattery() = %q, want %q", got, want)
+	}
+	if percent, onBattery := BatteryPercent(power); percent != 50 || !onBattery {
+		t.Errorf("Expected 50%% on battery, got %.0f%% %v", percent, onBattery)
+	}
+
+	power = metrics.PowerSupplyStats{OnAC: true, Batteries: []metrics.Battery{
+		{Name: "BAT0", Status: "Charging", Percent: 50, Power: 20, Energy: 25, EnergyFull: 50},
+	}}
+	if got, want := formatBattery(power, true), "AC BAT 50% +20.0W 1h15m to full"; got != want {
+		t.Errorf("formatBattery() = %q, want %q", got, want)
+	}
+	if _, onBattery := BatteryPercent(power); onBattery {
+		t.Error("A charging battery should not count as running the machine")
+	}
+}
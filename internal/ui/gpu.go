@@ -3,6 +3,8 @@ package ui
 import (
 	"fmt"
 	"math"
+	"slices"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -10,16 +12,31 @@ import (
 	"github.com/google/omnitop/internal/metrics"
 )
 
+// GPUViewMode selects how multiple devices are presented in the GPU column.
+type GPUViewMode int
+
+const (
+	GPUViewSingle    GPUViewMode = iota // One device at a time, selectable
+	GPUViewAll                          // Every device side by side
+	GPUViewAggregate                    // Host-wide totals across devices
+)
+
 type GPUModel struct {
-	width  int
-	height int
-	stats  metrics.GPUStats
-	Alert  bool
+	width         int
+	height        int
+	gpus          []metrics.GPUStats
+	selected      int // Device shown in GPUViewSingle
+	viewMode      GPUViewMode
+	showProcesses bool
+	showDetails   bool // Expanded telemetry in place of the history graph
+	Alert         bool
+	Stale         bool // Values come from an earlier sample
 }
 
 func NewGPUModel() GPUModel {
 	return GPUModel{
 		showProcesses: false, // Default to graph view
+		viewMode:      GPUViewSingle,
 	}
 }
 
@@ -33,13 +50,28 @@ func (m GPUModel) Update(msg tea.Msg) (GPUModel, tea.Cmd) {
 		switch msg.String() {
 		case "g":
 			m.showProcesses = !m.showProcesses
+		case "e":
+			m.showDetails = !m.showDetails
+		case "v": // Cycle Single -> All -> Aggregate
+			m.viewMode = (m.viewMode + 1) % 3
+		case ">": // Next device
+			if len(m.gpus) > 0 {
+				m.selected = (m.selected + 1) % len(m.gpus)
+			}
+		case "<": // Previous device
+			if len(m.gpus) > 0 {
+				m.selected = (m.selected - 1 + len(m.gpus)) % len(m.gpus)
+			}
 		}
 	}
 	return m, nil
 }
 
-func (m *GPUModel) SetStats(stats metrics.GPUStats) {
-	m.stats = stats
+func (m *GPUModel) SetStats(gpus []metrics.GPUStats) {
+	m.gpus = gpus
+	if m.selected >= len(gpus) {
+		m.selected = 0
+	}
 }
 
 func (m *GPUModel) SetSize(w, h int) {
@@ -58,41 +90,50 @@ func (m GPUModel) View() string {
 	}
 	style = style.Copy().Width(m.width).Height(m.height)
 
-	if !m.stats.Available {
+	if len(m.gpus) == 0 || !metrics.AggregateGPUs(m.gpus).Available {
 		content := lipgloss.Place(m.width-2, m.height-2, lipgloss.Center, lipgloss.Center, "GPU Unavailable\n(Run with --mock to see demo)")
 		return style.Render(content)
 	}
 
-	// Header
-	header := TitleStyle.Render(fmt.Sprintf("GPU: %s", m.stats.Name))
-
-	// Metrics Bars
-	// Calculate available width for bars
-	barLabelWidth := 20 // Approx width for labels
-	barWidth := m.width - 4 - barLabelWidth
-	if barWidth < 10 {
-		barWidth = 10
+	var content string
+	switch m.viewMode {
+	case GPUViewAll:
+		content = m.renderAll()
+	case GPUViewAggregate:
+		agg := metrics.AggregateGPUs(m.gpus)
+		content = m.renderDevice(agg, fmt.Sprintf("GPU: %s (aggregate)", agg.Name))
+	default:
+		gpu := m.gpus[m.selected]
+		title := fmt.Sprintf("GPU: %s", gpu.Name)
+		if len(m.gpus) > 1 {
+			title = fmt.Sprintf("GPU %d/%d: %s", m.selected+1, len(m.gpus), gpu.Name)
+		}
+		content = m.renderDevice(gpu, title)
 	}
 
-	utilBar := renderBar(int(m.stats.Utilization), 100, m.width-4, "Util")
+	return style.Render(content)
+}
 
-	memUtilPercent := int(m.stats.MemoryUtil)
-	if memUtilPercent == 0 && m.stats.MemoryTotal > 0 {
-		memUtilPercent = int(float64(m.stats.MemoryUsed) / float64(m.stats.MemoryTotal) * 100.0)
+// renderDevice renders the full detail view (bars, history graph and process
+// list) for a single device or for an aggregate.
+func (m GPUModel) renderDevice(gpu metrics.GPUStats, title string) string {
+	// Header
+	header := TitleStyle.Render(staleTitle(title, m.Stale))
+	if cause := gpu.ThrottleCause(); cause != "" {
+		header = lipgloss.JoinHorizontal(lipgloss.Left, header, " ", AlertStyle.Render(fmt.Sprintf("[THROTTLED: %s]", cause)))
 	}
-	memBar := renderBar(memUtilPercent, 100, m.width-4, fmt.Sprintf("VRAM %d/%d MB", m.stats.MemoryUsed/1024/1024, m.stats.MemoryTotal/1024/1024))
 
-	tempBar := renderBar(int(m.stats.Temperature), 100, m.width-4, fmt.Sprintf("Temp %d°C", m.stats.Temperature))
-	fanBar := renderBar(int(m.stats.FanSpeed), 100, m.width-4, fmt.Sprintf("Fan %d%%", m.stats.FanSpeed))
+	utilBar := renderBar(int(gpu.Utilization), 100, m.width-4, "Util")
 
-	// Power Bar
-	powerW := m.stats.PowerUsage / 1000
-	powerLimitW := m.stats.PowerLimit / 1000
-	if powerLimitW == 0 {
-		powerLimitW = 300
-	} // Default fallback if 0
-	powerPct := int(float64(powerW) / float64(powerLimitW) * 100)
-	powerBar := renderBar(powerPct, 100, m.width-4, fmt.Sprintf("Pwr %dW", powerW))
+	memUtilPercent := int(gpu.MemoryUtil)
+	if memUtilPercent == 0 && gpu.MemoryTotal > 0 {
+		memUtilPercent = int(float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100.0)
+	}
+	memBar := renderBar(memUtilPercent, 100, m.width-4, fmt.Sprintf("VRAM %d/%d MB", gpu.MemoryUsed/1024/1024, gpu.MemoryTotal/1024/1024))
+
+	tempBar := renderBar(int(gpu.Temperature), 100, m.width-4, fmt.Sprintf("Temp %d°C", gpu.Temperature))
+	fanBar := renderBar(int(gpu.FanSpeed), 100, m.width-4, fmt.Sprintf("Fan %d%%", gpu.FanSpeed))
+	powerBar := renderBar(gpuPowerPercent(gpu), 100, m.width-4, fmt.Sprintf("Pwr %dW", gpu.PowerUsage/1000))
 
 	// Calculate space for graph vs process list
 	// We want roughly 50% for graph, remaining for processes if height allows
@@ -105,6 +146,10 @@ func (m GPUModel) View() string {
 	if graphHeight < 5 {
 		graphHeight = 5
 	}
+	if m.showProcesses {
+		// Process view: give the graph's space to the process list
+		graphHeight = 0
+	}
 
 	// Process list gets remaining space
 	procHeight := availHeight - graphHeight - 2 // -2 for headers/padding
@@ -112,17 +157,23 @@ func (m GPUModel) View() string {
 		procHeight = 0
 	}
 
-	// Render Graph
-	graph := m.renderGraph(graphHeight)
+	// Render Graph (or the expanded telemetry in its place)
+	graph := ""
+	if m.showDetails {
+		graph = renderGPUDetails(gpu)
+		procHeight -= lipgloss.Height(graph) - graphHeight
+	} else if graphHeight > 0 {
+		graph = renderGPUGraph(gpu.HistoricalUtil, m.width-4, graphHeight)
+	}
 
 	// Render Process List
 	procList := ""
 	if procHeight > 2 {
-		procList = m.renderProcessTable(procHeight)
+		procList = renderGPUProcessTable(gpu.Processes, procHeight)
 	}
 
 	// Combine
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	return lipgloss.JoinVertical(lipgloss.Left,
 		header,
 		utilBar,
 		memBar,
@@ -134,18 +185,173 @@ func (m GPUModel) View() string {
 		"\n",
 		procList,
 	)
+}
 
-	return style.Render(content)
+// renderAll lays out a compact card per device in a grid, as many columns as
+// the panel width allows.
+func (m GPUModel) renderAll() string {
+	const minCardWidth = 28
+	innerWidth := m.width - 4
+	numCols := innerWidth / minCardWidth
+	if numCols < 1 {
+		numCols = 1
+	}
+	if numCols > len(m.gpus) {
+		numCols = len(m.gpus)
+	}
+	cardWidth := innerWidth/numCols - 1
+
+	rows := (len(m.gpus) + numCols - 1) / numCols
+	// Header line plus 5 lines of bars per card; the rest goes to graphs
+	graphHeight := (m.height-3)/rows - 6
+	if graphHeight < 0 {
+		graphHeight = 0
+	}
+	if graphHeight > 8 {
+		graphHeight = 8
+	}
+
+	var gridRows []string
+	for r := 0; r < rows; r++ {
+		var cards []string
+		for c := 0; c < numCols; c++ {
+			idx := r*numCols + c
+			if idx >= len(m.gpus) {
+				break
+			}
+			cards = append(cards, renderGPUCard(m.gpus[idx], cardWidth, graphHeight), " ")
+		}
+		gridRows = append(gridRows, lipgloss.JoinHorizontal(lipgloss.Top, cards...))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		append([]string{TitleStyle.Render(staleTitle(fmt.Sprintf("GPUs: %d devices", len(m.gpus)), m.Stale))}, gridRows...)...,
+	)
+}
+
+// renderGPUCard renders the compact per-device summary used by GPUViewAll.
+func renderGPUCard(gpu metrics.GPUStats, width, graphHeight int) string {
+	name := gpu.Name
+	if maxName := width - 4; maxName > 3 && len(name) > maxName {
+		name = name[:maxName-3] + "..."
+	}
+
+	memUtilPercent := 0
+	if gpu.MemoryTotal > 0 {
+		memUtilPercent = int(float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100.0)
+	}
+
+	lines := []string{
+		TitleStyle.Render(fmt.Sprintf("%d %s", gpu.Index, name)),
+		renderBar(int(gpu.Utilization), 100, width, fmt.Sprintf("Util %3d%%", gpu.Utilization)),
+		renderBar(memUtilPercent, 100, width, fmt.Sprintf("VRAM %3d%%", memUtilPercent)),
+		renderBar(int(gpu.Temperature), 100, width, fmt.Sprintf("Temp %3d°", gpu.Temperature)),
+		renderBar(gpuPowerPercent(gpu), 100, width, fmt.Sprintf("Pwr %4dW", gpu.PowerUsage/1000)),
+	}
+	if graphHeight > 0 {
+		lines = append(lines, renderGPUGraph(gpu.HistoricalUtil, width, graphHeight))
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
 }
 
-func (m GPUModel) renderGraph(height int) string {
-	if len(m.stats.HistoricalUtil) == 0 {
+// renderGPUDetails renders the expanded telemetry block: clocks, power limit,
+// performance state, PCIe and codec activity, ECC and throttle reasons.
+func renderGPUDetails(gpu metrics.GPUStats) string {
+	row := func(label, value string) string {
+		return MetricLabelStyle.Render(fmt.Sprintf("%-9s", label)) + MetricValueStyle.Render(value)
+	}
+	orNA := func(ok bool, s string) string {
+		if !ok {
+			return "N/A"
+		}
+		return s
+	}
+
+	pstate := gpu.PState
+	if pstate == "" {
+		pstate = "N/A"
+	}
+
+	throttle := MetricValueStyle.Render("none")
+	if len(gpu.Throttle) > 0 {
+		style := MetricValueStyle
+		if gpu.ThrottleCause() != "" {
+			style = AlertStyle
+		}
+		throttle = style.Render(strings.Join(gpu.Throttle, ", "))
+	}
+
+	ecc := fmt.Sprintf("corr %d  uncorr %d", gpu.ECCCorrected, gpu.ECCUncorrected)
+	eccStyled := MetricValueStyle.Render(ecc)
+	if gpu.ECCUncorrected > 0 {
+		eccStyled = AlertStyle.Render(ecc)
+	}
+
+	clocks := fmt.Sprintf("%d MHz core / %d MHz mem", gpu.GraphicsClock, gpu.MemoryClock)
+	if gpu.RequestedClock > 0 {
+		clocks = fmt.Sprintf("%d MHz core (req %d MHz)", gpu.GraphicsClock, gpu.RequestedClock)
+	}
+
+	lines := []string{
+		TitleStyle.Render("Details"),
+		row("Clocks", orNA(gpu.GraphicsClock > 0, clocks)),
+		row("Power", orNA(gpu.PowerLimit > 0, fmt.Sprintf("%dW / %dW limit", gpu.PowerUsage/1000, gpu.PowerLimit/1000))),
+		row("P-State", pstate),
+		row("PCIe", fmt.Sprintf("RX %s/s  TX %s/s", formatBytes(gpu.PCIeRxSpeed), formatBytes(gpu.PCIeTxSpeed))),
+		row("Video", fmt.Sprintf("enc %d%%  dec %d%%", gpu.EncoderUtil, gpu.DecoderUtil)),
+		MetricLabelStyle.Render(fmt.Sprintf("%-9s", "ECC")) + eccStyled,
+		MetricLabelStyle.Render(fmt.Sprintf("%-9s", "Throttle")) + throttle,
+	}
+	if len(gpu.Engines) > 0 {
+		lines = append(lines, row("Engines", formatGPUEngines(gpu.Engines)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// gpuEngineOrder lists engine classes in display order; unknown classes
+// follow alphabetically.
+var gpuEngineOrder = []string{
+	metrics.EngineRender, metrics.EngineCompute, metrics.EngineCopy,
+	metrics.EngineVideo, metrics.EngineVideoEnhance,
+	metrics.EngineEncode, metrics.EngineDecode,
+}
+
+func formatGPUEngines(engines map[string]uint32) string {
+	var extra []string
+	for class := range engines {
+		if !slices.Contains(gpuEngineOrder, class) {
+			extra = append(extra, class)
+		}
+	}
+	sort.Strings(extra)
+
+	var parts []string
+	for _, class := range append(append([]string(nil), gpuEngineOrder...), extra...) {
+		if util, ok := engines[class]; ok {
+			parts = append(parts, fmt.Sprintf("%s %d%%", class, util))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// gpuPowerPercent returns power draw as a percentage of the device limit.
+func gpuPowerPercent(gpu metrics.GPUStats) int {
+	powerW := gpu.PowerUsage / 1000
+	powerLimitW := gpu.PowerLimit / 1000
+	if powerLimitW == 0 {
+		powerLimitW = 300
+	} // Default fallback if 0
+	return int(float64(powerW) / float64(powerLimitW) * 100)
+}
+
+func renderGPUGraph(data []float64, width, height int) string {
+	if len(data) == 0 {
 		return "Waiting for data..."
 	}
 
 	// Use only last N points that fit width
-	data := m.stats.HistoricalUtil
-	maxPoints := m.width - 4
+	maxPoints := width
 	if maxPoints < 1 {
 		maxPoints = 1
 	}
@@ -173,9 +379,9 @@ func (m GPUModel) renderGraph(height int) string {
 	}
 
 	// Determine start index for data in the grid (right-aligned)
-	startIdx := maxPoints - len(data)
+	startIdx := maxPoints - len(window)
 
-	for x, val := range data {
+	for x, val := range window {
 		// Calculate height relative to max 100
 		// val is 0-100
 		// height is e.g. 10
@@ -218,50 +424,19 @@ func (m GPUModel) renderGraph(height int) string {
 	return sb.String()
 }
 
-func (m GPUModel) renderProcessTable(height int) string {
-	var sb strings.Builder
-	sb.WriteString(TitleStyle.Render("GPU Processes"))
-	sb.WriteString("\n")
-
-	if len(m.stats.Processes) == 0 {
-		sb.WriteString("No GPU processes found.")
-		return sb.String()
-	}
-
-	// Header
-	sb.WriteString(fmt.Sprintf("%-8s %-15s %s\n", "PID", "Mem", "Name"))
-
-	count := 0
-	for _, p := range m.stats.Processes {
-		if count >= height-2 {
-			break
-		}
-		memStr := fmt.Sprintf("%dMiB", p.MemoryUsed/1024/1024)
-		sb.WriteString(fmt.Sprintf("%-8d %-15s %s\n", p.PID, memStr, p.Name))
-		count++
-	}
-
-	return sb.String()
-}
-
-func (m GPUModel) renderProcessTable(height int) string {
+func renderGPUProcessTable(procs []metrics.GPUProcess, height int) string {
 	var sb strings.Builder
 	sb.WriteString(TitleStyle.Render("GPU Processes"))
 	sb.WriteString("\n")
 
-	// Filter GPU processes
-	// Assuming stats.Processes contains all system processes, we need to filter
-	// wait, stats.Processes is missing in GPUStats struct in types.go?
-	// Let's check types.go. Yes, GPUStats has `Processes []GPUProcess`.
-
-	if len(m.stats.Processes) == 0 {
+	if len(procs) == 0 {
 		sb.WriteString(MetricLabelStyle.Render("No GPU processes"))
 		return sb.String()
 	}
 
-	// Columns: PID, Name, VRAM
-	// PID (6), Name (15), VRAM (10)
-	header := fmt.Sprintf("%-6s %-15s %-10s", "PID", "Name", "VRAM")
+	// Columns: PID, Type, Name, VRAM, SM%, Enc%
+	// PID (7), Type (3), Name (15), VRAM (9), SM (4), Enc (4)
+	header := fmt.Sprintf("%-7s %-3s %-15s %9s %4s %4s", "PID", "T", "Name", "VRAM", "SM%", "Enc%")
 	sb.WriteString(MetricLabelStyle.Render(header) + "\n")
 
 	remainingHeight := height - 2 // Header + Title
@@ -269,7 +444,7 @@ func (m GPUModel) renderProcessTable(height int) string {
 		remainingHeight = 0
 	}
 
-	for i, p := range m.stats.Processes {
+	for i, p := range procs {
 		if i >= remainingHeight {
 			break
 		}
@@ -280,7 +455,7 @@ func (m GPUModel) renderProcessTable(height int) string {
 			name = name[:12] + "..."
 		}
 
-		line := fmt.Sprintf("%-6d %-15s %-10s", p.PID, name, vramStr)
+		line := fmt.Sprintf("%-7d %-3s %-15s %9s %4d %4d", p.PID, p.Type, name, vramStr, p.SMUtil, p.EncUtil)
 		sb.WriteString(MetricValueStyle.Render(line) + "\n")
 	}
 
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestGPUModelRendersFakeBackend(t *testing.T) {
+	backend := &metrics.FakeGPUBackend{
+		Frames: [][]metrics.FakeGPUState{{
+			{Name: "Fake H100", Utilization: 75, MemoryTotal: 80 << 30, MemoryUsed: 40 << 30, Temperature: 70, PowerUsage: 350000, PowerLimit: 700000,
+				Processes: []metrics.GPUProcess{{PID: 4242, Name: "train.py", MemoryUsed: 30 << 30}}},
+			{Name: "Fake L4", Utilization: 5, MemoryTotal: 24 << 30},
+		}},
+	}
+	provider := &metrics.RealProvider{GPUBackend: backend}
+	if err := provider.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer provider.Shutdown()
+
+	stats, err := provider.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+
+	m := NewGPUModel()
+	m.SetSize(80, 40)
+	m.SetStats(stats.GPUs)
+
+	view := m.View()
+	for _, want := range []string{"GPU 1/2: Fake H100", "VRAM 40960/81920 MB", "Pwr 350W", "4242", "train.py"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Single view missing %q", want)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")})
+	if view := m.View(); !strings.Contains(view, "GPU 2/2: Fake L4") {
+		t.Error("Next-device key did not select the second GPU")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	view = m.View()
+	if !strings.Contains(view, "Fake H100") || !strings.Contains(view, "Fake L4") {
+		t.Error("All view should show every device")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if view := m.View(); !strings.Contains(view, "2 GPUs (aggregate)") {
+		t.Error("Aggregate view missing title")
+	}
+}
+
+func TestGPUModelUnavailable(t *testing.T) {
+	m := NewGPUModel()
+	m.SetSize(60, 20)
+	m.SetStats(nil)
+	if view := m.View(); !strings.Contains(view, "GPU Unavailable") {
+		t.Error("Expected unavailable message without devices")
+	}
+}
+
+func TestGPUModelDetails(t *testing.T) {
+	gpu := metrics.GPUStats{
+		Available: true, Name: "Fake A10", GraphicsClock: 1695, MemoryClock: 6251,
+		PowerUsage: 149000, PowerLimit: 150000, PState: "P0",
+		Throttle:    []string{metrics.ThrottlePowerCap},
+		PCIeRxSpeed: 2 << 30, EncoderUtil: 12, ECCUncorrected: 1,
+	}
+
+	m := NewGPUModel()
+	m.SetSize(80, 40)
+	m.SetStats([]metrics.GPUStats{gpu})
+	if view := m.View(); !strings.Contains(view, "[THROTTLED: power]") {
+		t.Error("Header should flag power throttling")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	view := m.View()
+	for _, want := range []string{"1695 MHz core", "149W / 150W limit", "P0", "RX 2.0 GB/s", "enc 12%", "uncorr 1", "power-cap"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Details view missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+const (
+	// ioScaleDecay is applied to the recent peak per second between
+	// samples, so the scale halves about 14 seconds after a burst however
+	// often the UI polls.
+	ioScaleDecay = 0.95
+	// ioScaleFloor keeps an idle system from zooming in on noise.
+	ioScaleFloor = 1 << 20
+)
+
+// ioScale picks the throughput that fills a Net or Disk bar: the known
+// capacity when there is one, otherwise a decaying recent peak rounded up
+// to a readable value.
+type ioScale struct {
+	peak  float64
+	last  time.Time // Timestamp of the previous update
+	Max   uint64    // Bytes per second at a full bar
+	Known bool      // Max is a link speed or configured capacity, not a peak
+}
+
+// update folds the rates sampled at now into the peak and recomputes Max.
+// The peak is tracked even while the capacity is known so that losing it (a
+// link going down) does not reset the bar.
+func (s *ioScale) update(now time.Time, capacity uint64, rates ...uint64) {
+	if !s.last.IsZero() && now.After(s.last) {
+		s.peak *= math.Pow(ioScaleDecay, now.Sub(s.last).Seconds())
+	}
+	s.last = now
+	for _, r := range rates {
+		s.peak = max(s.peak, float64(r))
+	}
+	if capacity > 0 {
+		s.Max, s.Known = capacity, true
+		return
+	}
+	s.Max, s.Known = niceScale(max(s.peak, ioScaleFloor)), false
+}
+
+// percent returns rate as a percentage of the scale, capped at 100.
+func (s ioScale) percent(rate uint64) int {
+	if s.Max == 0 {
+		return 0
+	}
+	return int(min(float64(rate)/float64(s.Max)*100, 100))
+}
+
+// label renders the scale for a bar label: "/298M" for a known capacity and
+// "/~2M" for a peak.
+func (s ioScale) label() string {
+	if s.Known {
+		return "/" + formatBytesShort(s.Max)
+	}
+	return "/~" + formatBytesShort(s.Max)
+}
+
+// niceScale rounds v up to 1, 2 or 5 times a power of ten of a binary unit,
+// so the scale of a peak reads "20M" rather than "17.3M".
+func niceScale(v float64) uint64 {
+	unit := 1.0
+	for v/unit >= 1000 {
+		unit *= 1024
+	}
+	for _, step := range []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000} {
+		if v <= step*unit {
+			return uint64(step * unit)
+		}
+	}
+	return uint64(1024 * unit)
+}
+
+// formatBytesShort renders a byte count compactly, e.g. "298M".
+func formatBytesShort(bytes uint64) string {
+	const unit = 1024
+	v, exp := float64(bytes), 0
+	for v >= 1000 && exp < 6 {
+		v /= unit
+		exp++
+	}
+	suffix := string("BKMGTPE"[exp])
+	if v < 10 && v != math.Trunc(v) {
+		return fmt.Sprintf("%.1f%s", v, suffix)
+	}
+	return fmt.Sprintf("%.0f%s", v, suffix)
+}
+
+// netCapacity returns the combined bandwidth in bytes per second of the
+// interfaces counted in the Net bars, per direction. overrides, keyed by
+// name or glob pattern, replace reported link speeds. It is 0 when any
+// counted interface that is not down has an unknown speed, since the sum
+// would then understate the capacity.
+func netCapacity(ifaces []metrics.NetInterface, overrides map[string]uint64) uint64 {
+	var total uint64
+	for _, iface := range ifaces {
+		if !iface.Aggregated || iface.OperState == "down" {
+			continue
+		}
+		speed, ok := matchOverride(overrides, iface.Name)
+		if !ok {
+			speed = iface.Speed * 1000 * 1000 / 8
+		}
+		if speed == 0 {
+			return 0
+		}
+		total += speed
+	}
+	return total
+}
+
+// diskCapacity returns the combined throughput in bytes per second of the
+// whole disks counted in the Disk bars, or 0 when any of them is unknown.
+func diskCapacity(devices []metrics.DiskDevice, overrides map[string]uint64) uint64 {
+	var total uint64
+	for _, d := range devices {
+		if d.Kind != metrics.DiskKindDisk {
+			continue
+		}
+		speed, ok := matchOverride(overrides, d.Name)
+		if !ok {
+			speed = d.MaxSpeed
+		}
+		if speed == 0 {
+			return 0
+		}
+		total += speed
+	}
+	return total
+}
+
+// matchOverride looks name up in overrides, trying an exact match before
+// glob patterns. When several patterns match, the longest, being the most
+// specific, wins, and ties go to the first in sort order, so the choice does
+// not change between frames.
+func matchOverride(overrides map[string]uint64, name string) (uint64, bool) {
+	if v, ok := overrides[name]; ok {
+		return v, true
+	}
+	best, found := "", false
+	for pattern := range overrides {
+		if ok, _ := filepath.Match(pattern, name); !ok {
+			continue
+		}
+		if !found || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best, found = pattern, true
+		}
+	}
+	return overrides[best], found
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestIOScalePeakDecay(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var s ioScale
+	s.update(now, 0, 0, 0)
+	if s.Max != ioScaleFloor || s.Known || s.label() != "/~1M" {
+		t.Errorf("Expected an idle scale at the floor, got %+v %q", s, s.label())
+	}
+
+	now = now.Add(time.Second)
+	s.update(now, 0, 15<<20, 3<<20)
+	if s.Max != 20<<20 || s.percent(15<<20) != 75 || s.label() != "/~20M" {
+		t.Errorf("Expected a 15M burst to scale to 20M, got %+v", s)
+	}
+
+	// The burst decays away instead of pinning the scale
+	for i := 0; i < 100; i++ {
+		now = now.Add(time.Second)
+		s.update(now, 0, 0, 0)
+	}
+	if s.Max != ioScaleFloor {
+		t.Errorf("Expected the peak to decay to the floor, got %d", s.Max)
+	}
+
+	s.update(now.Add(time.Second), 125_000_000, 0, 0)
+	if !s.Known || s.Max != 125_000_000 || s.label() != "/119M" || s.percent(250_000_000) != 100 {
+		t.Errorf("Expected the known capacity to win, got %+v %q", s, s.label())
+	}
+}
+
+func TestIOScaleDecayPollRate(t *testing.T) {
+	// The peak decays by time, not by the number of polls in between
+	decayed := func(poll time.Duration) float64 {
+		now := time.Unix(1000, 0)
+		var s ioScale
+		s.update(now, 0, 100<<20)
+		for end := now.Add(10 * time.Second); now.Before(end); {
+			now = now.Add(poll)
+			s.update(now, 0, 0)
+		}
+		return s.peak
+	}
+	slow, fast := decayed(time.Second), decayed(250*time.Millisecond)
+	if math.Abs(slow-fast) > 1 {
+		t.Errorf("Expected the same decay at 1s and 250ms polls, got %.0f and %.0f", slow, fast)
+	}
+}
+
+func TestNetCapacity(t *testing.T) {
+	ifaces := []metrics.NetInterface{
+		{Name: "enp5s0", OperState: "up", Speed: 2500, Aggregated: true},
+		{Name: "enp6s0", OperState: "down", Aggregated: true},
+		{Name: "docker0", OperState: "up"},
+		{Name: "wlp4s0", OperState: "up", Aggregated: true},
+	}
+	if got := netCapacity(ifaces, nil); got != 0 {
+		t.Errorf("Expected Wi-Fi without a link speed to leave the capacity unknown, got %d", got)
+	}
+	got := netCapacity(ifaces, map[string]uint64{"wl*": 50_000_000, "enp5s0": 12_500_000})
+	if got != 62_500_000 {
+		t.Errorf("Expected overrides to replace link speeds, got %d", got)
+	}
+	if got := netCapacity(ifaces[:3], nil); got != 312_500_000 {
+		t.Errorf("Expected 2.5 Gbit/s, got %d", got)
+	}
+}
+
+func TestDiskCapacity(t *testing.T) {
+	devices := []metrics.DiskDevice{
+		{Name: "nvme0n1", Kind: metrics.DiskKindDisk, MaxSpeed: 4 << 30},
+		{Name: "nvme0n1p1", Kind: metrics.DiskKindPartition},
+		{Name: "sda", Kind: metrics.DiskKindDisk},
+	}
+	if got := diskCapacity(devices, nil); got != 0 {
+		t.Errorf("Expected an unknown SATA disk to leave the capacity unknown, got %d", got)
+	}
+	if got := diskCapacity(devices, map[string]uint64{"sd*": 500 << 20}); got != 4<<30+500<<20 {
+		t.Errorf("Unexpected capacity %d", got)
+	}
+}
+
+func TestMatchOverrideOverlapping(t *testing.T) {
+	overrides := map[string]uint64{"*": 1, "eth*": 2, "eth0": 3, "en?1": 4, "enp*": 5}
+	cases := []struct {
+		name string
+		want uint64
+	}{
+		{"eth0", 3}, // Exact match
+		{"eth1", 2}, // Longer pattern over "*"
+		{"wlan0", 1},
+		{"enp1", 4}, // Equal length, first in sort order
+	}
+	for _, c := range cases {
+		// Map order is random; repeat to catch a choice that depends on it
+		for i := 0; i < 50; i++ {
+			if got, ok := matchOverride(overrides, c.name); !ok || got != c.want {
+				t.Fatalf("matchOverride(%q) = %d, %v, want %d", c.name, got, ok, c.want)
+			}
+		}
+	}
+	if _, ok := matchOverride(map[string]uint64{"eth*": 2}, "wlan0"); ok {
+		t.Error("Expected no match")
+	}
+}
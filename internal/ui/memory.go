@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+// memParts lists what RAM holds in stacking order with their legend names.
+// Together with free memory they add up to Total, so the bar shows that most
+// of what is not free is cache the kernel gives back on demand.
+var memParts = []struct {
+	name  string
+	style lipgloss.Style
+	value func(metrics.MemoryStats) uint64
+}{
+	{"apps", MemAppsStyle, func(m metrics.MemoryStats) uint64 { return sub(m.Used, m.HugePagesTotal) }},
+	{"huge", MemHugeStyle, func(m metrics.MemoryStats) uint64 { return min(m.HugePagesTotal, m.Used) }},
+	{"shm", MemSharedStyle, func(m metrics.MemoryStats) uint64 { return min(m.Shared, m.Cached) }},
+	{"buf", MemBuffersStyle, func(m metrics.MemoryStats) uint64 { return m.Buffers }},
+	// Reclaimable slab (dentries, inodes) behaves like page cache
+	{"cache", MemCacheStyle, func(m metrics.MemoryStats) uint64 { return sub(m.Cached, m.Shared) + m.SlabReclaimable }},
+}
+
+// memSegments turns the memory composition into stacked bar segments. The
+// apps segment turns red when usage crosses 80%, like the plain bar did.
+func memSegments(m metrics.MemoryStats) []barSegment {
+	if m.Total == 0 {
+		return nil
+	}
+	segments := make([]barSegment, len(memParts))
+	for i, part := range memParts {
+		style := part.style
+		if part.name == "apps" && m.UsedPercent > 80 {
+			style = AlertBarStyle
+		}
+		segments[i] = barSegment{percent: float64(part.value(m)) / float64(m.Total) * 100, style: style}
+	}
+	return segments
+}
+
+// renderMemLegend lists the non-empty parts of the composition in their
+// colours, followed by free memory.
+func renderMemLegend(m metrics.MemoryStats) string {
+	var parts []string
+	for _, part := range memParts {
+		v := part.value(m)
+		if v == 0 && part.name != "apps" && part.name != "cache" {
+			continue
+		}
+		parts = append(parts, part.style.Render(fmt.Sprintf("%s %s", part.name, formatBytesShort(v))))
+	}
+	parts = append(parts, MetricLabelStyle.Render("free "+formatBytesShort(m.Free)))
+	return strings.Join(parts, " ")
+}
+
+// renderMemDetails shows what the bar does not: how much can be allocated
+// without swapping, pending writeback, kernel memory and compression.
+func renderMemDetails(m metrics.MemoryStats) string {
+	parts := []string{
+		"avail " + formatBytesShort(m.Available),
+		fmt.Sprintf("dirty %s wb %s", formatBytesShort(m.Dirty), formatBytesShort(m.Writeback)),
+		"slab " + formatBytesShort(m.SlabReclaimable+m.SlabUnreclaimable),
+	}
+	if m.AnonHugePages > 0 {
+		parts = append(parts, "thp "+formatBytesShort(m.AnonHugePages))
+	}
+	if m.HugePagesTotal > 0 {
+		parts = append(parts, fmt.Sprintf("huge free %s", formatBytesShort(m.HugePagesFree)))
+	}
+	if m.ZswapStored > 0 {
+		parts = append(parts, fmt.Sprintf("zswap %s→%s", formatBytesShort(m.ZswapStored), formatBytesShort(m.ZswapPool)))
+	}
+	if m.ZramStored > 0 {
+		parts = append(parts, fmt.Sprintf("zram %s→%s", formatBytesShort(m.ZramStored), formatBytesShort(m.ZramUsed)))
+	}
+	return MetricLabelStyle.Render(strings.Join(parts, "  "))
+}
+
+// sub subtracts without wrapping around.
+func sub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestMemSegments(t *testing.T) {
+	const gib = 1 << 30
+	m := metrics.MemoryStats{
+		Total:           16 * gib,
+		Used:            6 * gib,
+		Free:            2 * gib,
+		UsedPercent:     37.5,
+		Buffers:         gib,
+		Cached:          6 * gib,
+		Shared:          2 * gib,
+		SlabReclaimable: gib,
+		HugePagesTotal:  2 * gib,
+	}
+
+	segments := memSegments(m)
+	want := []float64{25, 12.5, 12.5, 6.25, 31.25} // apps, huge, shm, buf, cache
+	total := 0.0
+	for i, seg := range segments {
+		if seg.percent != want[i] {
+			t.Errorf("%s segment is %.2f%%, want %.2f%%", memParts[i].name, seg.percent, want[i])
+		}
+		total += seg.percent
+	}
+	// Everything but free memory
+	if total != 87.5 {
+		t.Errorf("Expected segments to cover 87.5%%, got %.2f%%", total)
+	}
+
+	legend := renderMemLegend(m)
+	for _, part := range []string{"apps 4G", "huge 2G", "shm 2G", "buf 1G", "cache 5G", "free 2G"} {
+		if !strings.Contains(legend, part) {
+			t.Errorf("Legend %q is missing %q", legend, part)
+		}
+	}
+
+	bar := renderBarCompact(segments, 40, "Mem 37.5%")
+	if w := lipgloss.Width(bar); w != 40 {
+		t.Errorf("Expected a 40 column bar, got %d: %q", w, bar)
+	}
+}
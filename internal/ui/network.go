@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+// NetworkModel lists network interfaces with their rates and throughput
+// graph, busiest first.
+type NetworkModel struct {
+	width  int
+	height int
+	stats  metrics.NetStats
+	Alert  bool
+	Stale  bool // Values come from an earlier sample
+
+	ShowVirtual bool // Loopback, bridges, veths, tunnels
+}
+
+func NewNetworkModel() NetworkModel {
+	return NetworkModel{}
+}
+
+func (m NetworkModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m NetworkModel) Update(msg tea.Msg) (NetworkModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "x": // Toggle virtual interfaces
+			m.ShowVirtual = !m.ShowVirtual
+		}
+	}
+	return m, nil
+}
+
+func (m *NetworkModel) SetStats(stats metrics.NetStats) {
+	m.stats = stats
+}
+
+func (m *NetworkModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m NetworkModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+
+	style := PanelStyle
+	if m.Alert {
+		style = AlertPanelStyle
+	}
+	style = style.Copy().Width(m.width).Height(m.height)
+
+	title := fmt.Sprintf("Network  ↓ %s/s  ↑ %s/s", formatBytes(m.stats.DownloadSpeed), formatBytes(m.stats.UploadSpeed))
+	title = staleTitle(title, m.Stale)
+	filterStr := "* = in totals"
+	if m.ShowVirtual {
+		filterStr += "|+virt"
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Left,
+		TitleStyle.Render(title),
+		lipgloss.PlaceHorizontal(m.width-lipgloss.Width(title)-lipgloss.Width(filterStr)-5, lipgloss.Right, " "),
+		MetricLabelStyle.Render(fmt.Sprintf("[%s]", filterStr)),
+	)
+
+	ifaces := visibleInterfaces(m.stats.Interfaces, m.ShowVirtual)
+	lines := []string{header}
+	if len(ifaces) == 0 {
+		lines = append(lines, MetricLabelStyle.Render("No network interfaces"))
+	}
+	for i, iface := range ifaces {
+		// Each interface takes four lines: name, packets, graph and a spacer
+		if len(lines)+4 > m.height-2 {
+			lines = append(lines, MetricLabelStyle.Render(fmt.Sprintf("... %d more", len(ifaces)-i)))
+			break
+		}
+		lines = append(lines, renderNetInterface(iface, m.width-4)...)
+	}
+
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// renderNetInterface draws one interface: name, link state and rates,
+// packet/error/drop counters, and a throughput history graph.
+func renderNetInterface(iface metrics.NetInterface, width int) []string {
+	name := "  " + iface.Name
+	if iface.Aggregated {
+		name = "* " + iface.Name
+	}
+	if iface.OperState != "" {
+		name += " " + iface.OperState
+	}
+	if iface.Speed > 0 {
+		name += " " + formatLinkSpeed(iface.Speed)
+	}
+	nameStyle := TitleStyle
+	if iface.OperState == "down" {
+		nameStyle = MetricLabelStyle
+	}
+	rates := fmt.Sprintf("↓ %s/s  ↑ %s/s", formatBytes(iface.DownloadSpeed), formatBytes(iface.UploadSpeed))
+	nameLine := lipgloss.JoinHorizontal(lipgloss.Left,
+		nameStyle.Render(name),
+		lipgloss.PlaceHorizontal(width-lipgloss.Width(name)-lipgloss.Width(rates), lipgloss.Right, " "),
+		MetricValueStyle.Render(rates),
+	)
+
+	packets := MetricLabelStyle.Render(fmt.Sprintf("pkts ↓ %.0f/s ↑ %.0f/s  ", iface.PacketsRecvRate, iface.PacketsSentRate))
+	errStyle := MetricLabelStyle
+	if iface.ErrorsIn+iface.ErrorsOut+iface.DropsIn+iface.DropsOut > 0 {
+		errStyle = AlertStyle
+	}
+	errors := errStyle.Render(fmt.Sprintf("err %d/%d  drop %d/%d", iface.ErrorsIn, iface.ErrorsOut, iface.DropsIn, iface.DropsOut))
+
+	return []string{
+		nameLine,
+		packets + errors,
+		BarStyle.Render(renderSparkline(iface.History, width)),
+		"",
+	}
+}
+
+// visibleInterfaces hides loopback and virtual interfaces unless asked, but
+// always keeps the ones counted in the totals, and orders them by activity.
+func visibleInterfaces(ifaces []metrics.NetInterface, showVirtual bool) []metrics.NetInterface {
+	var out []metrics.NetInterface
+	for _, iface := range ifaces {
+		if iface.Virtual && !iface.Aggregated && !showVirtual {
+			continue
+		}
+		out = append(out, iface)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].DownloadSpeed+out[i].UploadSpeed, out[j].DownloadSpeed+out[j].UploadSpeed
+		if a != b {
+			return a > b
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// formatLinkSpeed renders a link speed given in Mbit/s.
+func formatLinkSpeed(mbps uint64) string {
+	if mbps >= 1000 {
+		return fmt.Sprintf("%gG", float64(mbps)/1000)
+	}
+	return fmt.Sprintf("%dM", mbps)
+}
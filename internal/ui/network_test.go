@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestVisibleInterfaces(t *testing.T) {
+	ifaces := []metrics.NetInterface{
+		{Name: "docker0", Virtual: true, DownloadSpeed: 500},
+		{Name: "eth0", Aggregated: true, DownloadSpeed: 100},
+		{Name: "lo", Loopback: true, Virtual: true},
+		{Name: "wg0", Virtual: true, Aggregated: true, UploadSpeed: 200},
+		{Name: "wlan0", Aggregated: true},
+	}
+
+	got := visibleInterfaces(ifaces, false)
+	if len(got) != 3 || got[0].Name != "wg0" || got[1].Name != "eth0" || got[2].Name != "wlan0" {
+		t.Errorf("Unexpected interfaces %+v", got)
+	}
+	if got := visibleInterfaces(ifaces, true); len(got) != 5 || got[0].Name != "docker0" {
+		t.Errorf("Expected all interfaces, busiest first, got %+v", got)
+	}
+}
+
+func TestFormatLinkSpeed(t *testing.T) {
+	cases := map[uint64]string{100: "100M", 1000: "1G", 2500: "2.5G", 10000: "10G"}
+	for mbps, want := range cases {
+		if got := formatLinkSpeed(mbps); got != want {
+			t.Errorf("formatLinkSpeed(%d) = %q, want %q", mbps, got, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+// StatsMsg delivers a sample collected in the background.
+type StatsMsg struct {
+	Stats *metrics.SystemStats
+	Err   error
+}
+
+// Poller collects metrics on its own goroutine and hands each sample to the
+// UI as a StatsMsg, so a slow collector never blocks input or rendering.
+//
+// Samples are taken on multiples of the interval on the wall clock, so they
+// stay evenly spaced however long each one takes, and subsystems sampled
+// less often line up with them.
+type Poller struct {
+	provider metrics.Provider
+	interval time.Duration
+	samples  chan StatsMsg
+	done     chan struct{}
+}
+
+// minPollInterval bounds how often PollInterval lets the poller wake up.
+const minPollInterval = 50 * time.Millisecond
+
+// PollInterval returns the poll interval serving all of intervals: their
+// greatest common divisor, so each subsystem is sampled on its own
+// multiples. When that would poll faster than every 50 ms (250 and 333 ms
+// give 1 ms) it returns the shortest interval instead, and the others round
+// up to its multiples, which exact reports.
+func PollInterval(intervals ...time.Duration) (interval time.Duration, exact bool) {
+	var shortest time.Duration
+	for _, d := range intervals {
+		if d <= 0 {
+			continue
+		}
+		if shortest == 0 || d < shortest {
+			shortest = d
+		}
+		a, b := interval, d
+		for b != 0 {
+			a, b = b, a%b
+		}
+		interval = a
+	}
+	if interval < shortest && interval < minPollInterval {
+		interval = shortest
+	}
+	exact = true
+	for _, d := range intervals {
+		if d > 0 && d%interval != 0 {
+			exact = false
+		}
+	}
+	return interval, exact
+}
+
+// NewPoller returns a poller sampling provider every interval, one second
+// when interval is not positive. With per-subsystem intervals it should
+// come from PollInterval.
+func NewPoller(provider metrics.Provider, interval time.Duration) *Poller {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Poller{
+		provider: provider,
+		interval: interval,
+		samples:  make(chan StatsMsg),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start collects in the background until ctx is cancelled.
+func (p *Poller) Start(ctx context.Context) {
+	go p.run(ctx)
+}
+
+// Wait blocks until the collection goroutine has returned, after which the
+// provider can be shut down.
+func (p *Poller) Wait() {
+	<-p.done
+}
+
+func (p *Poller) run(ctx context.Context) {
+	defer close(p.done)
+	defer close(p.samples)
+	for {
+		stats, err := p.provider.GetStats(ctx)
+		select {
+		case p.samples <- StatsMsg{Stats: stats, Err: err}:
+		case <-ctx.Done():
+			return
+		}
+
+		timer := time.NewTimer(time.Until(nextTick(time.Now(), p.interval)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextTick returns the first multiple of interval on the wall clock after
+// now. Scheduling from the clock rather than from the previous tick keeps
+// delays from adding up, and a sample that overran skips the ticks it
+// missed instead of firing them back to back.
+func nextTick(now time.Time, interval time.Duration) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
+
+// next waits for the next sample. Bubble Tea runs it on its own goroutine.
+func (p *Poller) next() tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-p.samples
+		if !ok {
+			return nil // Shutting down
+		}
+		return msg
+	}
+}
+
+// staleTitle marks a panel title when its collector missed the deadline and
+// the values shown come from an earlier sample.
+func staleTitle(title string, stale bool) string {
+	if stale {
+		return title + " [stale]"
+	}
+	return title
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/omnitop/internal/config"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestPoller(t *testing.T) {
+	provider := &metrics.MockProvider{}
+	if err := provider.Init(); err != nil {
+		t.Fatal(err)
+	}
+	poller := NewPoller(provider, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	poller.Start(ctx)
+
+	msg, ok := poller.next()().(StatsMsg)
+	if !ok || msg.Err != nil || msg.Stats == nil {
+		t.Fatalf("Expected a sample, got %+v", msg)
+	}
+
+	// Cancelling stops the collector mid-wait and ends pending receives
+	cancel()
+	poller.Wait()
+	if msg := poller.next()(); msg != nil {
+		t.Errorf("Expected no message after shutdown, got %T", msg)
+	}
+}
+
+func TestNextTick(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		now      time.Duration
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"on a boundary", 0, time.Second, time.Second},
+		{"late poll", 30 * time.Millisecond, time.Second, time.Second},
+		{"slow sample skips missed ticks", 2300 * time.Millisecond, time.Second, 3 * time.Second},
+		{"sub-second interval", 260 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := nextTick(base.Add(tt.now), tt.interval); !got.Equal(base.Add(tt.want)) {
+			t.Errorf("%s: got %v, want %v", tt.name, got.Sub(base), tt.want)
+		}
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		intervals []time.Duration
+		want      time.Duration
+		exact     bool
+	}{
+		{"single", []time.Duration{1000 * ms}, 1000 * ms, true},
+		{"multiples", []time.Duration{1000 * ms, 250 * ms, 2000 * ms}, 250 * ms, true},
+		{"common divisor", []time.Duration{1000 * ms, 300 * ms, 500 * ms}, 100 * ms, true},
+		{"divisor too small", []time.Duration{1000 * ms, 250 * ms, 333 * ms}, 250 * ms, false},
+		{"ignores unset", []time.Duration{0, 400 * ms}, 400 * ms, true},
+	}
+	for _, tt := range tests {
+		got, exact := PollInterval(tt.intervals...)
+		if got != tt.want || exact != tt.exact {
+			t.Errorf("%s: got %v (exact %v), want %v (exact %v)", tt.name, got, exact, tt.want, tt.exact)
+		}
+	}
+}
+
+func TestRootModelStale(t *testing.T) {
+	m := NewRootModel(nil, config.DefaultConfig())
+	m.width, m.height = 200, 50
+	m.resizeModules()
+
+	stats := &metrics.SystemStats{Stale: map[metrics.Subsystem]bool{metrics.SubsystemProcesses: true}}
+	m.setStale(stats.Stale)
+	if !m.process.Stale || m.cpu.Stale || m.gpu.Stale {
+		t.Fatalf("Expected only the process panel stale")
+	}
+	if view := m.process.View(); !strings.Contains(view, "[stale]") {
+		t.Errorf("Expected a stale marker:\n%s", view)
+	}
+	if view := m.cpu.View(); strings.Contains(view, "[stale]") {
+		t.Errorf("Expected no stale marker on the CPU panel:\n%s", view)
+	}
+}
+
+func TestRootModelFilterKeys(t *testing.T) {
+	var model tea.Model = NewRootModel(nil, config.DefaultConfig())
+	press := func(keys ...tea.KeyMsg) {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// Shortcut keys are text while the process filter is typed
+	press(runes("/"), runes("v"), runes("e"), runes("<"), runes("["), runes("q"), tea.KeyMsg{Type: tea.KeyTab})
+	m := model.(RootModel)
+	if m.middle != panelProcesses || m.gpu.viewMode != GPUViewSingle || m.gpu.showDetails || m.col1Pct != 0.30 {
+		t.Fatalf("Expected no shortcuts while filtering, got middle %d, GPU view %d, details %v, column %.2f",
+			m.middle, m.gpu.viewMode, m.gpu.showDetails, m.col1Pct)
+	}
+	if m.process.filter != "ve<[q" {
+		t.Errorf("Expected the keys in the filter, got %q", m.process.filter)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc}, runes("v"), tea.KeyMsg{Type: tea.KeyTab})
+	if m := model.(RootModel); m.gpu.viewMode == GPUViewSingle || m.middle != panelDisks {
+		t.Errorf("Expected shortcuts after the filter closes, got GPU view %d, middle %d", m.gpu.viewMode, m.middle)
+	}
+}
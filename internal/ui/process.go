@@ -21,6 +21,8 @@ const (
 	SortCPU SortBy = iota
 	SortMem
 	SortPID
+	SortGPU
+	SortEnergy
 )
 
 type ProcessModel struct {
@@ -31,8 +33,22 @@ type ProcessModel struct {
 	sortBy    SortBy
 	filter    string
 	filtering bool
+	gpuOnly   bool // Only show processes using a GPU
 	textInput textinput.Model
 	Alert     bool
+	Stale     bool // Values come from an earlier sample
+
+	// MaxRows caps the processes listed after sorting and filtering; zero
+	// lists all of them
+	MaxRows int
+	total   int // Processes matching the filter, before MaxRows
+
+	// Configured full-scale throughput of the Net and Disk bars in bytes per
+	// second, by interface or device name or glob pattern
+	NetMaxSpeeds  map[string]uint64
+	DiskMaxSpeeds map[string]uint64
+	netScale      ioScale
+	diskScale     ioScale
 }
 
 func NewProcessModel() ProcessModel {
@@ -41,6 +57,9 @@ func NewProcessModel() ProcessModel {
 		{Title: "User", Width: 10},
 		{Title: "CPU%", Width: 6},
 		{Title: "Mem%", Width: 6},
+		{Title: "GPU", Width: 7},
+		{Title: "Stall", Width: 6},
+		{Title: "Energy", Width: 7},
 		{Title: "Command", Width: 20},
 	}
 
@@ -108,9 +127,12 @@ func (m ProcessModel) Update(msg tea.Msg) (ProcessModel, tea.Cmd) {
 			m.table.Blur()
 			return m, textinput.Blink
 		case "s":
-			m.sortBy = (m.sortBy + 1) % 3
+			m.sortBy = (m.sortBy + 1) % 5
 			// Re-sort
 			m.SetStats(m.stats)
+		case "o": // Toggle GPU-only filter
+			m.gpuOnly = !m.gpuOnly
+			m.SetStats(m.stats)
 		case "k", "f9":
 			if len(m.table.SelectedRow()) > 0 {
 				pidStr := m.table.SelectedRow()[0]
@@ -157,13 +179,18 @@ func (m ProcessModel) Update(msg tea.Msg) (ProcessModel, tea.Cmd) {
 
 func (m *ProcessModel) SetStats(stats metrics.SystemStats) {
 	m.stats = stats
+	m.netScale.update(stats.Timestamp, netCapacity(stats.Net.Interfaces, m.NetMaxSpeeds), stats.Net.DownloadSpeed, stats.Net.UploadSpeed)
+	m.diskScale.update(stats.Timestamp, diskCapacity(stats.Disk.Devices, m.DiskMaxSpeeds), stats.Disk.ReadSpeed, stats.Disk.WriteSpeed)
 	procs := stats.Processes
 
 	// Filter
 	var filtered []metrics.ProcessInfo
-	if m.filter != "" {
+	if m.filter != "" || m.gpuOnly {
 		lowerFilter := strings.ToLower(m.filter)
 		for _, p := range procs {
+			if m.gpuOnly && !p.IsGPUUser {
+				continue
+			}
 			if strings.Contains(strings.ToLower(p.Command), lowerFilter) ||
 				strings.Contains(strings.ToLower(p.User), lowerFilter) ||
 				fmt.Sprintf("%d", p.PID) == lowerFilter {
@@ -189,6 +216,26 @@ func (m *ProcessModel) SetStats(stats metrics.SystemStats) {
 		sort.Slice(filtered, func(i, j int) bool {
 			return filtered[i].PID < filtered[j].PID
 		})
+	case SortGPU:
+		// GPU users first, heaviest VRAM consumers on top
+		sort.Slice(filtered, func(i, j int) bool {
+			if filtered[i].IsGPUUser != filtered[j].IsGPUUser {
+				return filtered[i].IsGPUUser
+			}
+			if filtered[i].GPUMemory != filtered[j].GPUMemory {
+				return filtered[i].GPUMemory > filtered[j].GPUMemory
+			}
+			return filtered[i].CPUPercent > filtered[j].CPUPercent
+		})
+	case SortEnergy:
+		sort.Slice(filtered, func(i, j int) bool {
+			return filtered[i].Energy > filtered[j].Energy
+		})
+	}
+
+	m.total = len(filtered)
+	if m.MaxRows > 0 && len(filtered) > m.MaxRows {
+		filtered = filtered[:m.MaxRows]
 	}
 
 	rows := make([]table.Row, len(filtered))
@@ -198,18 +245,25 @@ func (m *ProcessModel) SetStats(stats metrics.SystemStats) {
 			p.User,
 			fmt.Sprintf("%.1f", p.CPUPercent),
 			fmt.Sprintf("%.1f", p.MemPercent),
+			formatGPUColumn(p),
+			formatStallColumn(p),
+			formatEnergy(p.Energy),
 			p.Command,
 		}
 	}
 	m.table.SetRows(rows)
 }
 
+// minCommandWidth is the narrowest the Command column gets before optional
+// columns make way for it.
+const minCommandWidth = 8
+
 func (m *ProcessModel) SetSize(w, h int) {
 	m.width = w
 	m.height = h
 
 	// Calculate available height for table
-	tableHeight := h - 4
+	tableHeight := h - 7
 	if tableHeight < 1 {
 		tableHeight = 1
 	}
@@ -223,13 +277,25 @@ func (m *ProcessModel) SetSize(w, h int) {
 	cols[1].Width = 10 // User
 	cols[2].Width = 6  // CPU
 	cols[3].Width = 6  // Mem
-
-	usedWidth := 6 + 10 + 6 + 6 + 10 // + padding
-	remaining := w - usedWidth
-	if remaining < 10 {
-		remaining = 10
+	cols[4].Width = 7  // GPU
+	cols[5].Width = 6  // Stall
+	cols[6].Width = 7  // Energy
+
+	// The table sits inside the panel padding and pads each cell by one
+	// on either side. When Command would get too narrow, Energy, Stall and
+	// GPU are dropped in that order; the table skips zero-width columns.
+	remaining := w - 2 - 2*len(cols)
+	for _, col := range cols[:7] {
+		remaining -= col.Width
 	}
-	cols[4].Width = remaining
+	for _, i := range []int{6, 5, 4} {
+		if remaining >= minCommandWidth {
+			break
+		}
+		remaining += cols[i].Width + 2
+		cols[i].Width = 0
+	}
+	cols[7].Width = max(remaining, minCommandWidth)
 	m.table.SetColumns(cols)
 }
 
@@ -250,6 +316,13 @@ func (m ProcessModel) View() string {
 	} else if m.filter != "" {
 		title = fmt.Sprintf("Filter: %s", m.filter)
 	}
+	if shown := len(m.table.Rows()); shown < m.total {
+		title += fmt.Sprintf(" showing %d of %d", shown, m.total)
+	} else {
+		title += fmt.Sprintf(" (%d)", m.total)
+	}
+
+	title = staleTitle(title, m.Stale)
 
 	sortStr := "CPU"
 	switch m.sortBy {
@@ -257,6 +330,13 @@ func (m ProcessModel) View() string {
 		sortStr = "MEM"
 	case SortPID:
 		sortStr = "PID"
+	case SortGPU:
+		sortStr = "GPU"
+	case SortEnergy:
+		sortStr = "ENERGY"
+	}
+	if m.gpuOnly {
+		sortStr += "|GPU only"
 	}
 
 	header := lipgloss.JoinHorizontal(lipgloss.Left,
@@ -266,40 +346,80 @@ func (m ProcessModel) View() string {
 	)
 
 	// Render Memory/Net/Disk bars at bottom
-	memBar := renderBar(int(m.stats.Memory.UsedPercent), 100, m.width-4, fmt.Sprintf("Mem %.1f%%", m.stats.Memory.UsedPercent))
+	// Memory as a composition of apps, shared memory, buffers and cache
+	memBar := renderBarCompact(memSegments(m.stats.Memory), m.width-4, fmt.Sprintf("Mem %.1f%%", m.stats.Memory.UsedPercent))
 	swapBar := renderBar(int(m.stats.Memory.SwapPercent), 100, m.width-4, fmt.Sprintf("Swap %.1f%%", m.stats.Memory.SwapPercent))
 
-	// Net/Disk (simple bars for speed/activity)
-	// Use 100MB/s as arbitrary max for visualization for now
-	const maxIO = 100 * 1024 * 1024 // 100MB
-
-	// We need speed (bytes/sec) but stats.Net is Total Bytes.
-	// ProcessModel doesn't store previous stats to calc speed?
-	// metrics.SystemStats has DiskStats which has ReadSpeed/WriteSpeed?
-	// Let's check internal/metrics/types.go. Yes!
-
-	netDownBar := renderBar(int(m.stats.Net.DownloadSpeed), maxIO, m.width/2-2, fmt.Sprintf("Net ↓ %s/s", formatBytes(m.stats.Net.DownloadSpeed)))
-	netUpBar := renderBar(int(m.stats.Net.UploadSpeed), maxIO, m.width/2-2, fmt.Sprintf("Net ↑ %s/s", formatBytes(m.stats.Net.UploadSpeed)))
+	// Net/Disk bars scaled to the link speed or disk capability, or to a
+	// recent peak when that is unknown
+	net, disk := m.stats.Net, m.stats.Disk
+	netDownBar := renderBar(m.netScale.percent(net.DownloadSpeed), 100, m.width/2-2, fmt.Sprintf("Net ↓ %s/s %s", formatBytes(net.DownloadSpeed), m.netScale.label()))
+	netUpBar := renderBar(m.netScale.percent(net.UploadSpeed), 100, m.width/2-2, fmt.Sprintf("Net ↑ %s/s %s", formatBytes(net.UploadSpeed), m.netScale.label()))
 
-	diskReadBar := renderBar(int(m.stats.Disk.ReadSpeed), maxIO, m.width/2-2, fmt.Sprintf("Disk R %s/s", formatBytes(m.stats.Disk.ReadSpeed)))
-	diskWriteBar := renderBar(int(m.stats.Disk.WriteSpeed), maxIO, m.width/2-2, fmt.Sprintf("Disk W %s/s", formatBytes(m.stats.Disk.WriteSpeed)))
+	diskReadBar := renderBar(m.diskScale.percent(disk.ReadSpeed), 100, m.width/2-2, fmt.Sprintf("Disk R %s/s %s", formatBytes(disk.ReadSpeed), m.diskScale.label()))
+	diskWriteBar := renderBar(m.diskScale.percent(disk.WriteSpeed), 100, m.width/2-2, fmt.Sprintf("Disk W %s/s %s", formatBytes(disk.WriteSpeed), m.diskScale.label()))
 
 	ioRow1 := lipgloss.JoinHorizontal(lipgloss.Top, netDownBar, " ", netUpBar)
 	ioRow2 := lipgloss.JoinHorizontal(lipgloss.Top, diskReadBar, " ", diskWriteBar)
 
-	headerText := fmt.Sprintf("Processes (Sort: %s) [c:CPU m:MEM p:PID k:Kill]", strings.ToUpper(m.sortBy))
+	// Memory and IO stalls, "some" with "full" in parentheses
+	pressureStr := "Stall: N/A"
+	if psi := m.stats.Pressure; psi.Memory.Available || psi.IO.Available {
+		pressureStr = fmt.Sprintf("Stall mem %.1f%% (%.1f%%)  io %.1f%% (%.1f%%)",
+			psi.Memory.Some.Avg10, psi.Memory.Full.Avg10, psi.IO.Some.Avg10, psi.IO.Full.Avg10)
+	}
+	pressure := MetricLabelStyle.Render(pressureStr)
 
 	return style.Render(lipgloss.JoinVertical(lipgloss.Left,
 		header,
 		m.table.View(),
 		"\n",
 		memBar,
+		renderMemLegend(m.stats.Memory),
+		renderMemDetails(m.stats.Memory),
 		swapBar,
 		ioRow1,
 		ioRow2,
+		pressure,
 	))
 }
 
+// formatGPUColumn shows a process's VRAM footprint, or a marker when it uses
+// a GPU but the driver does not report per-process memory.
+func formatGPUColumn(p metrics.ProcessInfo) string {
+	if !p.IsGPUUser {
+		return ""
+	}
+	if p.GPUMemory == 0 {
+		return "*"
+	}
+	return fmt.Sprintf("%dM", p.GPUMemory/1024/1024)
+}
+
+// formatStallColumn shows the worse of the CPU and memory "some" avg10 stall
+// of a process's cgroup, blank when the cgroup exposes no PSI.
+func formatStallColumn(p metrics.ProcessInfo) string {
+	if !p.CPUStall.Available && !p.MemStall.Available {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", max(p.CPUStall.Some.Avg10, p.MemStall.Some.Avg10))
+}
+
+// formatEnergy renders the estimated joules a process has drawn, e.g.
+// "850J" or "12.3kJ", blank when nothing was attributed to it.
+func formatEnergy(joules float64) string {
+	switch {
+	case joules < 0.5:
+		return ""
+	case joules < 1000:
+		return fmt.Sprintf("%.0fJ", joules)
+	case joules < 1e6:
+		return fmt.Sprintf("%.1fkJ", joules/1e3)
+	default:
+		return fmt.Sprintf("%.1fMJ", joules/1e6)
+	}
+}
+
 func formatBytes(bytes uint64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestProcessModelGPUSortAndFilter(t *testing.T) {
+	stats := metrics.SystemStats{
+		Processes: []metrics.ProcessInfo{
+			{PID: 1, Command: "init", CPUPercent: 50, Energy: 12345},
+			{PID: 2, Command: "train", CPUPercent: 10, IsGPUUser: true, GPUMemory: 2 << 30},
+			{PID: 3, Command: "render", CPUPercent: 5, IsGPUUser: true, GPUMemory: 4 << 30,
+				CPUStall: metrics.Pressure{Available: true, Some: metrics.PressureLine{Avg10: 3.25}},
+				MemStall: metrics.Pressure{Available: true, Some: metrics.PressureLine{Avg10: 7.5}}},
+		},
+	}
+
+	m := NewProcessModel()
+	m.SetSize(80, 30)
+	m.sortBy = SortGPU
+	m.SetStats(stats)
+
+	rows := m.table.Rows()
+	if len(rows) != 3 || rows[0][0] != "3" || rows[1][0] != "2" {
+		t.Fatalf("Expected GPU users sorted by VRAM first, got %v", rows)
+	}
+	if rows[0][4] != "4096M" || rows[2][4] != "" {
+		t.Errorf("Unexpected GPU column values: %q, %q", rows[0][4], rows[2][4])
+	}
+	if rows[0][5] != "7.5" || rows[2][5] != "" {
+		t.Errorf("Unexpected Stall column values: %q, %q", rows[0][5], rows[2][5])
+	}
+
+	if rows[2][6] != "12.3kJ" || rows[0][6] != "" || rows[0][7] != "render" {
+		t.Errorf("Unexpected Energy column values: %q, %q", rows[2][6], rows[0][6])
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if m.sortBy != SortEnergy || m.table.Rows()[0][0] != "1" {
+		t.Errorf("Expected the energy sort after GPU, got %v", m.table.Rows())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if rows := m.table.Rows(); len(rows) != 2 {
+		t.Errorf("GPU-only filter should leave 2 processes, got %d", len(rows))
+	}
+}
+
+func TestProcessModelMaxRows(t *testing.T) {
+	var stats metrics.SystemStats
+	for pid := int32(1); pid <= 10; pid++ {
+		// The highest PIDs are the busiest
+		stats.Processes = append(stats.Processes, metrics.ProcessInfo{PID: pid, Command: "worker", CPUPercent: float64(pid)})
+	}
+
+	m := NewProcessModel()
+	m.MaxRows = 3
+	m.SetSize(80, 30)
+	m.SetStats(stats)
+
+	rows := m.table.Rows()
+	if len(rows) != 3 || rows[0][0] != "10" || rows[2][0] != "8" {
+		t.Fatalf("Expected the 3 busiest processes, got %v", rows)
+	}
+	if view := m.View(); !strings.Contains(view, "showing 3 of 10") {
+		t.Errorf("Expected the total in the title:\n%s", view)
+	}
+
+	m.MaxRows = 0
+	m.SetStats(stats)
+	if rows := m.table.Rows(); len(rows) != 10 {
+		t.Errorf("Expected every process without a cap, got %d", len(rows))
+	}
+}
+
+func TestProcessModelNarrow(t *testing.T) {
+	stats := metrics.SystemStats{
+		Processes: []metrics.ProcessInfo{
+			{PID: 123456, User: "someuser", Command: "python train.py --epochs 100", IsGPUUser: true, GPUMemory: 1 << 30},
+		},
+	}
+
+	m := NewProcessModel()
+	m.SetSize(48, 30)
+	m.SetStats(stats)
+
+	view := m.View()
+	for _, line := range strings.Split(view, "\n") {
+		// The border adds a column on each side
+		if w := lipgloss.Width(line); w > 50 {
+			t.Fatalf("Expected lines to fit 48 columns, got %d:\n%s", w, view)
+		}
+	}
+	header := strings.Split(view, "\n")[2]
+	for _, col := range []string{"Energy", "Stall", "GPU"} {
+		if strings.Contains(header, col) {
+			t.Errorf("Expected %s to be dropped at 48 columns: %q", col, header)
+		}
+	}
+	if !strings.Contains(header, "PID") || !strings.Contains(header, "Command") {
+		t.Errorf("Expected PID and Command to stay: %q", header)
+	}
+
+	m.SetSize(100, 30)
+	header = strings.Split(m.View(), "\n")[2]
+	for _, col := range []string{"Energy", "Stall", "GPU", "Command"} {
+		if !strings.Contains(header, col) {
+			t.Errorf("Expected %s at 100 columns: %q", col, header)
+		}
+	}
+}
@@ -2,7 +2,8 @@ package ui
 
 import (
 	"fmt"
-	"math/rand"
+	"log"
+	"math"
 	"os/exec"
 	"time"
 
@@ -12,31 +13,32 @@ import (
 	"github.com/google/omnitop/internal/metrics"
 )
 
-type TickMsg time.Time
+// middlePanel selects what the middle column shows.
+type middlePanel int
 
-var tickRand = rand.New(rand.NewSource(time.Now().UnixNano()))
-
-func tick(interval int) tea.Cmd {
-	// Add jitter
-	jitter := time.Duration(tickRand.Intn(200)-100) * time.Millisecond
-	base := time.Duration(interval) * time.Millisecond
-	if base <= 0 {
-		base = time.Second
-	}
-	return tea.Tick(base+jitter, func(t time.Time) tea.Msg {
-		return TickMsg(t)
-	})
-}
+const (
+	panelProcesses middlePanel = iota
+	panelDisks
+	panelFilesystems
+	panelNetwork
+	panelSensors
+	numMiddlePanels
+)
 
 type RootModel struct {
-	provider metrics.Provider
-	config   *config.ProfileConfiguration
+	poller *Poller
+	config *config.ProfileConfiguration
 
 	// Sub-models
 	gpu     GPUModel
 	process ProcessModel
+	disk    DiskModel
+	fs      FilesystemModel
+	net     NetworkModel
+	sensors SensorsModel
 	cpu     CPUModel
 	footer  FooterModel
+	middle  middlePanel // Panel shown in the middle column (Tab cycles)
 
 	// Layout state
 	width, height int
@@ -51,11 +53,25 @@ type RootModel struct {
 	lastAlertTime  time.Time
 }
 
-func NewRootModel(provider metrics.Provider, cfg *config.ProfileConfiguration) RootModel {
+func NewRootModel(poller *Poller, cfg *config.ProfileConfiguration) RootModel {
 	// Defaults if config is missing values
 	col1 := 0.30
 	col2 := 0.40
+	disk := NewDiskModel()
+	fs := NewFilesystemModel()
+	net := NewNetworkModel()
+	process := NewProcessModel()
+	sensors := NewSensorsModel()
 	if cfg != nil {
+		sensors.Renames = cfg.Sensors.Rename
+		sensors.Hidden = cfg.Sensors.Hide
+		process.NetMaxSpeeds = scaleSpeeds(cfg.Network.MaxSpeeds, 1000*1000/8) // Mbit/s
+		process.DiskMaxSpeeds = scaleSpeeds(cfg.Disks.MaxSpeeds, 1<<20)        // MB/s
+		process.MaxRows = cfg.MaxProcesses
+		net.ShowVirtual = cfg.Network.ShowVirtual
+		fs.Threshold = cfg.AlertThresholds.DiskUsagePercent
+		disk.ShowPartitions = cfg.Disks.ShowPartitions
+		disk.ShowVirtual = cfg.Disks.ShowVirtual
 		if v, ok := cfg.ColumnWidths["gpu"]; ok {
 			col1 = v
 		}
@@ -65,23 +81,38 @@ func NewRootModel(provider metrics.Provider, cfg *config.ProfileConfiguration) R
 	}
 
 	return RootModel{
-		provider: provider,
-		config:   cfg,
-		gpu:      NewGPUModel(),
-		process:  NewProcessModel(),
-		cpu:      NewCPUModel(),
-		footer:   NewFooterModel(),
-		col1Pct:  col1,
-		col2Pct:  col2,
+		poller:  poller,
+		config:  cfg,
+		gpu:     NewGPUModel(),
+		process: process,
+		disk:    disk,
+		fs:      fs,
+		net:     net,
+		sensors: sensors,
+		cpu:     NewCPUModel(),
+		footer:  NewFooterModel(),
+		col1Pct: col1,
+		col2Pct: col2,
+	}
+}
+
+// scaleSpeeds converts configured speeds to bytes per second.
+func scaleSpeeds(speeds map[string]uint64, unit uint64) map[string]uint64 {
+	if len(speeds) == 0 {
+		return nil
+	}
+	out := make(map[string]uint64, len(speeds))
+	for name, v := range speeds {
+		out[name] = v * unit
 	}
+	return out
 }
 
 func (m RootModel) Init() tea.Cmd {
-	interval := 1000
-	if m.config != nil {
-		interval = m.config.RefreshInterval
+	if m.poller == nil {
+		return nil
 	}
-	return tick(interval)
+	return m.poller.next()
 }
 
 func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -90,12 +121,23 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// While the process filter is typed, keys are text for it rather
+		// than shortcuts; only ctrl+c still quits.
+		if m.middle == panelProcesses && m.process.filtering && msg.String() != "ctrl+c" {
+			m.process, cmd = m.process.Update(msg)
+			cmds = append(cmds, cmd)
+			break
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
 			// Save config on exit
 			m.config.ColumnWidths["gpu"] = m.col1Pct
 			m.config.ColumnWidths["process"] = m.col2Pct
 			m.config.ColumnWidths["cpu"] = 1.0 - m.col1Pct - m.col2Pct
+			m.config.Disks.ShowPartitions = m.disk.ShowPartitions
+			m.config.Disks.ShowVirtual = m.disk.ShowVirtual
+			m.config.Network.ShowVirtual = m.net.ShowVirtual
 			// Best effort save to profiles.json
 			if err := config.SaveConfig("profiles.json", m.config); err != nil {
 				log.Printf("Failed to save config: %v", err)
@@ -127,10 +169,23 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.resizeModules()
 		case "t": // Toggle Tooltips
 			m.showTooltip = !m.showTooltip
+		case "tab": // Cycle middle column panels
+			m.middle = (m.middle + 1) % numMiddlePanels
 		}
 
 		// Pass keys to sub-models
-		m.process, cmd = m.process.Update(msg)
+		switch m.middle {
+		case panelProcesses:
+			m.process, cmd = m.process.Update(msg)
+		case panelDisks:
+			m.disk, cmd = m.disk.Update(msg)
+		case panelFilesystems:
+			m.fs, cmd = m.fs.Update(msg)
+		case panelNetwork:
+			m.net, cmd = m.net.Update(msg)
+		case panelSensors:
+			m.sensors, cmd = m.sensors.Update(msg)
+		}
 		cmds = append(cmds, cmd)
 		m.gpu, cmd = m.gpu.Update(msg) // GPU toggle process list
 		cmds = append(cmds, cmd)
@@ -140,21 +195,21 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		m.resizeModules()
 
-	case TickMsg:
-		// Fetch metrics
-		stats, err := m.provider.GetStats()
-		if err == nil {
-			m.gpu.SetStats(stats.GPU)
+	case StatsMsg:
+		if stats := msg.Stats; msg.Err == nil && stats != nil {
+			m.gpu.SetStats(stats.GPUs)
 			m.process.SetStats(*stats)
+			m.disk.SetStats(stats.Disk)
+			m.fs.SetStats(stats.Filesystems)
+			m.net.SetStats(stats.Net)
+			m.sensors.SetStats(stats.Sensors)
+			m.footer.SetStats(stats.PowerSupply)
 			m.cpu.SetStats(*stats)
+			m.setStale(stats.Stale)
 			m.checkAlerts(stats)
 		}
-		// Continue tick
-		interval := 1000
-		if m.config != nil {
-			interval = m.config.RefreshInterval
-		}
-		cmds = append(cmds, tick(interval))
+		// Wait for the next sample
+		cmds = append(cmds, m.poller.next())
 
 	case tea.MouseMsg:
 		m.mouseX = msg.X
@@ -166,43 +221,110 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Pass mouse to sub-models
-		m.process, cmd = m.process.Update(msg)
-		cmds = append(cmds, cmd)
+		if m.middle == panelProcesses {
+			m.process, cmd = m.process.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// setStale flags the panels showing subsystems whose collector missed its
+// deadline.
+func (m *RootModel) setStale(stale map[metrics.Subsystem]bool) {
+	m.gpu.Stale = stale[metrics.SubsystemGPU]
+	m.process.Stale = stale[metrics.SubsystemProcesses] || stale[metrics.SubsystemMemory]
+	m.disk.Stale = stale[metrics.SubsystemDisks]
+	m.fs.Stale = stale[metrics.SubsystemFilesystems]
+	m.net.Stale = stale[metrics.SubsystemNetwork]
+	m.sensors.Stale = stale[metrics.SubsystemSensors]
+	m.footer.Stale = stale[metrics.SubsystemSensors]
+	m.cpu.Stale = stale[metrics.SubsystemCPU]
+}
+
 func (m *RootModel) checkAlerts(stats *metrics.SystemStats) {
 	if m.config == nil {
 		return
 	}
 
-	// Check CPU
-	cpuAlert := stats.CPU.GlobalUsagePercent > m.config.AlertThresholds.CPUUsagePercent
+	// Check CPU usage and temperature
+	cpuTemp := stats.CPU.PackageTemp
+	for _, t := range stats.CPU.PerCoreTemp {
+		cpuTemp = math.Max(cpuTemp, t)
+	}
+	cpuUsageAlert := stats.CPU.GlobalUsagePercent > m.config.AlertThresholds.CPUUsagePercent
+	cpuTempAlert := m.config.AlertThresholds.CPUTempCelsius > 0 && cpuTemp > m.config.AlertThresholds.CPUTempCelsius
+	thresholds := m.config.AlertThresholds
+	psi := stats.Pressure
+	cpuPressureAlert := thresholds.CPUPressurePercent > 0 && psi.CPU.Some.Avg10 > thresholds.CPUPressurePercent
+	cpuAlert := cpuUsageAlert || cpuTempAlert || cpuPressureAlert
 	m.cpu.Alert = cpuAlert
 
-	// Check GPU
-	gpuAlert := stats.GPU.Available && (float64(stats.GPU.Utilization) > m.config.AlertThresholds.GPUUsagePercent || float64(stats.GPU.Temperature) > m.config.AlertThresholds.GPUTempCelsius)
+	// Check GPUs (any device over threshold raises the alert)
+	gpuAlert := false
+	var gpuAlertMsg string
+	for _, gpu := range stats.GPUs {
+		if gpu.Available && (float64(gpu.Utilization) > m.config.AlertThresholds.GPUUsagePercent || float64(gpu.Temperature) > m.config.AlertThresholds.GPUTempCelsius) {
+			gpuAlert = true
+			gpuAlertMsg += fmt.Sprintf("GPU%d %d%% %d°C ", gpu.Index, gpu.Utilization, gpu.Temperature)
+		}
+	}
 	m.gpu.Alert = gpuAlert
 
 	// Check Memory (in Process module)
-	memAlert := stats.Memory.UsedPercent > m.config.AlertThresholds.MemoryUsagePercent
+	memUsageAlert := stats.Memory.UsedPercent > m.config.AlertThresholds.MemoryUsagePercent
+	memPressureAlert := thresholds.MemoryPressurePercent > 0 && psi.Memory.Some.Avg10 > thresholds.MemoryPressurePercent
+	ioPressureAlert := thresholds.IOPressurePercent > 0 && psi.IO.Some.Avg10 > thresholds.IOPressurePercent
+	memAlert := memUsageAlert || memPressureAlert || ioPressureAlert
 	m.process.Alert = memAlert
 
+	// Check filesystems (writable mounts over the disk usage threshold)
+	var fsAlertMsg string
+	for _, fs := range stats.Filesystems {
+		if FilesystemOverThreshold(fs, m.config.AlertThresholds.DiskUsagePercent) {
+			fsAlertMsg += fmt.Sprintf("%s %.0f%% ", fs.Mount, max(fs.UsedPercent, fs.InodesPercent))
+		}
+	}
+	fsAlert := fsAlertMsg != ""
+	m.fs.Alert = fsAlert
+
+	// Check battery (only while it runs the machine)
+	batteryPercent, onBattery := BatteryPercent(stats.PowerSupply)
+	batteryAlert := thresholds.LowBatteryPercent > 0 && onBattery && batteryPercent < thresholds.LowBatteryPercent
+	m.footer.Alert = batteryAlert
+
 	// Notify
-	if (cpuAlert || gpuAlert || memAlert) && time.Since(m.lastAlertTime) > 10*time.Second {
+	if (cpuAlert || gpuAlert || memAlert || fsAlert || batteryAlert) && time.Since(m.lastAlertTime) > 10*time.Second {
 		m.lastAlertTime = time.Now()
 		msg := "System Alert: "
-		if cpuAlert {
+		if cpuUsageAlert {
 			msg += fmt.Sprintf("CPU %.0f%% ", stats.CPU.GlobalUsagePercent)
 		}
+		if cpuTempAlert {
+			msg += fmt.Sprintf("CPU %.0f°C ", cpuTemp)
+		}
 		if gpuAlert {
-			msg += fmt.Sprintf("GPU %d%% ", stats.GPU.Utilization)
+			msg += gpuAlertMsg
+		}
+		if cpuPressureAlert {
+			msg += fmt.Sprintf("CPU stall %.0f%% ", psi.CPU.Some.Avg10)
 		}
-		if memAlert {
+		if memUsageAlert {
 			msg += fmt.Sprintf("Mem %.0f%% ", stats.Memory.UsedPercent)
 		}
+		if memPressureAlert {
+			msg += fmt.Sprintf("Mem stall %.0f%% ", psi.Memory.Some.Avg10)
+		}
+		if ioPressureAlert {
+			msg += fmt.Sprintf("IO stall %.0f%% ", psi.IO.Some.Avg10)
+		}
+		if fsAlert {
+			msg += fsAlertMsg
+		}
+		if batteryAlert {
+			msg += fmt.Sprintf("Battery %.0f%% ", batteryPercent)
+		}
 
 		// Run in background
 		go exec.Command("notify-send", "-u", "critical", "OmniTop Alert", msg).Run()
@@ -223,14 +345,30 @@ func (m *RootModel) updateTooltip() {
 		// GPU
 		m.showTooltip = true
 		m.tooltipContent = "GPU Stats:\nUtilization of graphics core\nand VRAM usage."
+	} else if m.mouseX < w1+w2 && m.middle == panelDisks {
+		// Disks
+		m.showTooltip = true
+		m.tooltipContent = "Disks:\nPer-device throughput, IOPS,\nawait and %util, busiest first.\nPartitions: p, Virtual: x"
+	} else if m.mouseX < w1+w2 && m.middle == panelFilesystems {
+		// Filesystems
+		m.showTooltip = true
+		m.tooltipContent = "Filesystems:\nSpace and inode usage per mount.\nFull-in estimate from recent growth."
+	} else if m.mouseX < w1+w2 && m.middle == panelNetwork {
+		// Network
+		m.showTooltip = true
+		m.tooltipContent = "Network:\nPer-interface rates, packets,\nerrors and drops. * = in Net totals.\nVirtual: x"
+	} else if m.mouseX < w1+w2 && m.middle == panelSensors {
+		// Sensors
+		m.showTooltip = true
+		m.tooltipContent = "Sensors:\nEvery hwmon chip: temps, fans,\nvoltages, current and power.\nRed = near crit, past max or under min."
 	} else if m.mouseX < w1+w2 {
 		// Process
 		m.showTooltip = true
-		m.tooltipContent = "Processes:\nList of active tasks.\nSort by CPU/MEM.\nKill: k, Renice: []"
+		m.tooltipContent = "Processes:\nList of active tasks.\nSort by CPU/MEM/PID/GPU/ENERGY.\nEnergy: est. joules from CPU/GPU share\nKill: k, Renice: []\nMem bar: apps|huge|shm|buf|cache"
 	} else {
 		// CPU
 		m.showTooltip = true
-		m.tooltipContent = "CPU Stats:\nPer-core usage bars.\nLoad Avg: 1/5/15m."
+		m.tooltipContent = "CPU Stats:\nPer-core usage bars.\nLoad Avg: 1/5/15m.\nPower: RAPL package/core/uncore/dram."
 	}
 }
 
@@ -252,6 +390,10 @@ func (m *RootModel) resizeModules() {
 
 	m.gpu.SetSize(w1, h)
 	m.process.SetSize(w2, h)
+	m.disk.SetSize(w2, h)
+	m.fs.SetSize(w2, h)
+	m.net.SetSize(w2, h)
+	m.sensors.SetSize(w2, h)
 	m.cpu.SetSize(w3, h)
 	m.footer.SetSize(m.width)
 }
@@ -262,18 +404,25 @@ func (m RootModel) View() string {
 	}
 
 	// Render columns
+	var middle string
+	switch m.middle {
+	case panelDisks:
+		middle = m.disk.View()
+	case panelFilesystems:
+		middle = m.fs.View()
+	case panelNetwork:
+		middle = m.net.View()
+	case panelSensors:
+		middle = m.sensors.View()
+	default:
+		middle = m.process.View()
+	}
 	cols := lipgloss.JoinHorizontal(lipgloss.Top,
 		m.gpu.View(),
-		m.process.View(),
+		middle,
 		m.cpu.View(),
 	)
 
-	// Add Footer
-	view := lipgloss.JoinVertical(lipgloss.Left,
-		cols,
-		m.footer.View(),
-	)
-
 	// Overlay Tooltip (in Footer)
 	if m.showTooltip && m.tooltipContent != "" {
 		// Re-rendering footer with tooltip content
@@ -289,12 +438,10 @@ func (m RootModel) View() string {
 	// This works!
 
 	// Re-join
-	view = lipgloss.JoinVertical(lipgloss.Left,
+	return lipgloss.JoinVertical(lipgloss.Left,
 		cols,
-		footerView,
+		m.footer.View(),
 	)
-
-	return view
 }
 
 func (m RootModel) getTooltipText() string {
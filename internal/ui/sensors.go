@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/omnitop/internal/metrics"
+)
+
+// sensorCritFraction is how close to its critical limit a reading has to be
+// to be highlighted, e.g. 90°C for a 100°C limit.
+const sensorCritFraction = 0.9
+
+// SensorsModel lists hardware sensors grouped by chip, highlighting readings
+// near their limits.
+type SensorsModel struct {
+	width   int
+	height  int
+	sensors []metrics.Sensor
+	Alert   bool
+	Stale   bool // Values come from an earlier sample
+
+	Renames map[string]string // Display name by "chip/input" or "chip/label"
+	Hidden  []string          // Glob patterns of sensors or chips to leave out
+}
+
+func NewSensorsModel() SensorsModel {
+	return SensorsModel{}
+}
+
+func (m SensorsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SensorsModel) Update(msg tea.Msg) (SensorsModel, tea.Cmd) {
+	return m, nil
+}
+
+// SetStats stores the visible sensors and raises the panel alert when any
+// of them is near a limit.
+func (m *SensorsModel) SetStats(sensors []metrics.Sensor) {
+	var visible []metrics.Sensor
+	alert := false
+	for _, s := range sensors {
+		if sensorHidden(s, m.Hidden) {
+			continue
+		}
+		visible = append(visible, s)
+		alert = alert || SensorNearLimit(s)
+	}
+	m.sensors = visible
+	m.Alert = alert
+}
+
+func (m *SensorsModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+func (m SensorsModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+
+	style := PanelStyle
+	if m.Alert {
+		style = AlertPanelStyle
+	}
+	style = style.Copy().Width(m.width).Height(m.height)
+
+	lines := []string{TitleStyle.Render(staleTitle(fmt.Sprintf("Sensors (%d)", len(m.sensors)), m.Stale))}
+	if len(m.sensors) == 0 {
+		lines = append(lines, MetricLabelStyle.Render("No hwmon sensors"))
+	}
+	chip := ""
+	for i, s := range m.sensors {
+		// A sensor takes one line, plus one for its chip's heading
+		need := 1
+		if s.Chip != chip {
+			need = 2
+		}
+		if len(lines)+need > m.height-2 {
+			lines = append(lines, MetricLabelStyle.Render(fmt.Sprintf("... %d more", len(m.sensors)-i)))
+			break
+		}
+		if s.Chip != chip {
+			chip = s.Chip
+			lines = append(lines, TitleStyle.Render(chip))
+		}
+		lines = append(lines, renderSensor(s, sensorName(s, m.Renames), m.width-4))
+	}
+
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// renderSensor draws one reading with its name on the left and its limits
+// on the right.
+func renderSensor(s metrics.Sensor, name string, width int) string {
+	value := formatSensorValue(s.Kind, s.Value)
+	limits := formatSensorLimits(s)
+	valueStyle := MetricValueStyle
+	if SensorNearLimit(s) {
+		valueStyle = AlertStyle
+	}
+	left := "  " + name
+	return lipgloss.JoinHorizontal(lipgloss.Left,
+		MetricLabelStyle.Render(left),
+		lipgloss.PlaceHorizontal(width-lipgloss.Width(left)-lipgloss.Width(value)-lipgloss.Width(limits), lipgloss.Right, " "),
+		valueStyle.Render(value),
+		MetricLabelStyle.Render(limits),
+	)
+}
+
+// SensorNearLimit reports whether a reading is close to its critical limit,
+// past its maximum, or below its minimum (a stalled fan, a sagging rail).
+func SensorNearLimit(s metrics.Sensor) bool {
+	switch {
+	case s.Crit > 0 && s.Value >= s.Crit*sensorCritFraction:
+		return true
+	case s.Max > 0 && s.Value >= s.Max:
+		return true
+	case s.Min > 0 && s.Value < s.Min:
+		return true
+	}
+	return false
+}
+
+// sensorName returns the configured name of a sensor, or its label.
+func sensorName(s metrics.Sensor, renames map[string]string) string {
+	if name, ok := renames[s.ID]; ok {
+		return name
+	}
+	if name, ok := renames[s.Chip+"/"+s.Label]; ok {
+		return name
+	}
+	return s.Label
+}
+
+// sensorHidden reports whether a sensor matches one of the hide patterns,
+// by input name or label, or by chip for patterns without a "/".
+func sensorHidden(s metrics.Sensor, patterns []string) bool {
+	for _, p := range patterns {
+		for _, name := range []string{s.ID, s.Chip + "/" + s.Label} {
+			if ok, _ := filepath.Match(p, name); ok {
+				return true
+			}
+		}
+		if ok, _ := filepath.Match(p, s.Chip); ok && !strings.Contains(p, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSensorValue renders a reading in the unit of its kind.
+func formatSensorValue(kind metrics.SensorKind, v float64) string {
+	switch kind {
+	case metrics.SensorTemp:
+		return fmt.Sprintf("%.1f°C", v)
+	case metrics.SensorFan:
+		return fmt.Sprintf("%.0f RPM", v)
+	case metrics.SensorVoltage:
+		return fmt.Sprintf("%.3f V", v)
+	case metrics.SensorCurrent:
+		return fmt.Sprintf("%.2f A", v)
+	case metrics.SensorPower:
+		return fmt.Sprintf("%.1f W", v)
+	}
+	return fmt.Sprintf("%g", v)
+}
+
+// formatSensorLimits lists the limits a chip reports, e.g. " (max 82 crit 85)".
+func formatSensorLimits(s metrics.Sensor) string {
+	var parts []string
+	if s.Min > 0 {
+		parts = append(parts, fmt.Sprintf("min %g", roundLimit(s.Min)))
+	}
+	if s.Max > 0 {
+		parts = append(parts, fmt.Sprintf("max %g", roundLimit(s.Max)))
+	}
+	if s.Crit > 0 {
+		parts = append(parts, fmt.Sprintf("crit %g", roundLimit(s.Crit)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, " ") + ")"
+}
+
+// roundLimit keeps two decimals for voltage-sized limits and none for
+// temperatures and fan speeds.
+func roundLimit(v float64) float64 {
+	if v >= 20 {
+		return float64(int(v + 0.5))
+	}
+	return float64(int(v*100+0.5)) / 100
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/omnitop/internal/metrics"
+)
+
+func TestSensorNearLimit(t *testing.T) {
+	cases := []struct {
+		sensor metrics.Sensor
+		want   bool
+	}{
+		{metrics.Sensor{Kind: metrics.SensorTemp, Value: 60, Max: 80, Crit: 100}, false},
+		{metrics.Sensor{Kind: metrics.SensorTemp, Value: 91, Crit: 100}, true},
+		{metrics.Sensor{Kind: metrics.SensorTemp, Value: 82, Min: -273.15, Max: 81.85, Crit: 84.85}, true},
+		{metrics.Sensor{Kind: metrics.SensorFan, Value: 0, Min: 300}, true},
+		{metrics.Sensor{Kind: metrics.SensorFan, Value: 0}, false}, // Unused header
+		{metrics.Sensor{Kind: metrics.SensorVoltage, Value: 3.3, Min: 2.98, Max: 3.63}, false},
+		{metrics.Sensor{Kind: metrics.SensorVoltage, Value: 2.9, Min: 2.98, Max: 3.63}, true},
+	}
+	for _, c := range cases {
+		if got := SensorNearLimit(c.sensor); got != c.want {
+			t.Errorf("SensorNearLimit(%+v) = %v, want %v", c.sensor, got, c.want)
+		}
+	}
+}
+
+func TestSensorsModelRenameAndHide(t *testing.T) {
+	sensors := []metrics.Sensor{
+		{ID: "acpitz-LNXTHERM:00/temp1", Chip: "acpitz-LNXTHERM:00", Label: "temp1", Kind: metrics.SensorTemp, Value: 27.8},
+		{ID: "nvme-nvme0/temp1", Chip: "nvme-nvme0", Label: "Composite", Kind: metrics.SensorTemp, Value: 41},
+		{ID: "nvme-nvme0/temp2", Chip: "nvme-nvme0", Label: "Sensor 1", Kind: metrics.SensorTemp, Value: 40},
+		{ID: "nct6798/fan2", Chip: "nct6798", Label: "fan2", Kind: metrics.SensorFan, Value: 0, Min: 300},
+	}
+
+	m := NewSensorsModel()
+	m.Hidden = []string{"acpitz*", "nvme-*/Sensor *"}
+	m.Renames = map[string]string{"nvme-nvme0/Composite": "Boot SSD", "nct6798/fan2": "Rear fan"}
+	m.SetSize(60, 20)
+	m.SetStats(sensors)
+
+	if len(m.sensors) != 2 {
+		t.Fatalf("Expected 2 visible sensors, got %+v", m.sensors)
+	}
+	if !m.Alert {
+		t.Error("Expected the stopped fan to raise the panel alert")
+	}
+	view := m.View()
+	for _, want := range []string{"Sensors (2)", "nvme-nvme0", "Boot SSD", "41.0°C", "Rear fan", "0 RPM (min 300)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "acpitz") || strings.Contains(view, "Sensor 1") {
+		t.Errorf("Hidden sensors shown:\n%s", view)
+	}
+}
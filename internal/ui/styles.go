@@ -14,6 +14,10 @@ const (
 	ColorSteelGray     = "#4C566A" // Panels/Borders
 	ColorPaleBlue      = "#8FBCBB" // Graphs/Normal Metrics
 	ColorBloodCrimson  = "#C41E3A" // Alerts/Errors
+	ColorRuneViolet    = "#B48EAD" // Secondary accents (E-cores)
+	ColorDeepBlue      = "#5E81AC" // Low-priority activity
+	ColorFrostGold     = "#EBCB8B" // Waiting (iowait)
+	ColorNorthrendMoss = "#A3BE8C" // Virtualization (guest)
 )
 
 var (
@@ -52,6 +56,30 @@ var (
 			Foreground(lipgloss.Color(ColorBloodCrimson)).
 			Bold(true)
 
+	// Hybrid CPU core labels
+	PCoreStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorIceBlue)).
+			Bold(true)
+
+	ECoreStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorRuneViolet))
+
+	// CPU time modes in stacked bars
+	CPUUserStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorPaleBlue))
+	CPUNiceStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorDeepBlue))
+	CPUSystemStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorIceBlue))
+	CPUIRQStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorRuneViolet))
+	CPUGuestStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorNorthrendMoss))
+	CPUStealStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorBloodCrimson))
+	CPUIOWaitStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorFrostGold))
+
+	// Memory composition in stacked bars
+	MemAppsStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorPaleBlue))
+	MemHugeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorRuneViolet))
+	MemSharedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorNorthrendMoss))
+	MemBuffersStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorDeepBlue))
+	MemCacheStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(ColorFrostGold))
+
 	// Bar styles
 	BarStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(ColorPaleBlue))